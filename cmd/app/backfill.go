@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"crypto-observer/internal/db"
+	"crypto-observer/internal/model"
+	"crypto-observer/internal/service"
+	"crypto-observer/pkg/config"
+	"crypto-observer/pkg/logger"
+)
+
+// runBackfill — разовая догрузка истории без HTTP-сервера:
+//
+//	app backfill -symbol btc -from 2024-01-01 [-to 2024-03-01] [-quote usd] [-id bitcoin]
+//
+// Задача пишется в backfill_jobs, так что её видно и в GET /currency/backfill работающего сервиса.
+// Прерванная по Ctrl+C задача остаётся в очереди и будет продолжена воркером сервиса.
+func runBackfill(args []string) int {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", "тикер, например btc")
+	quote := fs.String("quote", model.DefaultQuote, "валюта котировки")
+	fromS := fs.String("from", "", "начало диапазона: 2024-01-01, RFC 3339 или unix seconds")
+	toS := fs.String("to", "", "конец диапазона; по умолчанию — сейчас")
+	providerID := fs.String("id", "", "id монеты у CoinGecko, если тикер не сопоставлен")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	sym := strings.ToLower(strings.TrimSpace(*symbol))
+	from, errFrom := parseTime(*fromS)
+	to, errTo := parseTime(*toS)
+	if sym == "" || *fromS == "" || errFrom != nil || errTo != nil {
+		fmt.Fprintln(os.Stderr, "backfill: -symbol and a valid -from are required")
+		fs.Usage()
+		return 2
+	}
+
+	cfg := config.MustLoad()
+	logger.Init()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	st, err := db.NewStorage(cfg.DB.DSN)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "backfill: storage init failed:", err)
+		return 1
+	}
+	defer st.Close()
+
+	id := strings.TrimSpace(*providerID)
+	if id == "" {
+		if id, err = lookupProviderID(ctx, st, cfg, sym); err != nil {
+			fmt.Fprintln(os.Stderr, "backfill:", err)
+			return 1
+		}
+	}
+
+	b := service.NewBackfiller(st, newCoingeckoClient(cfg), backfillOptions(cfg))
+	j, err := b.RunNow(ctx, model.BackfillJob{
+		Symbol: sym, ProviderID: id, Quote: strings.ToLower(strings.TrimSpace(*quote)), From: from, To: to,
+	})
+	if j != nil {
+		fmt.Printf("job %d %s: fetched %d, inserted %d, skipped %d\n", j.ID, j.State, j.Fetched, j.Inserted, j.Fetched-j.Inserted)
+	}
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidBackfill) {
+			fmt.Fprintln(os.Stderr, "backfill:", err, "(use -id)")
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, "backfill:", err)
+		return 1
+	}
+	return 0
+}
+
+// lookupProviderID ищет id монеты так же, как сервис: админские соответствия из БД,
+// id из watchlist, затем файл symbols.file и встроенная карта.
+func lookupProviderID(ctx context.Context, st *db.Storage, cfg *config.Config, symbol string) (string, error) {
+	mappings, err := st.ListSymbolMappings(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, m := range mappings {
+		if m.Symbol == symbol {
+			return m.ProviderID, nil
+		}
+	}
+	items, err := st.ListWatchlist(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, w := range items {
+		if w.Symbol == symbol && w.ProviderID != "" {
+			return w.ProviderID, nil
+		}
+	}
+	symbols := service.NewSymbolRegistry()
+	if path := cfg.Symbols.File; path != "" {
+		if _, err := symbols.LoadFile(path); err != nil {
+			return "", err
+		}
+	}
+	if m, ok := symbols.Lookup(symbol); ok {
+		return m.ProviderID, nil
+	}
+	return "", nil
+}
+
+// parseTime — "2024-01-01", RFC 3339 или unix seconds; пустая строка — 0.
+func parseTime(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t.Unix(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, fmt.Errorf("want YYYY-MM-DD, RFC 3339 or unix seconds, got %q", s)
+	}
+	return t.Unix(), nil
+}
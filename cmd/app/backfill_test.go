@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestParseTime(t *testing.T) {
+	for in, want := range map[string]int64{
+		"":                          0,
+		"1704067200":                1704067200,
+		"2024-01-01":                1704067200,
+		"2024-01-01T03:00:00Z":      1704078000,
+		"2024-01-01T05:00:00+02:00": 1704078000,
+	} {
+		got, err := parseTime(in)
+		if err != nil || got != want {
+			t.Fatalf("parseTime(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := parseTime("yesterday"); err == nil {
+		t.Fatalf("want error on bad date")
+	}
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,13 +11,23 @@ import (
 	"time"
 
 	"crypto-observer/internal/api"
+	"crypto-observer/internal/binance"
+	"crypto-observer/internal/coinbase"
+	"crypto-observer/internal/coingecko"
 	"crypto-observer/internal/db"
+	"crypto-observer/internal/kraken"
+	"crypto-observer/internal/metrics"
 	"crypto-observer/internal/service"
 	"crypto-observer/pkg/config"
 	"crypto-observer/pkg/logger"
 )
 
 func main() {
+	// разовые команды без HTTP-сервера
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		os.Exit(runBackfill(os.Args[2:]))
+	}
+
 	// 1) конфиг + логгер
 	cfg := config.MustLoad() // паникует, если конфига нет/битый
 	logger.Init()            // читает уровень из cfg.Log.Level внутри
@@ -33,16 +44,73 @@ func main() {
 	}
 	defer st.Close()
 
+	metrics.Registry.MustRegister(metrics.NewPoolCollector(st.PoolStat))
+
 	// 4) сервис
-	svc := service.NewService(
-		st,
-		cfg.Collector.DefaultPeriodSeconds,
-		cfg.Coingecko.BaseURL,
-		time.Duration(cfg.Coingecko.TimeoutSec)*time.Second,
-	)
+	cgClient := newCoingeckoClient(cfg)
+	// тикер → id CoinGecko: встроенная карта, затем файл; админские записи из БД — в Restore
+	symbols := service.NewSymbolRegistry()
+	if path := cfg.Symbols.File; path != "" {
+		n, err := symbols.LoadFile(path)
+		if err != nil {
+			log.WithError(err).Fatal("symbols file load failed")
+		}
+		log.WithField("count", n).Info("symbols file loaded")
+	}
+
+	providers := service.NewProviderRegistry()
+	svc := service.NewService(st, cfg.Collector.DefaultPeriodSeconds, providers, symbols)
+	// пакетные запросы живут, пока живут коллекторы: до svc.Shutdown, а не до сигнала
+	if err := buildProviders(svc.Context(), cfg, cgClient, symbols, providers); err != nil {
+		log.WithError(err).Fatal("price providers init failed")
+	}
+
+	// справочник монет CoinGecko для проверки тикеров в /currency/add
+	if m := cfg.Coingecko.CoinsRefreshMin; m > 0 {
+		catalog := service.NewCoinCatalog(cgClient, time.Duration(m)*time.Minute)
+		catalog.Start(ctx)
+		svc.UseCatalog(catalog)
+	}
+
+	// исходящие вебхуки: очередь в БД, воркер доставки живёт до сигнала остановки
+	if wh := cfg.Webhooks; len(wh.Endpoints) > 0 {
+		endpoints := make([]service.WebhookEndpoint, 0, len(wh.Endpoints))
+		for _, e := range wh.Endpoints {
+			endpoints = append(endpoints, service.WebhookEndpoint{Name: e.Name, URL: e.URL, Secret: e.Secret, Events: e.Events})
+		}
+		webhooks := service.NewWebhooks(st, endpoints, service.WebhookOptions{
+			MaxAttempts: wh.MaxAttempts,
+			Backoff:     time.Duration(wh.BackoffSec) * time.Second,
+			MaxBackoff:  time.Duration(wh.MaxBackoffSec) * time.Second,
+			Timeout:     time.Duration(wh.TimeoutSec) * time.Second,
+			Poll:        time.Duration(wh.PollMs) * time.Millisecond,
+		})
+		webhooks.Start(ctx)
+		svc.UseWebhooks(webhooks)
+	}
+
+	// догрузка истории из market_chart: очередь в БД, задачи выполняются по одной
+	backfill := service.NewBackfiller(st, cgClient, backfillOptions(cfg))
+	backfill.Start(ctx)
+	svc.UseBackfill(backfill)
+
+	// аудит разрывов в истории: пороги для /currency/gaps и, по желанию, фоновый проход с догрузкой
+	gc := cfg.Gaps
+	svc.StartGapAudit(ctx, service.GapAuditOptions{
+		Factor:   gc.Factor,
+		Lookback: time.Duration(gc.LookbackH) * time.Hour,
+		Interval: time.Duration(gc.IntervalMin) * time.Minute,
+		Backfill: gc.Backfill,
+	})
+
+	// возобновляем сбор по сохранённому watchlist (и подтягиваем соответствия тикеров)
+	if err := svc.Restore(ctx); err != nil {
+		log.WithError(err).Error("watchlist restore failed")
+	}
 
 	// 5) http router
-	r := api.NewRouter(api.NewHandler(svc))
+	handler := api.NewHandler(svc)
+	r := api.NewRouter(handler)
 
 	// 6) http server
 	srv := &http.Server{
@@ -52,6 +120,8 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// Shutdown не отменяет контексты запросов: бесконечные потоки цен закрываем сами
+	srv.RegisterOnShutdown(handler.CloseStreams)
 
 	go func() {
 		log.WithField("addr", cfg.Server.Addr).Info("HTTP server listening")
@@ -64,10 +134,91 @@ func main() {
 	<-ctx.Done()
 	log.Info("shutdown started")
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	_ = srv.Shutdown(shutdownCtx)
+	httpCtx, cancelHTTP := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelHTTP()
+	if err := srv.Shutdown(httpCtx); err != nil {
+		log.WithError(err).Error("HTTP server did not stop in time")
+	}
+	// HTTP больше не принимает запросы — останавливаем коллекторы и дожидаемся начатых сохранений;
+	// свой таймаут, чтобы долгий HTTP не съел время на сохранения
+	svcCtx, cancelSvc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelSvc()
+	if err := svc.Shutdown(svcCtx); err != nil {
+		log.WithError(err).Error("collectors did not stop in time")
+	}
 
 	log.Info("shutdown complete")
 	os.Exit(0)
 }
+
+func newCoingeckoClient(cfg *config.Config) *coingecko.Client {
+	cgc := cfg.Coingecko
+	return coingecko.NewWithOptions(cgc.BaseURL, coingecko.Options{
+		Timeout:     time.Duration(cgc.TimeoutSec) * time.Second,
+		MaxRetries:  cgc.MaxRetries,
+		BaseBackoff: time.Duration(cgc.BackoffMs) * time.Millisecond,
+		MaxBackoff:  time.Duration(cgc.MaxBackoffMs) * time.Millisecond,
+		RatePerMin:  cgc.RatePerMin,
+		Burst:       cgc.Burst,
+	})
+}
+
+func backfillOptions(cfg *config.Config) service.BackfillOptions {
+	bf := cfg.Backfill
+	return service.BackfillOptions{
+		Chunk: time.Duration(bf.ChunkDays) * 24 * time.Hour,
+		Batch: bf.BatchSize,
+	}
+}
+
+// buildProviders регистрирует в reg CoinGecko (с пакетной склейкой запросов, её запросы живут до отмены ctx)
+// и те биржи, у которых задан base_url, затем применяет провайдера по умолчанию и привязки тикеров из конфига.
+func buildProviders(ctx context.Context, cfg *config.Config, cgClient *coingecko.Client, symbols *service.SymbolRegistry, reg *service.ProviderRegistry) error {
+	cg := service.NewCoingeckoProvider(cgClient, symbols)
+	if w := cfg.Collector.BatchWindowMs; w > 0 {
+		reg.Register("coingecko", service.NewBatcher(ctx, cg, time.Duration(w)*time.Millisecond))
+	} else {
+		reg.Register("coingecko", cg)
+	}
+	if p := cfg.Binance; p.BaseURL != "" {
+		reg.Register("binance", binance.New(p.BaseURL, time.Duration(p.TimeoutSec)*time.Second))
+	}
+	if p := cfg.Kraken; p.BaseURL != "" {
+		reg.Register("kraken", kraken.New(p.BaseURL, time.Duration(p.TimeoutSec)*time.Second))
+	}
+	if p := cfg.Coinbase; p.BaseURL != "" {
+		reg.Register("coinbase", coinbase.New(p.BaseURL, time.Duration(p.TimeoutSec)*time.Second))
+	}
+
+	if c := cfg.Consensus; len(c.Providers) > 0 {
+		members := make(map[string]service.PriceProvider, len(c.Providers))
+		for _, name := range c.Providers {
+			p, ok := reg.Lookup(name)
+			if !ok {
+				return fmt.Errorf("consensus: unknown price provider %q", name)
+			}
+			members[name] = p
+		}
+		cons, err := service.NewConsensus(members, service.ConsensusOptions{
+			Method:          c.Method,
+			MinSources:      c.MinSources,
+			MaxDeviationPct: c.MaxDeviationPct,
+		})
+		if err != nil {
+			return err
+		}
+		reg.Register("consensus", cons)
+	}
+
+	if cfg.Providers.Default != "" {
+		if err := reg.SetDefault(cfg.Providers.Default); err != nil {
+			return err
+		}
+	}
+	for sym, name := range cfg.Providers.Symbols {
+		if err := reg.Assign(sym, name); err != nil {
+			return fmt.Errorf("providers.symbols: %w", err)
+		}
+	}
+	return nil
+}
@@ -15,9 +15,30 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/currency/add": {
+        "/alerts": {
+            "get": {
+                "description": "Все правила оповещения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "List alert rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AlertDTO"
+                            }
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Начать отслеживать цену криптовалюты",
+                "description": "Создать правило оповещения по цене",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,23 +46,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "currency"
+                    "alerts"
                 ],
-                "summary": "Add a cryptocurrency to watchlist",
+                "summary": "Create an alert rule",
                 "parameters": [
                     {
-                        "description": "Symbol and period",
+                        "description": "Alert rule",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.AddCurrencyRequest"
+                            "$ref": "#/definitions/model.AlertReq"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.AlertDTO"
+                        }
                     },
                     "400": {
                         "description": "Bad request",
@@ -52,9 +76,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/currency/price": {
+        "/alerts/{id}": {
             "get": {
-                "description": "Получить цену валюты на момент времени",
+                "description": "Правило и его состояние",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Get an alert rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Alert id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AlertDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменить правило; состояние сбрасывается в armed",
                 "consumes": [
                     "application/json"
                 ],
@@ -62,22 +125,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "currency"
+                    "alerts"
                 ],
-                "summary": "Get price by symbol and timestamp",
+                "summary": "Replace an alert rule",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Symbol",
-                        "name": "symbol",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "Alert id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
+                    {
+                        "description": "Alert rule",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AlertReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AlertDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удалить правило",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Delete an alert rule",
+                "parameters": [
                     {
                         "type": "integer",
-                        "description": "Timestamp (unix)",
-                        "name": "timestamp",
-                        "in": "query",
+                        "description": "Alert id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -85,7 +189,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.PriceResponseonse"
+                            "$ref": "#/definitions/model.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "404": {
@@ -97,9 +207,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/currency/remove": {
+        "/currency/add": {
             "post": {
-                "description": "Прекратить отслеживать цену криптовалюты",
+                "description": "Начать отслеживать цену криптовалюты",
                 "consumes": [
                     "application/json"
                 ],
@@ -109,15 +219,15 @@ const docTemplate = `{
                 "tags": [
                     "currency"
                 ],
-                "summary": "Remove a cryptocurrency from watchlist",
+                "summary": "Add a cryptocurrency to watchlist",
                 "parameters": [
                     {
-                        "description": "Symbol",
+                        "description": "Symbol and period",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.RemoveCurrencyRequest"
+                            "$ref": "#/definitions/model.AddCurrencyRequest"
                         }
                     }
                 ],
@@ -133,38 +243,1140 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.AddCurrencyRequest": {
-            "type": "object",
-            "properties": {
-                "period": {
-                    "type": "integer"
-                },
-                "symbol": {
-                    "type": "string"
+        },
+        "/currency/backfill": {
+            "get": {
+                "description": "Задачи догрузки истории, новые сначала",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backfill"
+                ],
+                "summary": "List backfill jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbol, empty — all",
+                        "name": "symbol",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BackfillJobDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Backfill unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Поставить в очередь догрузку цен за [from, to] из market_chart/range",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backfill"
+                ],
+                "summary": "Start a backfill job",
+                "parameters": [
+                    {
+                        "description": "Range",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.BackfillReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.BackfillJobDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Ambiguous symbol",
+                        "schema": {
+                            "$ref": "#/definitions/model.SymbolErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Backfill unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
                 }
             }
         },
-        "model.PriceResponseonse": {
-            "type": "object",
-            "properties": {
-                "coin": {
-                    "type": "string"
-                },
-                "price": {
-                    "type": "number"
-                },
-                "timestamp": {
-                    "type": "integer"
+        "/currency/backfill/{id}": {
+            "get": {
+                "description": "Состояние и прогресс задачи догрузки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "backfill"
+                ],
+                "summary": "Get a backfill job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Job id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BackfillJobDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Backfill unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
                 }
             }
         },
-        "model.RemoveCurrencyRequest": {
-            "type": "object",
-            "properties": {
-                "symbol": {
+        "/currency/candles": {
+            "get": {
+                "description": "Свечи OHLC по сохранённым ценам; без from — последние 100",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currency"
+                ],
+                "summary": "Get OHLC candles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbol",
+                        "name": "symbol",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1m, 5m, 1h or 1d",
+                        "name": "interval",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Quote currency, default usd",
+                        "name": "quote",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "From (unix)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "To (unix), default now",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CandlesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/currency/gaps": {
+            "get": {
+                "description": "Разрывы в истории тикера за аудиторский lookback по всем валютам котировки, по его текущему расписанию",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currency"
+                ],
+                "summary": "Find gaps in price history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbol",
+                        "name": "symbol",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.GapDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not tracked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/currency/history": {
+            "get": {
+                "description": "История цен за диапазон по возрастанию timestamp, постранично",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currency"
+                ],
+                "summary": "Get price history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbol",
+                        "name": "symbol",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Quote currency, default usd",
+                        "name": "quote",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "From (unix)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "To (unix), default now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, default 100, max 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.HistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/currency/list": {
+            "get": {
+                "description": "Состояние всех коллекторов по алфавиту, включая остановленные",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currency"
+                ],
+                "summary": "List tracked currencies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.CollectorStatusDTO"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/currency/price": {
+            "get": {
+                "description": "Получить цену валюты на момент времени",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currency"
+                ],
+                "summary": "Get price by symbol and timestamp",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbol",
+                        "name": "symbol",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Timestamp (unix)",
+                        "name": "timestamp",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PriceResponseonse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/currency/remove": {
+            "post": {
+                "description": "Прекратить отслеживать цену криптовалюты",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currency"
+                ],
+                "summary": "Remove a cryptocurrency from watchlist",
+                "parameters": [
+                    {
+                        "description": "Symbol",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RemoveCurrencyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/currency/stream": {
+            "get": {
+                "description": "Каждая сохранённая цена — событие price с data model.PriceDTO и id \"<timestamp>-<id строки>\". По Last-Event-ID досылаются цены после него по (ts, id)",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "Stream prices over Server-Sent Events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbols, comma separated",
+                        "name": "symbols",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last received event id: ts-id, or a bare ts",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PriceDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/currency/{symbol}": {
+            "patch": {
+                "description": "Поменять расписание и/или валюты котировки работающего коллектора без перезапуска; пропущенные поля не меняются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currency"
+                ],
+                "summary": "Update a tracked currency",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Symbol",
+                        "name": "symbol",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpdateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CollectorStatusDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not tracked or removed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries": {
+            "get": {
+                "description": "Журнал доставок вебхуков, новые сначала",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, delivered or failed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Default 50, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDeliveryDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws/prices": {
+            "get": {
+                "description": "Поток сохранённых цен. Клиент шлёт model.WSRequest, сервер — model.WSMessage",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "Stream prices over WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Initial symbols, comma separated",
+                        "name": "symbols",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/model.WSMessage"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.AddCurrencyRequest": {
+            "type": "object",
+            "properties": {
+                "period": {
+                    "type": "integer"
+                },
+                "symbol": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AlertDTO": {
+            "type": "object",
+            "properties": {
+                "cooldown_sec": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "integer"
+                },
+                "fired_at": {
+                    "type": "integer"
+                },
+                "fired_price": {
+                    "description": "Десятичная строка",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "quote": {
+                    "type": "string"
+                },
+                "state": {
+                    "description": "armed, fired или cooldown",
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "description": "Десятичная строка",
+                    "type": "string"
+                },
+                "window_sec": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AlertReq": {
+            "type": "object",
+            "properties": {
+                "cooldown_sec": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "above, below или change",
+                    "type": "string"
+                },
+                "quote": {
+                    "description": "По умолчанию usd",
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "description": "Цена или процент изменения (change), десятичная строка",
+                    "type": "string",
+                    "example": "30000"
+                },
+                "window_sec": {
+                    "description": "Окно для change, секунды",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.BackfillJobDTO": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fetched": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "inserted": {
+                    "type": "integer"
+                },
+                "progress": {
+                    "description": "Доля пройденного диапазона, 0..1",
+                    "type": "number"
+                },
+                "provider_id": {
+                    "type": "string"
+                },
+                "quote": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "description": "Дубли уже сохранённых цен",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "integer"
+                },
+                "state": {
+                    "description": "pending, running, done или failed",
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.BackfillReq": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "Unix seconds",
+                    "type": "integer"
+                },
+                "provider_id": {
+                    "description": "Явный id CoinGecko, если тикер неоднозначен",
+                    "type": "string"
+                },
+                "quote": {
+                    "description": "По умолчанию usd",
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "to": {
+                    "description": "Unix seconds; по умолчанию — сейчас",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CandleDTO": {
+            "type": "object",
+            "properties": {
+                "close": {
+                    "description": "Десятичная строка",
+                    "type": "string"
+                },
+                "count": {
+                    "description": "Сколько цен вошло в свечу",
+                    "type": "integer"
+                },
+                "high": {
+                    "description": "Десятичная строка",
+                    "type": "string"
+                },
+                "low": {
+                    "description": "Десятичная строка",
+                    "type": "string"
+                },
+                "open": {
+                    "description": "Десятичная строка",
+                    "type": "string",
+                    "example": "29150.32"
+                },
+                "timestamp": {
+                    "description": "Начало свечи (unix)",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CandlesResponse": {
+            "type": "object",
+            "properties": {
+                "candles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CandleDTO"
+                    }
+                },
+                "coin": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "1h"
+                },
+                "quote": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CoinRef": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CollectorStatusDTO": {
+            "type": "object",
+            "properties": {
+                "align": {
+                    "type": "boolean"
+                },
+                "coin": {
+                    "type": "string"
+                },
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "cron": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "last_fetch": {
+                    "description": "Unix-время последнего тика",
+                    "type": "integer"
+                },
+                "last_price": {
+                    "description": "Последняя сохранённая цена по валютам котировки, десятичные строки",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "period_sec": {
+                    "description": "Текущий период опроса",
+                    "type": "integer"
+                },
+                "quotes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "running": {
+                    "description": "false — снят через /currency/remove",
+                    "type": "boolean"
+                },
+                "state": {
+                    "description": "pending, ok, error или unknown_coin",
+                    "type": "string"
+                },
+                "windows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ScheduleWindowDTO"
+                    }
+                }
+            }
+        },
+        "model.GapDTO": {
+            "type": "object",
+            "properties": {
+                "backfill_job_id": {
+                    "description": "Задача догрузки, покрывающая разрыв",
+                    "type": "integer"
+                },
+                "duration_sec": {
+                    "type": "integer"
+                },
+                "from": {
+                    "description": "ts последней цены перед разрывом",
+                    "type": "integer"
+                },
+                "missed": {
+                    "description": "Сколько тиков расписания пропущено",
+                    "type": "integer"
+                },
+                "quote": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "to": {
+                    "description": "ts первой цены после разрыва",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.HistoryResponse": {
+            "type": "object",
+            "properties": {
+                "coin": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PriceDTO"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Курсор следующей страницы; нет — страница последняя",
+                    "type": "string"
+                },
+                "quote": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PriceDTO": {
+            "type": "object",
+            "properties": {
+                "coin": {
+                    "type": "string"
+                },
+                "price": {
+                    "description": "Точная десятичная строка",
+                    "type": "string",
+                    "example": "0.00001234"
+                },
+                "quote": {
+                    "type": "string",
+                    "example": "usd"
+                },
+                "sources": {
+                    "description": "Сколько источников вошло в цену (консенсус)",
+                    "type": "integer"
+                },
+                "spread": {
+                    "description": "Разброс max - min между источниками, десятичная строка",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.PriceResponseonse": {
+            "type": "object",
+            "properties": {
+                "coin": {
+                    "type": "string"
+                },
+                "price": {
+                    "description": "Точная десятичная строка",
+                    "type": "string",
+                    "example": "0.00001234"
+                },
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.RemoveCurrencyRequest": {
+            "type": "object",
+            "properties": {
+                "symbol": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ScheduleWindowDTO": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "from": {
+                    "description": "HH:MM",
+                    "type": "string",
+                    "example": "09:00"
+                },
+                "period": {
+                    "description": "Секунды",
+                    "type": "integer"
+                },
+                "to": {
+                    "description": "HH:MM, позже from",
+                    "type": "string",
+                    "example": "18:00"
+                },
+                "tz": {
+                    "description": "По умолчанию UTC",
+                    "type": "string"
+                }
+            }
+        },
+        "model.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "model.SymbolErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "suggestions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CoinRef"
+                    }
+                }
+            }
+        },
+        "model.UpdateReq": {
+            "type": "object",
+            "properties": {
+                "align": {
+                    "description": "Тики на границах периода",
+                    "type": "boolean"
+                },
+                "cron": {
+                    "description": "Новое cron-выражение; пустая строка — вернуться к периоду",
+                    "type": "string"
+                },
+                "period": {
+                    "description": "Новый период опроса, секунды",
+                    "type": "integer"
+                },
+                "quotes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "windows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ScheduleWindowDTO"
+                    }
+                }
+            }
+        },
+        "model.WSMessage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/model.PriceDTO"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "symbols": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "description": "subscribed, price или error",
+                    "type": "string"
+                }
+            }
+        },
+        "model.WSRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "subscribe или unsubscribe",
+                    "type": "string"
+                },
+                "symbols": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.WebhookDeliveryDTO": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "integer"
+                },
+                "delivered_at": {
+                    "type": "integer"
+                },
+                "endpoint": {
+                    "description": "Имя получателя из конфига",
+                    "type": "string"
+                },
+                "event": {
+                    "description": "alert.fired или collector.error",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_code": {
+                    "description": "HTTP-код последней попытки",
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "description": "Когда будет следующая попытка (pending)",
+                    "type": "integer"
+                },
+                "payload": {
+                    "description": "Тело события, как его получил получатель",
+                    "type": "object"
+                },
+                "status": {
+                    "description": "pending, delivered или failed",
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
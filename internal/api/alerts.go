@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	list := h.service.ListAlerts()
+	out := make([]model.AlertDTO, 0, len(list))
+	for _, a := range list {
+		out = append(out, toAlertDTO(a))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) CreateAlert(w http.ResponseWriter, r *http.Request) {
+	var req model.AlertReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.L().WithError(err).Warn("CreateAlert: bad request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a, err := h.service.CreateAlert(req)
+	if err != nil {
+		writeAlertError(w, "CreateAlert", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toAlertDTO(*a))
+}
+
+func (h *Handler) GetAlert(w http.ResponseWriter, r *http.Request) {
+	id, ok := alertID(w, r)
+	if !ok {
+		return
+	}
+	a, err := h.service.GetAlert(id)
+	if err != nil {
+		writeAlertError(w, "GetAlert", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAlertDTO(*a))
+}
+
+// UpdateAlert заменяет условие правила; правило взводится заново.
+func (h *Handler) UpdateAlert(w http.ResponseWriter, r *http.Request) {
+	id, ok := alertID(w, r)
+	if !ok {
+		return
+	}
+	var req model.AlertReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.L().WithError(err).Warn("UpdateAlert: bad request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a, err := h.service.UpdateAlert(id, req)
+	if err != nil {
+		writeAlertError(w, "UpdateAlert", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAlertDTO(*a))
+}
+
+func (h *Handler) DeleteAlert(w http.ResponseWriter, r *http.Request) {
+	id, ok := alertID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteAlert(id); err != nil {
+		writeAlertError(w, "DeleteAlert", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, model.StatusOK())
+}
+
+func alertID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid alert id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+func writeAlertError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidAlert), errors.Is(err, model.ErrInvalidQuote):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrAlertNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		logger.L().WithError(err).Error(op + ": service failed")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+func toAlertDTO(a model.Alert) model.AlertDTO {
+	dto := model.AlertDTO{
+		ID:          a.ID,
+		Symbol:      a.Symbol,
+		Quote:       a.Quote,
+		Kind:        a.Kind,
+		Threshold:   a.Threshold,
+		WindowSec:   a.Window,
+		CooldownSec: a.Cooldown,
+		State:       a.State,
+		FiredAt:     a.FiredAt,
+		CreatedAt:   a.CreatedAt,
+	}
+	if a.FiredAt != 0 {
+		dto.FiredPrice = &a.FiredPrice
+	}
+	return dto
+}
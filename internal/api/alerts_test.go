@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlerts_Create(t *testing.T) {
+	f := &fakeService{alertResp: &model.Alert{
+		ID: 5, Symbol: "btc", Quote: "usd", Kind: model.AlertAbove,
+		Threshold: model.MustDecimal("70000.5"), State: model.AlertArmed, CreatedAt: 1,
+	}}
+	rr := do(f, http.MethodPost, "/alerts", `{"symbol":"btc","kind":"above","threshold":"70000.5","cooldown_sec":600}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Equal(t, "70000.5", f.gotAlert.Threshold.String())
+	require.Equal(t, int64(600), f.gotAlert.CooldownSec)
+
+	var got model.AlertDTO
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, int64(5), got.ID)
+	require.Equal(t, "armed", got.State)
+	require.Nil(t, got.FiredPrice, "never fired")
+	require.Contains(t, rr.Body.String(), `"threshold":"70000.5"`)
+}
+
+func TestAlerts_Errors(t *testing.T) {
+	f := &fakeService{alertErr: fmt.Errorf("%w: unknown kind", model.ErrInvalidAlert)}
+	require.Equal(t, http.StatusBadRequest, do(f, http.MethodPost, "/alerts", `{"symbol":"btc","kind":"x"}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(f, http.MethodPost, "/alerts", `{`).Code)
+
+	f.alertErr = model.ErrAlertNotFound
+	require.Equal(t, http.StatusNotFound, do(f, http.MethodGet, "/alerts/9", "").Code)
+	require.Equal(t, http.StatusNotFound, do(f, http.MethodDelete, "/alerts/9", "").Code)
+	require.Equal(t, http.StatusBadRequest, do(f, http.MethodGet, "/alerts/abc", "").Code)
+
+	f.alertErr = fmt.Errorf("db down")
+	require.Equal(t, http.StatusInternalServerError, do(f, http.MethodGet, "/alerts/9", "").Code)
+}
+
+func TestAlerts_GetUpdateDeleteList(t *testing.T) {
+	f := &fakeService{alertResp: &model.Alert{
+		ID: 3, Symbol: "eth", Quote: "usd", Kind: model.AlertChange, Threshold: model.MustDecimal("-5"),
+		Window: 3600, State: model.AlertFired, FiredAt: 100, FiredPrice: model.MustDecimal("1900"),
+	}}
+
+	rr := do(f, http.MethodGet, "/alerts/3", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, int64(3), f.gotID)
+	require.Contains(t, rr.Body.String(), `"fired_price":"1900"`)
+
+	rr = do(f, http.MethodPut, "/alerts/3", `{"symbol":"eth","kind":"change","threshold":-7,"window_sec":7200}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "-7", f.gotAlert.Threshold.String())
+	require.Equal(t, int64(7200), f.gotAlert.WindowSec)
+
+	f.gotID = 0
+	require.Equal(t, http.StatusOK, do(f, http.MethodDelete, "/alerts/3", "").Code)
+	require.Equal(t, int64(3), f.gotID)
+
+	rr = do(f, http.MethodGet, "/alerts", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var list []model.AlertDTO
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	require.Equal(t, int64(3600), list[0].WindowSec)
+}
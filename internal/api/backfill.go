@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// StartBackfill — POST /currency/backfill: ставит догрузку истории в очередь, 202 с задачей.
+func (h *Handler) StartBackfill(w http.ResponseWriter, r *http.Request) {
+	var req model.BackfillReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.L().WithError(err).Warn("StartBackfill: bad request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	j, err := h.service.StartBackfill(req)
+	if err != nil {
+		writeBackfillError(w, "StartBackfill", err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, toBackfillJobDTO(*j))
+}
+
+// GetBackfill — GET /currency/backfill/{id}: состояние и прогресс задачи.
+func (h *Handler) GetBackfill(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return
+	}
+	j, err := h.service.BackfillJob(id)
+	if err != nil {
+		writeBackfillError(w, "GetBackfill", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toBackfillJobDTO(*j))
+}
+
+// ListBackfills — GET /currency/backfill?symbol=btc&limit=50: задачи, новые сначала.
+func (h *Handler) ListBackfills(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	list, err := h.service.BackfillJobs(r.URL.Query().Get("symbol"), limit)
+	if err != nil {
+		writeBackfillError(w, "ListBackfills", err)
+		return
+	}
+	out := make([]model.BackfillJobDTO, 0, len(list))
+	for _, j := range list {
+		out = append(out, toBackfillJobDTO(j))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func writeBackfillError(w http.ResponseWriter, op string, err error) {
+	var symErr *model.SymbolError
+	switch {
+	case errors.Is(err, model.ErrInvalidBackfill), errors.Is(err, model.ErrInvalidQuote):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, model.ErrBackfillNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, model.ErrBackfillUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.As(err, &symErr):
+		writeJSON(w, http.StatusUnprocessableEntity, model.SymbolErrorResponse{
+			Error:       symErr.Error(),
+			Suggestions: symErr.Suggestions,
+		})
+	default:
+		logger.L().WithError(err).Error(op + ": service failed")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+func toBackfillJobDTO(j model.BackfillJob) model.BackfillJobDTO {
+	dto := model.BackfillJobDTO{
+		ID:         j.ID,
+		Symbol:     j.Symbol,
+		ProviderID: j.ProviderID,
+		Quote:      j.Quote,
+		From:       j.From,
+		To:         j.To,
+		State:      j.State,
+		Fetched:    j.Fetched,
+		Inserted:   j.Inserted,
+		Skipped:    j.Fetched - j.Inserted,
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		StartedAt:  j.StartedAt,
+		FinishedAt: j.FinishedAt,
+	}
+	switch {
+	case j.State == model.BackfillDone:
+		dto.Progress = 1
+	case j.To > j.From:
+		dto.Progress = float64(j.Cursor-j.From) / float64(j.To-j.From)
+	}
+	return dto
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfill_Start(t *testing.T) {
+	f := &fakeService{backfillResp: &model.BackfillJob{
+		ID: 7, Symbol: "btc", ProviderID: "bitcoin", Quote: "usd", From: 1000, To: 5000, Cursor: 1000,
+		State: model.BackfillPending, CreatedAt: 6000,
+	}}
+	rr := do(f, http.MethodPost, "/currency/backfill", `{"symbol":"btc","from":1000,"to":5000}`)
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	require.Equal(t, model.BackfillReq{Symbol: "btc", From: 1000, To: 5000}, f.gotBackfill)
+	require.JSONEq(t, `{"id":7,"symbol":"btc","provider_id":"bitcoin","quote":"usd","from":1000,"to":5000,
+		"state":"pending","progress":0,"fetched":0,"inserted":0,"skipped":0,"created_at":6000}`, rr.Body.String())
+}
+
+func TestBackfill_GetAndList(t *testing.T) {
+	f := &fakeService{backfillResp: &model.BackfillJob{
+		ID: 3, Symbol: "eth", Quote: "usd", From: 1000, To: 5000, Cursor: 2000,
+		State: model.BackfillRunning, Fetched: 40, Inserted: 30, StartedAt: 6000,
+	}}
+	rr := do(f, http.MethodGet, "/currency/backfill/3", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, int64(3), f.gotID)
+	var got model.BackfillJobDTO
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, 0.25, got.Progress)
+	require.Equal(t, 10, got.Skipped)
+
+	rr = do(f, http.MethodGet, "/currency/backfill?symbol=eth", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var list []model.BackfillJobDTO
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+
+	f.backfillResp.State = model.BackfillDone
+	rr = do(f, http.MethodGet, "/currency/backfill/3", "")
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Equal(t, 1.0, got.Progress)
+}
+
+func TestBackfill_Errors(t *testing.T) {
+	f := &fakeService{backfillErr: fmt.Errorf("%w: from is required", model.ErrInvalidBackfill)}
+	require.Equal(t, http.StatusBadRequest, do(f, http.MethodPost, "/currency/backfill", `{"symbol":"btc"}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(f, http.MethodPost, "/currency/backfill", `{`).Code)
+	require.Equal(t, http.StatusBadRequest, do(f, http.MethodGet, "/currency/backfill/x", "").Code)
+	require.Equal(t, http.StatusBadRequest, do(f, http.MethodGet, "/currency/backfill?limit=x", "").Code)
+
+	f.backfillErr = &model.SymbolError{Symbol: "uni", Err: model.ErrAmbiguousSymbol}
+	require.Equal(t, http.StatusUnprocessableEntity, do(f, http.MethodPost, "/currency/backfill", `{"symbol":"uni","from":1}`).Code)
+
+	f.backfillErr = model.ErrBackfillNotFound
+	require.Equal(t, http.StatusNotFound, do(f, http.MethodGet, "/currency/backfill/9", "").Code)
+
+	f.backfillErr = model.ErrBackfillUnavailable
+	require.Equal(t, http.StatusServiceUnavailable, do(f, http.MethodPost, "/currency/backfill", `{"symbol":"btc","from":1}`).Code)
+
+	f.backfillErr = fmt.Errorf("db down")
+	require.Equal(t, http.StatusInternalServerError, do(f, http.MethodGet, "/currency/backfill", "").Code)
+}
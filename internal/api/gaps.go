@@ -0,0 +1,41 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+// GetGaps — GET /currency/gaps?symbol=btc: разрывы в сохранённой истории тикера по его расписанию.
+func (h *Handler) GetGaps(w http.ResponseWriter, r *http.Request) {
+	symbol := r.URL.Query().Get("symbol")
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+	gaps, err := h.service.FindGaps(symbol)
+	if err != nil {
+		if errors.Is(err, model.ErrNotTracked) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		logger.L().WithError(err).Error("GetGaps: service failed")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	out := make([]model.GapDTO, 0, len(gaps))
+	for _, g := range gaps {
+		out = append(out, model.GapDTO{
+			Symbol:        g.Symbol,
+			Quote:         g.Quote,
+			From:          g.From,
+			To:            g.To,
+			DurationSec:   g.To - g.From,
+			Missed:        g.Missed,
+			BackfillJobID: g.BackfillJob,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
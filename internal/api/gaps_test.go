@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGaps_Get(t *testing.T) {
+	f := &fakeService{gaps: []model.Gap{
+		{Symbol: "btc", Quote: "usd", From: 1000, To: 1600, Missed: 59, BackfillJob: 4},
+		{Symbol: "btc", Quote: "eur", From: 2000, To: 2050, Missed: 4},
+	}}
+	rr := do(f, http.MethodGet, "/currency/gaps?symbol=btc", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "btc", f.gotGaps)
+	require.JSONEq(t, `[
+		{"symbol":"btc","quote":"usd","from":1000,"to":1600,"duration_sec":600,"missed":59,"backfill_job_id":4},
+		{"symbol":"btc","quote":"eur","from":2000,"to":2050,"duration_sec":50,"missed":4}
+	]`, rr.Body.String())
+
+	f.gaps = nil
+	rr = do(f, http.MethodGet, "/currency/gaps?symbol=btc", "")
+	require.JSONEq(t, `[]`, rr.Body.String())
+}
+
+func TestGaps_Errors(t *testing.T) {
+	f := &fakeService{}
+	require.Equal(t, http.StatusBadRequest, do(f, http.MethodGet, "/currency/gaps", "").Code)
+
+	f.gapsErr = model.ErrNotTracked
+	require.Equal(t, http.StatusNotFound, do(f, http.MethodGet, "/currency/gaps?symbol=zzz", "").Code)
+
+	f.gapsErr = errors.New("db down")
+	require.Equal(t, http.StatusInternalServerError, do(f, http.MethodGet, "/currency/gaps?symbol=btc", "").Code)
+}
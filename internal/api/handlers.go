@@ -1,22 +1,38 @@
 package api
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"crypto-observer/internal/model"
 	"crypto-observer/pkg/logger"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type Handler struct {
 	service CurrencyService
+
+	// streams отменяется CloseStreams: потоки SSE и WebSocket на нём завершаются
+	streams      context.Context
+	closeStreams context.CancelFunc
 }
 
 func NewHandler(s CurrencyService) *Handler {
-	return &Handler{service: s}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Handler{service: s, streams: ctx, closeStreams: cancel}
 }
 
+// CloseStreams завершает открытые потоки цен. http.Server.Shutdown не отменяет контексты
+// запросов и ждал бы бесконечные потоки до своего дедлайна — регистрируется через RegisterOnShutdown.
+func (h *Handler) CloseStreams() { h.closeStreams() }
+
 func (h *Handler) AddCurrency(w http.ResponseWriter, r *http.Request) {
 	var req model.AddReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -28,7 +44,19 @@ func (h *Handler) AddCurrency(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "symbol is required", http.StatusBadRequest)
 		return
 	}
-	if err := h.service.AddCurrency(req.Symbol, req.Period); err != nil {
+	if err := h.service.AddCurrency(req); err != nil {
+		if errors.Is(err, model.ErrInvalidQuote) || errors.Is(err, model.ErrInvalidSchedule) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var symErr *model.SymbolError
+		if errors.As(err, &symErr) {
+			writeJSON(w, http.StatusUnprocessableEntity, model.SymbolErrorResponse{
+				Error:       symErr.Error(),
+				Suggestions: symErr.Suggestions,
+			})
+			return
+		}
 		logger.L().WithError(err).Error("AddCurrency: service failed")
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
@@ -56,12 +84,39 @@ func (h *Handler) RemoveCurrency(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// UpdateCurrency — PATCH /currency/{symbol}: {"period":30,"quotes":["usd","eur"]}.
+func (h *Handler) UpdateCurrency(w http.ResponseWriter, r *http.Request) {
+	symbol := chi.URLParam(r, "symbol")
+	var req model.UpdateReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.L().WithError(err).Warn("UpdateCurrency: bad request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	st, err := h.service.UpdateCurrency(symbol, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrNotTracked):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, model.ErrInvalidPeriod), errors.Is(err, model.ErrInvalidQuote), errors.Is(err, model.ErrInvalidSchedule):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			logger.L().WithError(err).Error("UpdateCurrency: service failed")
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, toCollectorStatusDTO(*st))
+}
+
 func (h *Handler) GetPrice(w http.ResponseWriter, r *http.Request) {
 	symbol := r.URL.Query().Get("symbol")
 	tsStr := r.URL.Query().Get("timestamp")
+	quote := quoteParam(r)
 
 	logger.L().WithFields(logger.Fields{
 		"symbol": symbol,
+		"quote":  quote,
 		"ts":     tsStr,
 	}).Info("GetPrice: start")
 
@@ -80,9 +135,13 @@ func (h *Handler) GetPrice(w http.ResponseWriter, r *http.Request) {
 		ts = v
 	}
 
-	price, err := h.service.GetPrice(symbol, ts)
+	price, err := h.service.GetPrice(symbol, quote, ts)
 	if err != nil {
-		// ЛЮБАЯ внутренняя ошибка сервиса → 500
+		if errors.Is(err, model.ErrInvalidQuote) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// остальные ошибки сервиса → 500
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -91,11 +150,324 @@ func (h *Handler) GetPrice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := model.PriceDTO{
-		Coin:      price.Symbol,
-		Timestamp: price.TS,
-		Price:     price.Price,
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toPriceDTO(*price))
+}
+
+func toPriceDTO(p model.Price) model.PriceDTO {
+	dto := model.PriceDTO{
+		Coin:      p.Symbol,
+		Quote:     p.Quote,
+		Timestamp: p.TS,
+		Price:     p.Price,
+		Sources:   p.Sources,
+	}
+	if !p.Spread.IsZero() {
+		dto.Spread = &p.Spread
+	}
+	return dto
+}
+
+// GetStatus показывает состояние сбора по тикеру — в том числе unknown_coin
+// для опечаток, которые приняли в /currency/add.
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	symbol := r.URL.Query().Get("symbol")
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+	st := h.service.Status(symbol)
+	if st == nil {
+		http.Error(w, "not tracked", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toCollectorStatusDTO(*st))
+}
+
+// ListCurrencies — все отслеживаемые тикеры: период, валюты, последний тик и цена, ошибки подряд.
+func (h *Handler) ListCurrencies(w http.ResponseWriter, r *http.Request) {
+	list := h.service.ListCollectors()
+	out := make([]model.CollectorStatusDTO, 0, len(list))
+	for _, st := range list {
+		out = append(out, toCollectorStatusDTO(st))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func toCollectorStatusDTO(st model.CollectorStatus) model.CollectorStatusDTO {
+	return model.CollectorStatusDTO{
+		Coin:      st.Symbol,
+		Running:   st.Running,
+		State:     st.State,
+		Error:     st.Error,
+		PeriodSec: st.PeriodSec,
+		Aligned:   st.Aligned,
+		Cron:      st.Cron,
+		Windows:   toScheduleWindowDTOs(st.Windows),
+		Quotes:    st.Quotes,
+		LastFetch: st.LastFetch,
+		LastPrice: st.LastPrice,
+		Failures:  st.Failures,
+	}
+}
+
+// toScheduleWindowDTOs — окна в том же виде, в каком их принимает POST /currency.
+func toScheduleWindowDTOs(ws []model.ScheduleWindow) []model.ScheduleWindowDTO {
+	if len(ws) == 0 {
+		return nil
+	}
+	clock := func(m int) string { return fmt.Sprintf("%02d:%02d", m/60, m%60) }
+	out := make([]model.ScheduleWindowDTO, 0, len(ws))
+	for _, w := range ws {
+		dto := model.ScheduleWindowDTO{From: clock(w.From), To: clock(w.To), TZ: w.TZ, Period: w.Period}
+		for _, d := range w.Days {
+			dto.Days = append(dto.Days, strings.ToLower(d.String()[:3]))
+		}
+		out = append(out, dto)
+	}
+	return out
+}
+
+func (h *Handler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	symbol := q.Get("symbol")
+	quote := quoteParam(r)
+
+	logger.L().WithFields(logger.Fields{
+		"symbol": symbol,
+		"quote":  quote,
+		"from":   q.Get("from"),
+		"to":     q.Get("to"),
+		"cursor": q.Get("cursor"),
+	}).Info("GetHistory: start")
+
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+	from, err := queryInt64(q.Get("from"))
+	if err != nil {
+		http.Error(w, "invalid from", http.StatusBadRequest)
+		return
+	}
+	to, err := queryInt64(q.Get("to"))
+	if err != nil {
+		http.Error(w, "invalid to", http.StatusBadRequest)
+		return
+	}
+	if to != 0 && from > to {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt64(q.Get("limit"))
+	if err != nil || limit < 0 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	var after *model.HistoryCursor
+	if c := q.Get("cursor"); c != "" {
+		after, err = decodeCursor(c)
+		if err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.service.GetHistory(symbol, quote, from, to, int(limit), after)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidQuote) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := model.HistoryResponse{Coin: symbol, Quote: quote, Items: make([]model.PriceDTO, 0, len(page.Items))}
+	for _, p := range page.Items {
+		resp.Items = append(resp.Items, toPriceDTO(p))
+	}
+	if page.Next != nil {
+		resp.NextCursor = encodeCursor(*page.Next)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) GetCandles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	symbol := q.Get("symbol")
+	interval := q.Get("interval")
+	quote := quoteParam(r)
+
+	logger.L().WithFields(logger.Fields{
+		"symbol":   symbol,
+		"quote":    quote,
+		"interval": interval,
+		"from":     q.Get("from"),
+		"to":       q.Get("to"),
+	}).Info("GetCandles: start")
+
+	if symbol == "" || interval == "" {
+		http.Error(w, "symbol and interval are required", http.StatusBadRequest)
+		return
+	}
+	from, err := queryInt64(q.Get("from"))
+	if err != nil {
+		http.Error(w, "invalid from", http.StatusBadRequest)
+		return
+	}
+	to, err := queryInt64(q.Get("to"))
+	if err != nil {
+		http.Error(w, "invalid to", http.StatusBadRequest)
+		return
+	}
+	if to != 0 && from > to {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	candles, err := h.service.GetCandles(symbol, quote, interval, from, to)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidInterval) || errors.Is(err, model.ErrRangeTooLarge) ||
+			errors.Is(err, model.ErrInvalidQuote) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := model.CandlesResponse{Coin: symbol, Quote: quote, Interval: interval, Candles: make([]model.CandleDTO, 0, len(candles))}
+	for _, c := range candles {
+		resp.Candles = append(resp.Candles, model.CandleDTO{
+			Timestamp: c.TS,
+			Open:      c.Open,
+			High:      c.High,
+			Low:       c.Low,
+			Close:     c.Close,
+			Count:     c.Count,
+		})
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+func (h *Handler) ListSymbols(w http.ResponseWriter, r *http.Request) {
+	list := h.service.ListSymbols()
+	out := make([]model.SymbolMappingDTO, 0, len(list))
+	for _, m := range list {
+		out = append(out, model.SymbolMappingDTO{
+			Symbol:     m.Symbol,
+			ProviderID: m.ProviderID,
+			Source:     m.Source,
+			UpdatedAt:  m.UpdatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// AddSymbol добавляет соответствие; если тикер уже сопоставлен другой монете — 409.
+func (h *Handler) AddSymbol(w http.ResponseWriter, r *http.Request) {
+	var req model.SymbolMappingReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.L().WithError(err).Warn("AddSymbol: bad request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.setSymbol(w, req.Symbol, req.ProviderID, false)
+}
+
+// OverrideSymbol заменяет соответствие тикера без проверки на конфликт.
+func (h *Handler) OverrideSymbol(w http.ResponseWriter, r *http.Request) {
+	var req model.SymbolMappingReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.L().WithError(err).Warn("OverrideSymbol: bad request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.setSymbol(w, chi.URLParam(r, "symbol"), req.ProviderID, true)
+}
+
+func (h *Handler) setSymbol(w http.ResponseWriter, symbol, providerID string, override bool) {
+	if symbol == "" || providerID == "" {
+		http.Error(w, "symbol and provider_id are required", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.SetSymbol(symbol, providerID, override); err != nil {
+		var symErr *model.SymbolError
+		switch {
+		case errors.Is(err, model.ErrSymbolExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.As(err, &symErr):
+			writeJSON(w, http.StatusUnprocessableEntity, model.SymbolErrorResponse{
+				Error:       symErr.Error(),
+				Suggestions: symErr.Suggestions,
+			})
+		default:
+			logger.L().WithError(err).Error("SetSymbol: service failed")
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, model.StatusOK())
+}
+
+func (h *Handler) DeleteSymbol(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteSymbol(chi.URLParam(r, "symbol")); err != nil {
+		logger.L().WithError(err).Error("DeleteSymbol: service failed")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// queryInt64 — пустая строка означает «не задано» (0).
+func queryInt64(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// quoteParam — валюта котировки из ?quote=; не задана — model.DefaultQuote.
+// Формат проверяет сервис.
+func quoteParam(r *http.Request) string {
+	q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("quote")))
+	if q == "" {
+		return model.DefaultQuote
+	}
+	return q
+}
+
+// Курсор для клиента непрозрачен: base64url от "ts:id".
+func encodeCursor(c model.HistoryCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.TS, c.ID)))
+}
+
+func decodeCursor(s string) (*model.HistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	tsStr, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, errors.New("malformed cursor")
+	}
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &model.HistoryCursor{TS: ts, ID: id}, nil
+}
@@ -3,9 +3,11 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"crypto-observer/internal/model"
 	"crypto-observer/pkg/logger"
@@ -13,40 +15,190 @@ import (
 )
 
 type fakeService struct {
-	addErr  error
-	rmErr   error
-	getResp *model.Price
-	getErr  error
-	gotAdd  struct {
-		symbol string
-		period int
-	}
+	addErr    error
+	rmErr     error
+	getResp   *model.Price
+	getErr    error
+	gotAdd    model.AddReq
 	gotRemove string
 	gotGet    struct {
+		symbol, quote string
+		ts            int64
+	}
+
+	statusResp *model.CollectorStatus
+	listResp   []model.CollectorStatus
+	updateErr  error
+	gotUpdate  struct {
 		symbol string
-		ts     int64
+		req    model.UpdateReq
+	}
+	readiness model.Readiness
+
+	candlesResp []model.Candle
+	candlesErr  error
+	gotCandles  struct {
+		symbol, quote, interval string
+		from, to                int64
+	}
+
+	histResp *model.HistoryPage
+	histErr  error
+	gotHist  struct {
+		symbol   string
+		quote    string
+		from, to int64
+		limit    int
+		after    *model.HistoryCursor
+	}
+
+	prices    chan model.Price
+	subscribe chan []string // каждая подписка: её тикеры
+
+	sinceResp []model.Price
+	sinceErr  error
+	gotSince  model.HistoryCursor
+
+	alertResp *model.Alert
+	alertErr  error
+	gotAlert  model.AlertReq
+	gotID     int64
+
+	backfillResp *model.BackfillJob
+	backfillErr  error
+	gotBackfill  model.BackfillReq
+
+	gaps    []model.Gap
+	gapsErr error
+	gotGaps string
+
+	deliveries    []model.WebhookDelivery
+	deliveriesErr error
+	gotDeliveries struct {
+		status string
+		limit  int
 	}
 }
 
-func (f *fakeService) AddCurrency(symbol string, period int) error {
-	f.gotAdd = struct {
-		symbol string
-		period int
-	}{symbol, period}
+func (f *fakeService) AddCurrency(req model.AddReq) error {
+	f.gotAdd = req
 	return f.addErr
 }
 func (f *fakeService) RemoveCurrency(symbol string) error {
 	f.gotRemove = symbol
 	return f.rmErr
 }
-func (f *fakeService) GetPrice(symbol string, ts int64) (*model.Price, error) {
-	f.gotGet = struct {
-		symbol string
-		ts     int64
-	}{symbol, ts}
+func (f *fakeService) GetPrice(symbol, quote string, ts int64) (*model.Price, error) {
+	f.gotGet.symbol, f.gotGet.quote, f.gotGet.ts = symbol, quote, ts
 	return f.getResp, f.getErr
 }
 
+func (f *fakeService) GetHistory(symbol, quote string, from, to int64, limit int, after *model.HistoryCursor) (*model.HistoryPage, error) {
+	f.gotHist.symbol, f.gotHist.quote = symbol, quote
+	f.gotHist.from, f.gotHist.to = from, to
+	f.gotHist.limit, f.gotHist.after = limit, after
+	return f.histResp, f.histErr
+}
+
+func (f *fakeService) GetCandles(symbol, quote, interval string, from, to int64) ([]model.Candle, error) {
+	f.gotCandles.symbol, f.gotCandles.quote, f.gotCandles.interval = symbol, quote, interval
+	f.gotCandles.from, f.gotCandles.to = from, to
+	return f.candlesResp, f.candlesErr
+}
+
+func (f *fakeService) Status(symbol string) *model.CollectorStatus {
+	return f.statusResp
+}
+
+func (f *fakeService) SubscribePrices(symbols []string) (<-chan model.Price, func()) {
+	if f.subscribe != nil {
+		f.subscribe <- symbols
+	}
+	return f.prices, func() {}
+}
+
+func (f *fakeService) PricesSince(symbols []string, after model.HistoryCursor) ([]model.Price, error) {
+	f.gotSince = after
+	return f.sinceResp, f.sinceErr
+}
+
+func (f *fakeService) ListAlerts() []model.Alert {
+	if f.alertResp == nil {
+		return nil
+	}
+	return []model.Alert{*f.alertResp}
+}
+
+func (f *fakeService) GetAlert(id int64) (*model.Alert, error) {
+	f.gotID = id
+	return f.alertResp, f.alertErr
+}
+
+func (f *fakeService) CreateAlert(req model.AlertReq) (*model.Alert, error) {
+	f.gotAlert = req
+	return f.alertResp, f.alertErr
+}
+
+func (f *fakeService) UpdateAlert(id int64, req model.AlertReq) (*model.Alert, error) {
+	f.gotID, f.gotAlert = id, req
+	return f.alertResp, f.alertErr
+}
+
+func (f *fakeService) DeleteAlert(id int64) error {
+	f.gotID = id
+	return f.alertErr
+}
+
+func (f *fakeService) WebhookDeliveries(status string, limit int) ([]model.WebhookDelivery, error) {
+	f.gotDeliveries.status, f.gotDeliveries.limit = status, limit
+	return f.deliveries, f.deliveriesErr
+}
+
+func (f *fakeService) StartBackfill(req model.BackfillReq) (*model.BackfillJob, error) {
+	f.gotBackfill = req
+	return f.backfillResp, f.backfillErr
+}
+
+func (f *fakeService) BackfillJob(id int64) (*model.BackfillJob, error) {
+	f.gotID = id
+	return f.backfillResp, f.backfillErr
+}
+
+func (f *fakeService) BackfillJobs(symbol string, limit int) ([]model.BackfillJob, error) {
+	if f.backfillResp == nil {
+		return nil, f.backfillErr
+	}
+	return []model.BackfillJob{*f.backfillResp}, f.backfillErr
+}
+
+func (f *fakeService) FindGaps(symbol string) ([]model.Gap, error) {
+	f.gotGaps = symbol
+	return f.gaps, f.gapsErr
+}
+
+func (f *fakeService) Readiness() model.Readiness { return f.readiness }
+
+func (f *fakeService) UpdateCurrency(symbol string, req model.UpdateReq) (*model.CollectorStatus, error) {
+	f.gotUpdate.symbol, f.gotUpdate.req = symbol, req
+	if f.updateErr != nil {
+		return nil, f.updateErr
+	}
+	return &model.CollectorStatus{Symbol: symbol, Running: true, State: "ok", PeriodSec: int64(req.Period), Quotes: req.Quotes}, nil
+}
+
+// do прогоняет запрос через роутер с фейковым сервисом.
+func do(f *fakeService, method, path, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	NewRouter(NewHandler(f)).ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewReader([]byte(body))))
+	return rr
+}
+
+func (f *fakeService) ListCollectors() []model.CollectorStatus { return f.listResp }
+
+func (f *fakeService) ListSymbols() []model.SymbolMapping                       { return nil }
+func (f *fakeService) SetSymbol(symbol, providerID string, override bool) error { return nil }
+func (f *fakeService) DeleteSymbol(symbol string) error                         { return nil }
+
 func init() { logger.Init() }
 
 func TestHandler_AddCurrency(t *testing.T) {
@@ -59,6 +211,13 @@ func TestHandler_AddCurrency(t *testing.T) {
 		{"ok", map[string]any{"symbol": "btc", "period": 5}, nil, http.StatusOK},
 		{"bad json", "not-json", nil, http.StatusBadRequest},
 		{"missing fields", map[string]any{"symbol": ""}, nil, http.StatusBadRequest},
+		{"unknown symbol", map[string]any{"symbol": "btc", "period": 5},
+			&model.SymbolError{Symbol: "btc", Err: model.ErrUnknownCoin}, http.StatusUnprocessableEntity},
+		{"invalid quote", map[string]any{"symbol": "btc", "quotes": []string{"e"}},
+			fmt.Errorf("%w: %q", model.ErrInvalidQuote, "e"), http.StatusBadRequest},
+		{"invalid schedule", map[string]any{"symbol": "btc", "cron": "bogus"},
+			fmt.Errorf("%w: cron: bad", model.ErrInvalidSchedule), http.StatusBadRequest},
+		{"service error", map[string]any{"symbol": "btc", "period": 5}, assertError("db"), http.StatusInternalServerError},
 	}
 
 	for _, tc := range tests {
@@ -81,13 +240,35 @@ func TestHandler_AddCurrency(t *testing.T) {
 			h.AddCurrency(rr, req)
 			require.Equal(t, tc.wantCode, rr.Code)
 			if tc.wantCode == http.StatusOK {
-				require.Equal(t, "btc", fs.gotAdd.symbol)
-				require.Equal(t, 5, fs.gotAdd.period)
+				require.Equal(t, "btc", fs.gotAdd.Symbol)
+				require.Equal(t, 5, fs.gotAdd.Period)
 			}
 		})
 	}
 }
 
+func TestHandler_AddCurrency_Suggestions(t *testing.T) {
+	fs := &fakeService{addErr: &model.SymbolError{
+		Symbol:      "uni",
+		Err:         model.ErrAmbiguousSymbol,
+		Suggestions: []model.CoinRef{{ID: "uniswap", Symbol: "uni", Name: "Uniswap"}, {ID: "universe", Symbol: "uni", Name: "Universe"}},
+	}}
+	h := NewHandler(fs)
+
+	body := []byte(`{"symbol":"uni","provider_id":""}`)
+	req := httptest.NewRequest(http.MethodPost, "/currency/add", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	h.AddCurrency(rr, req)
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	var out model.SymbolErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &out))
+	require.Contains(t, out.Error, "ambiguous")
+	require.Len(t, out.Suggestions, 2)
+	require.Equal(t, "uniswap", out.Suggestions[0].ID)
+}
+
 func TestHandler_RemoveCurrency(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -137,9 +318,21 @@ func TestHandler_GetPrice(t *testing.T) {
 		{
 			name:     "ok",
 			query:    "/currency/price?symbol=btc&timestamp=111",
-			resp:     &model.Price{Symbol: "btc", TS: 111, Price: 12345},
+			resp:     &model.Price{Symbol: "btc", TS: 111, Price: model.MustDecimal("123.45")},
 			wantCode: http.StatusOK,
 		},
+		{
+			name:     "eur quote",
+			query:    "/currency/price?symbol=btc&quote=EUR",
+			resp:     &model.Price{Symbol: "btc", Quote: "eur", TS: 111, Price: model.MustDecimal("123.45")},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "invalid quote",
+			query:    "/currency/price?symbol=btc&quote=e1",
+			svcErr:   model.ErrInvalidQuote,
+			wantCode: http.StatusBadRequest,
+		},
 		{
 			name:     "missing params",
 			query:    "/currency/price?symbol=&timestamp=",
@@ -170,14 +363,225 @@ func TestHandler_GetPrice(t *testing.T) {
 			h.GetPrice(rr, req)
 			require.Equal(t, tc.wantCode, rr.Code)
 			if rr.Code == http.StatusOK {
-				var out struct{ Price int64 }
+				var out model.PriceDTO
 				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &out))
-				require.Equal(t, int64(12345), out.Price)
+				require.Equal(t, model.MustDecimal("123.45"), out.Price)
+				require.Equal(t, tc.resp.Quote, out.Quote)
+				if tc.resp.Quote != "" {
+					require.Equal(t, tc.resp.Quote, fs.gotGet.quote)
+				} else {
+					require.Equal(t, model.DefaultQuote, fs.gotGet.quote)
+				}
 			}
 		})
 	}
 }
 
+func TestHandler_GetPrice_DecimalString(t *testing.T) {
+	fs := &fakeService{getResp: &model.Price{
+		Symbol: "pepe", Quote: "usd", TS: 1,
+		Price: model.MustDecimal("0.00001234"), Sources: 2, Spread: model.MustDecimal("0.0000001"),
+	}}
+	h := NewHandler(fs)
+
+	rr := httptest.NewRecorder()
+	h.GetPrice(rr, httptest.NewRequest(http.MethodGet, "/currency/price?symbol=pepe", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `{"coin":"pepe","quote":"usd","timestamp":1,"price":"0.00001234","sources":2,"spread":"0.0000001"}`, rr.Body.String())
+}
+
+func TestHandler_GetHistory(t *testing.T) {
+	page := &model.HistoryPage{
+		Items: []model.Price{{Symbol: "btc", TS: 10, Price: model.NewDecimal(100, 0)}, {Symbol: "btc", TS: 20, Price: model.NewDecimal(200, 0), Sources: 2, Spread: model.NewDecimal(5, 1)}},
+		Next:  &model.HistoryCursor{TS: 20, ID: 42},
+	}
+	tests := []struct {
+		name     string
+		query    string
+		svcErr   error
+		wantCode int
+	}{
+		{"ok", "/currency/history?symbol=btc&from=1&to=100&limit=2", nil, http.StatusOK},
+		{"missing symbol", "/currency/history?from=1", nil, http.StatusBadRequest},
+		{"bad from", "/currency/history?symbol=btc&from=x", nil, http.StatusBadRequest},
+		{"from after to", "/currency/history?symbol=btc&from=10&to=5", nil, http.StatusBadRequest},
+		{"bad limit", "/currency/history?symbol=btc&limit=-1", nil, http.StatusBadRequest},
+		{"bad cursor", "/currency/history?symbol=btc&cursor=bm9wZQ", nil, http.StatusBadRequest},
+		{"service error", "/currency/history?symbol=btc", assertError("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &fakeService{histResp: page, histErr: tc.svcErr}
+			h := NewHandler(fs)
+
+			req := httptest.NewRequest(http.MethodGet, tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			h.GetHistory(rr, req)
+			require.Equal(t, tc.wantCode, rr.Code)
+			if rr.Code == http.StatusOK {
+				require.Equal(t, int64(1), fs.gotHist.from)
+				require.Equal(t, int64(100), fs.gotHist.to)
+				require.Equal(t, 2, fs.gotHist.limit)
+
+				var out model.HistoryResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &out))
+				require.Len(t, out.Items, 2)
+				require.NotEmpty(t, out.NextCursor)
+				// история сериализуется так же, как /currency/price
+				require.Equal(t, 2, out.Items[1].Sources)
+				require.NotNil(t, out.Items[1].Spread)
+				require.Equal(t, "0.5", out.Items[1].Spread.String())
+
+				// курсор из ответа должен разбираться обратно в ту же позицию
+				cur, err := decodeCursor(out.NextCursor)
+				require.NoError(t, err)
+				require.Equal(t, page.Next, cur)
+			}
+		})
+	}
+}
+
+func TestHandler_GetCandles(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		svcErr   error
+		wantCode int
+	}{
+		{"ok", "/currency/candles?symbol=btc&interval=5m&from=300&to=900", nil, http.StatusOK},
+		{"missing interval", "/currency/candles?symbol=btc", nil, http.StatusBadRequest},
+		{"bad to", "/currency/candles?symbol=btc&interval=1m&to=x", nil, http.StatusBadRequest},
+		{"unknown interval", "/currency/candles?symbol=btc&interval=7m", model.ErrInvalidInterval, http.StatusBadRequest},
+		{"range too large", "/currency/candles?symbol=btc&interval=1m", model.ErrRangeTooLarge, http.StatusBadRequest},
+		{"service error", "/currency/candles?symbol=btc&interval=1m", assertError("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &fakeService{
+				candlesResp: []model.Candle{{Symbol: "btc", TS: 300, Open: model.NewDecimal(1, 0), High: model.NewDecimal(5, 0),
+					Low: model.NewDecimal(1, 0), Close: model.NewDecimal(4, 0), Count: 7}},
+				candlesErr: tc.svcErr,
+			}
+			h := NewHandler(fs)
+
+			req := httptest.NewRequest(http.MethodGet, tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			h.GetCandles(rr, req)
+			require.Equal(t, tc.wantCode, rr.Code)
+			if rr.Code == http.StatusOK {
+				require.Equal(t, "5m", fs.gotCandles.interval)
+				require.Equal(t, int64(300), fs.gotCandles.from)
+				require.Equal(t, int64(900), fs.gotCandles.to)
+
+				var out model.CandlesResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &out))
+				require.Len(t, out.Candles, 1)
+				require.Equal(t, int64(7), out.Candles[0].Count)
+			}
+		})
+	}
+}
+
+func TestHandler_GetStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		status   *model.CollectorStatus
+		wantCode int
+	}{
+		{"ok", "/currency/status?symbol=btc", &model.CollectorStatus{Symbol: "btc", Running: true, State: "ok"}, http.StatusOK},
+		{"missing symbol", "/currency/status", nil, http.StatusBadRequest},
+		{"not tracked", "/currency/status?symbol=xyz", nil, http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler(&fakeService{statusResp: tc.status})
+
+			req := httptest.NewRequest(http.MethodGet, tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			h.GetStatus(rr, req)
+			require.Equal(t, tc.wantCode, rr.Code)
+			if rr.Code == http.StatusOK {
+				var out model.CollectorStatusDTO
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &out))
+				require.Equal(t, "ok", out.State)
+				require.True(t, out.Running)
+			}
+		})
+	}
+}
+
+func TestHandler_ListCurrencies(t *testing.T) {
+	f := &fakeService{listResp: []model.CollectorStatus{
+		{Symbol: "btc", Running: true, State: "ok", PeriodSec: 60, Aligned: true, Quotes: []string{"usd", "eur"},
+			LastFetch: 100, LastPrice: map[string]model.Decimal{"usd": model.MustDecimal("70000.5")}},
+		{Symbol: "eth", State: "error", Error: "db down", PeriodSec: 30, Quotes: []string{"usd"}, Failures: 3},
+		{Symbol: "sol", PeriodSec: 5, Quotes: []string{"usd"}, Windows: []model.ScheduleWindow{
+			{Days: []time.Weekday{time.Monday, time.Friday}, From: 9*60 + 30, To: 16 * 60, TZ: "America/New_York", Period: 5},
+		}},
+		{Symbol: "xrp", PeriodSec: 900, Cron: "*/15 * * * *", Quotes: []string{"usd"}},
+	}}
+	rr := httptest.NewRecorder()
+	NewRouter(NewHandler(f)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/currency/list", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `[
+		{"coin":"btc","running":true,"state":"ok","period_sec":60,"align":true,"quotes":["usd","eur"],
+		 "last_fetch":100,"last_price":{"usd":"70000.5"},"consecutive_failures":0},
+		{"coin":"eth","running":false,"state":"error","error":"db down","period_sec":30,"align":false,"quotes":["usd"],
+		 "consecutive_failures":3},
+		{"coin":"sol","running":false,"state":"","period_sec":5,"align":false,"quotes":["usd"],"consecutive_failures":0,
+		 "windows":[{"days":["mon","fri"],"from":"09:30","to":"16:00","tz":"America/New_York","period":5}]},
+		{"coin":"xrp","running":false,"state":"","period_sec":900,"align":false,"quotes":["usd"],"consecutive_failures":0,
+		 "cron":"*/15 * * * *"}
+	]`, rr.Body.String())
+
+	// пустой список — [], а не null
+	rr = httptest.NewRecorder()
+	NewRouter(NewHandler(&fakeService{})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/currency/list", nil))
+	require.Equal(t, "[]\n", rr.Body.String())
+}
+
+func TestHandler_UpdateCurrency(t *testing.T) {
+	patch := func(f *fakeService, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		NewRouter(NewHandler(f)).ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, path, bytes.NewReader([]byte(body))))
+		return rr
+	}
+
+	f := &fakeService{}
+	rr := patch(f, "/currency/btc", `{"period":30,"quotes":["usd","eur"]}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "btc", f.gotUpdate.symbol)
+	require.Equal(t, model.UpdateReq{Period: 30, Quotes: []string{"usd", "eur"}}, f.gotUpdate.req)
+	var out model.CollectorStatusDTO
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &out))
+	require.Equal(t, int64(30), out.PeriodSec)
+
+	require.Equal(t, http.StatusBadRequest, patch(f, "/currency/btc", `{`).Code)
+	f.updateErr = model.ErrNotTracked
+	require.Equal(t, http.StatusNotFound, patch(f, "/currency/xyz", `{"period":30}`).Code)
+	f.updateErr = model.ErrInvalidPeriod
+	require.Equal(t, http.StatusBadRequest, patch(f, "/currency/btc", `{"period":-1}`).Code)
+	f.updateErr = fmt.Errorf("%w: cron and windows are mutually exclusive", model.ErrInvalidSchedule)
+	require.Equal(t, http.StatusBadRequest, patch(f, "/currency/btc", `{"cron":"* * * * *"}`).Code)
+	f.updateErr = fmt.Errorf("db down")
+	require.Equal(t, http.StatusInternalServerError, patch(f, "/currency/btc", `{"period":30}`).Code)
+
+	// [] — убрать окна, отсутствие поля — не трогать
+	f.updateErr = nil
+	require.Equal(t, http.StatusOK, patch(f, "/currency/btc", `{"windows":[]}`).Code)
+	require.NotNil(t, f.gotUpdate.req.Windows)
+	require.Equal(t, http.StatusOK, patch(f, "/currency/btc", `{"cron":""}`).Code)
+	require.Nil(t, f.gotUpdate.req.Windows)
+	require.Equal(t, "", *f.gotUpdate.req.Cron)
+}
+
 // простой маркер ошибки
 type markerErr string
 
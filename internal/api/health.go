@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"crypto-observer/internal/model"
+)
+
+// Healthz — liveness: процесс жив и обслуживает HTTP. Внешние зависимости не трогает,
+// чтобы сбой БД не приводил к перезапуску контейнера.
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, model.StatusOK())
+}
+
+// Readyz — readiness: 503, если недоступен Postgres. Молчащий провайдер и застывшие
+// коллекторы дают status=degraded с кодом 200 — история цен по-прежнему отдаётся.
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	rd := h.service.Readiness()
+	dto := model.ReadinessDTO{
+		Status:    model.ReadyOK,
+		DB:        "ok",
+		Providers: make([]model.ProviderHealthDTO, 0, len(rd.Providers)),
+		Stale:     make([]model.StaleCollectorDTO, 0, len(rd.Stale)),
+	}
+	for _, p := range rd.Providers {
+		dto.Providers = append(dto.Providers, model.ProviderHealthDTO{Name: p.Name, OK: p.OK, LastSuccess: p.LastSuccess})
+		if !p.OK {
+			dto.Status = model.ReadyDegraded
+		}
+	}
+	for _, s := range rd.Stale {
+		dto.Stale = append(dto.Stale, model.StaleCollectorDTO{Coin: s.Symbol, PeriodSec: s.PeriodSec, LastSave: s.LastSave})
+		dto.Status = model.ReadyDegraded
+	}
+	code := http.StatusOK
+	if rd.DBError != "" {
+		dto.Status, dto.DB = model.ReadyNotReady, rd.DBError
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, dto)
+}
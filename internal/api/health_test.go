@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getReadyz(t *testing.T, f *fakeService) (int, model.ReadinessDTO) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	NewRouter(NewHandler(f)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var dto model.ReadinessDTO
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &dto))
+	return rr.Code, dto
+}
+
+func TestHealthz(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewRouter(NewHandler(&fakeService{})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	f := &fakeService{readiness: model.Readiness{
+		Providers: []model.ProviderHealth{{Name: "coingecko", OK: true, LastSuccess: 100}},
+	}}
+	code, dto := getReadyz(t, f)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, model.ReadyOK, dto.Status)
+	require.Equal(t, "ok", dto.DB)
+	require.NotNil(t, dto.Stale, "empty list, not null")
+
+	f.readiness.Stale = []model.StaleCollector{{Symbol: "eth", PeriodSec: 60, LastSave: 50}}
+	code, dto = getReadyz(t, f)
+	require.Equal(t, http.StatusOK, code, "stale collectors do not take the instance out of rotation")
+	require.Equal(t, model.ReadyDegraded, dto.Status)
+	require.Equal(t, []model.StaleCollectorDTO{{Coin: "eth", PeriodSec: 60, LastSave: 50}}, dto.Stale)
+
+	f.readiness = model.Readiness{Providers: []model.ProviderHealth{{Name: "kraken"}}}
+	_, dto = getReadyz(t, f)
+	require.Equal(t, model.ReadyDegraded, dto.Status)
+
+	f.readiness.DBError = "connection refused"
+	code, dto = getReadyz(t, f)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, model.ReadyNotReady, dto.Status)
+	require.Equal(t, "connection refused", dto.DB)
+}
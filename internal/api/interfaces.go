@@ -3,7 +3,42 @@ package api
 import "crypto-observer/internal/model"
 
 type CurrencyService interface {
-	AddCurrency(symbol string, periodSec int) error
+	AddCurrency(req model.AddReq) error
 	RemoveCurrency(symbol string) error
-	GetPrice(symbol string, ts int64) (*model.Price, error)
+	// UpdateCurrency меняет период/валюты работающего коллектора без перезапуска.
+	UpdateCurrency(symbol string, req model.UpdateReq) (*model.CollectorStatus, error)
+	GetPrice(symbol, quote string, ts int64) (*model.Price, error)
+	Status(symbol string) *model.CollectorStatus
+	// ListCollectors — состояние всех коллекторов по алфавиту.
+	ListCollectors() []model.CollectorStatus
+
+	ListSymbols() []model.SymbolMapping
+	SetSymbol(symbol, providerID string, override bool) error
+	DeleteSymbol(symbol string) error
+	GetCandles(symbol, quote, interval string, from, to int64) ([]model.Candle, error)
+	GetHistory(symbol, quote string, from, to int64, limit int, after *model.HistoryCursor) (*model.HistoryPage, error)
+
+	// SubscribePrices — поток сохранённых цен по тикерам; cancel закрывает канал.
+	SubscribePrices(symbols []string) (<-chan model.Price, func())
+	// PricesSince — сохранённые цены тикеров строго после позиции after по (ts, id) (досылка потока).
+	PricesSince(symbols []string, after model.HistoryCursor) ([]model.Price, error)
+
+	ListAlerts() []model.Alert
+	GetAlert(id int64) (*model.Alert, error)
+	CreateAlert(req model.AlertReq) (*model.Alert, error)
+	UpdateAlert(id int64, req model.AlertReq) (*model.Alert, error)
+	DeleteAlert(id int64) error
+
+	// WebhookDeliveries — журнал доставок вебхуков, новые сначала.
+	WebhookDeliveries(status string, limit int) ([]model.WebhookDelivery, error)
+
+	// StartBackfill ставит в очередь догрузку истории цен; BackfillJob/BackfillJobs — её состояние.
+	StartBackfill(req model.BackfillReq) (*model.BackfillJob, error)
+	BackfillJob(id int64) (*model.BackfillJob, error)
+	BackfillJobs(symbol string, limit int) ([]model.BackfillJob, error)
+	// FindGaps — разрывы в истории тикера длиннее допустимого по его расписанию.
+	FindGaps(symbol string) ([]model.Gap, error)
+
+	// Readiness — ping БД и сводка по провайдерам и застывшим коллекторам.
+	Readiness() model.Readiness
 }
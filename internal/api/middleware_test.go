@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"crypto-observer/internal/metrics"
+	"crypto-observer/internal/model"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+// requestCount — сколько запросов записано в гистограмму с такими метками.
+func requestCount(t *testing.T, method, route, code string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	h := metrics.HTTPRequestSeconds.WithLabelValues(method, route, code)
+	require.NoError(t, h.(prometheus.Metric).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestInstrument_LabelsByRoutePattern(t *testing.T) {
+	h := NewRouter(NewHandler(&fakeService{alertResp: &model.Alert{ID: 1, Symbol: "btc"}}))
+	byID := requestCount(t, http.MethodGet, "/alerts/{id}", "200")
+	unmatched := requestCount(t, http.MethodGet, "unmatched", "404")
+
+	for _, p := range []string{"/alerts/1", "/alerts/2", "/alerts/3"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/no/such/path", nil))
+
+	require.Equal(t, byID+3, requestCount(t, http.MethodGet, "/alerts/{id}", "200"))
+	require.Equal(t, unmatched+1, requestCount(t, http.MethodGet, "unmatched", "404"))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), `route="/alerts/{id}"`)
+}
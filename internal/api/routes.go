@@ -3,15 +3,50 @@ package api
 import (
 	"net/http"
 
+	"crypto-observer/internal/metrics"
+
 	"github.com/go-chi/chi/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 func NewRouter(h *Handler) http.Handler {
 	r := chi.NewRouter()
+	r.Use(instrument)
 	r.Post("/currency/add", h.AddCurrency)
 	r.Post("/currency/remove", h.RemoveCurrency)
 	r.Get("/currency/price", h.GetPrice)
+	r.Get("/currency/status", h.GetStatus)
+	r.Get("/currency/list", h.ListCurrencies)
+	r.Patch("/currency/{symbol}", h.UpdateCurrency)
+	r.Post("/currency/backfill", h.StartBackfill)
+	r.Get("/currency/backfill", h.ListBackfills)
+	r.Get("/currency/backfill/{id}", h.GetBackfill)
+	r.Get("/currency/gaps", h.GetGaps)
+	r.Get("/currency/history", h.GetHistory)
+	r.Get("/currency/candles", h.GetCandles)
+	r.Get("/currency/stream", h.StreamPrices)
+	r.Get("/ws/prices", h.PricesWS)
+
+	r.Route("/admin/symbols", func(r chi.Router) {
+		r.Get("/", h.ListSymbols)
+		r.Post("/", h.AddSymbol)
+		r.Put("/{symbol}", h.OverrideSymbol)
+		r.Delete("/{symbol}", h.DeleteSymbol)
+	})
+
+	r.Route("/alerts", func(r chi.Router) {
+		r.Get("/", h.ListAlerts)
+		r.Post("/", h.CreateAlert)
+		r.Get("/{id}", h.GetAlert)
+		r.Put("/{id}", h.UpdateAlert)
+		r.Delete("/{id}", h.DeleteAlert)
+	})
+
+	r.Get("/webhooks/deliveries", h.ListWebhookDeliveries)
+
+	r.Get("/healthz", h.Healthz)
+	r.Get("/readyz", h.Readyz)
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 	return r
 }
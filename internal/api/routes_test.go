@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -18,11 +19,21 @@ type fakeServ struct {
 
 	priceResp *model.Price
 	priceErr  error
+
+	historyPage *model.HistoryPage
+	candles     []model.Candle
+	status      *model.CollectorStatus
+
+	symbols     []model.SymbolMapping
+	setSymErr   error
+	gotSetSym   model.SymbolMapping
+	gotOverride bool
+	deletedSym  string
 }
 
-func (f *fakeServ) AddCurrency(symbol string, periodSec int) error {
-	f.addSymbol = symbol
-	f.addPeriod = periodSec
+func (f *fakeServ) AddCurrency(req model.AddReq) error {
+	f.addSymbol = req.Symbol
+	f.addPeriod = req.Period
 	return nil
 }
 
@@ -31,10 +42,71 @@ func (f *fakeServ) RemoveCurrency(symbol string) error {
 	return nil
 }
 
-func (f *fakeServ) GetPrice(symbol string, ts int64) (*model.Price, error) {
+func (f *fakeServ) GetPrice(symbol, quote string, ts int64) (*model.Price, error) {
 	return f.priceResp, f.priceErr
 }
 
+func (f *fakeServ) GetHistory(symbol, quote string, from, to int64, limit int, after *model.HistoryCursor) (*model.HistoryPage, error) {
+	return f.historyPage, nil
+}
+
+func (f *fakeServ) GetCandles(symbol, quote, interval string, from, to int64) ([]model.Candle, error) {
+	return f.candles, nil
+}
+
+func (f *fakeServ) Status(symbol string) *model.CollectorStatus {
+	return f.status
+}
+
+func (f *fakeServ) SubscribePrices(symbols []string) (<-chan model.Price, func()) {
+	return nil, func() {}
+}
+
+func (f *fakeServ) PricesSince(symbols []string, after model.HistoryCursor) ([]model.Price, error) {
+	return nil, nil
+}
+
+func (f *fakeServ) ListAlerts() []model.Alert                            { return nil }
+func (f *fakeServ) GetAlert(id int64) (*model.Alert, error)              { return nil, model.ErrAlertNotFound }
+func (f *fakeServ) CreateAlert(req model.AlertReq) (*model.Alert, error) { return nil, nil }
+func (f *fakeServ) UpdateAlert(id int64, req model.AlertReq) (*model.Alert, error) {
+	return nil, nil
+}
+func (f *fakeServ) DeleteAlert(id int64) error { return nil }
+
+func (f *fakeServ) WebhookDeliveries(status string, limit int) ([]model.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (f *fakeServ) Readiness() model.Readiness { return model.Readiness{} }
+
+func (f *fakeServ) StartBackfill(req model.BackfillReq) (*model.BackfillJob, error) { return nil, nil }
+func (f *fakeServ) BackfillJob(id int64) (*model.BackfillJob, error)                { return nil, nil }
+func (f *fakeServ) BackfillJobs(symbol string, limit int) ([]model.BackfillJob, error) {
+	return nil, nil
+}
+
+func (f *fakeServ) FindGaps(symbol string) ([]model.Gap, error) { return nil, model.ErrNotTracked }
+
+func (f *fakeServ) UpdateCurrency(symbol string, req model.UpdateReq) (*model.CollectorStatus, error) {
+	return nil, model.ErrNotTracked
+}
+
+func (f *fakeServ) ListCollectors() []model.CollectorStatus { return nil }
+
+func (f *fakeServ) ListSymbols() []model.SymbolMapping { return f.symbols }
+
+func (f *fakeServ) SetSymbol(symbol, providerID string, override bool) error {
+	f.gotSetSym = model.SymbolMapping{Symbol: symbol, ProviderID: providerID}
+	f.gotOverride = override
+	return f.setSymErr
+}
+
+func (f *fakeServ) DeleteSymbol(symbol string) error {
+	f.deletedSym = symbol
+	return nil
+}
+
 // -------------------------------------------------------------
 
 func TestNewRouter_AddCurrency(t *testing.T) {
@@ -78,7 +150,7 @@ func TestNewRouter_RemoveCurrency(t *testing.T) {
 }
 
 func TestNewRouter_GetPrice(t *testing.T) {
-	want := &model.Price{Symbol: "btc", TS: 111, Price: 12345}
+	want := &model.Price{Symbol: "btc", TS: 111, Price: model.MustDecimal("123.45")}
 	svc := &fakeServ{priceResp: want}
 	h := NewHandler(svc)
 	r := NewRouter(h)
@@ -101,6 +173,126 @@ func TestNewRouter_GetPrice(t *testing.T) {
 	}
 }
 
+func TestNewRouter_GetHistory(t *testing.T) {
+	svc := &fakeServ{historyPage: &model.HistoryPage{Items: []model.Price{{Symbol: "btc", TS: 1, Price: model.NewDecimal(2, 0)}}}}
+	r := NewRouter(NewHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/currency/history?symbol=btc&from=0&to=10", nil)
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: want %d, got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var got model.HistoryResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(got.Items) != 1 || got.NextCursor != "" {
+		t.Fatalf("unexpected response: %#v", got)
+	}
+}
+
+func TestNewRouter_GetCandles(t *testing.T) {
+	svc := &fakeServ{candles: []model.Candle{{Symbol: "btc", TS: 60, Open: model.NewDecimal(1, 0), High: model.NewDecimal(3, 0), Low: model.NewDecimal(1, 0), Close: model.NewDecimal(2, 0), Count: 3}}}
+	r := NewRouter(NewHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/currency/candles?symbol=btc&interval=1m", nil)
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: want %d, got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var got model.CandlesResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(got.Candles) != 1 || got.Candles[0].High != model.NewDecimal(3, 0) {
+		t.Fatalf("unexpected response: %#v", got)
+	}
+}
+
+func TestNewRouter_GetStatus(t *testing.T) {
+	svc := &fakeServ{status: &model.CollectorStatus{Symbol: "btcc", Running: true, State: "unknown_coin", Error: "unknown coin: btcc"}}
+	r := NewRouter(NewHandler(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/currency/status?symbol=btcc", nil)
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: want %d, got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var got model.CollectorStatusDTO
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if got.State != "unknown_coin" || got.Error == "" {
+		t.Fatalf("unexpected response: %#v", got)
+	}
+}
+
+func TestNewRouter_AdminSymbols(t *testing.T) {
+	svc := &fakeServ{symbols: []model.SymbolMapping{{Symbol: "btc", ProviderID: "bitcoin", Source: "builtin"}}}
+	r := NewRouter(NewHandler(svc))
+
+	// list
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/symbols", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list: want 200, got %d", rr.Code)
+	}
+	var list []model.SymbolMappingDTO
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].ProviderID != "bitcoin" {
+		t.Fatalf("list: unexpected body %s (%v)", rr.Body.String(), err)
+	}
+
+	// add
+	rr = httptest.NewRecorder()
+	body := []byte(`{"symbol":"uni","provider_id":"uniswap"}`)
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/symbols", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK || svc.gotSetSym.Symbol != "uni" || svc.gotOverride {
+		t.Fatalf("add: code %d, got %#v override=%v", rr.Code, svc.gotSetSym, svc.gotOverride)
+	}
+
+	// override берёт тикер из пути
+	rr = httptest.NewRecorder()
+	body = []byte(`{"provider_id":"universe-token"}`)
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/admin/symbols/uni", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK || svc.gotSetSym.Symbol != "uni" || svc.gotSetSym.ProviderID != "universe-token" || !svc.gotOverride {
+		t.Fatalf("override: code %d, got %#v override=%v", rr.Code, svc.gotSetSym, svc.gotOverride)
+	}
+
+	// delete
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/admin/symbols/uni", nil))
+	if rr.Code != http.StatusOK || svc.deletedSym != "uni" {
+		t.Fatalf("delete: code %d, deleted %q", rr.Code, svc.deletedSym)
+	}
+}
+
+func TestNewRouter_AdminSymbols_Conflict(t *testing.T) {
+	svc := &fakeServ{setSymErr: fmt.Errorf("%w: uni", model.ErrSymbolExists)}
+	r := NewRouter(NewHandler(svc))
+
+	rr := httptest.NewRecorder()
+	body := []byte(`{"symbol":"uni","provider_id":"uniswap"}`)
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/symbols", bytes.NewReader(body)))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("want 409, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/symbols", bytes.NewReader([]byte(`{"symbol":"uni"}`))))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("missing provider_id: want 400, got %d", rr.Code)
+	}
+}
+
 func TestNewRouter_SwaggerMounted(t *testing.T) {
 	svc := &fakeServ{}
 	h := NewHandler(svc)
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+// Как часто шлём комментарий-пинг, чтобы прокси не закрывали молчащий поток
+var sseKeepAlive = 15 * time.Second
+
+// StreamPrices — поток сохранённых цен в формате Server-Sent Events
+// для клиентов, у которых WebSocket режут прокси.
+// id события — "<ts>-<id строки prices>": браузер пришлёт его в Last-Event-ID при
+// переподключении, и мы дошлём из БД всё, что идёт после него по (ts, id).
+// На одном ts бывает несколько цен, поэтому одного ts в id мало.
+func (h *Handler) StreamPrices(w http.ResponseWriter, r *http.Request) {
+	symbols := splitSymbols(r.URL.Query().Get("symbols"))
+	if len(symbols) == 0 {
+		http.Error(w, "symbols is required", http.StatusBadRequest)
+		return
+	}
+	var (
+		after  model.HistoryCursor
+		resume bool
+	)
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		var err error
+		if after, err = parseEventID(v); err != nil {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		resume = true
+	}
+	rc := http.NewResponseController(w)
+	// поток живёт дольше Read/WriteTimeout сервера; по истёкшему чтению сервер отменил бы контекст
+	if err := errors.Join(rc.SetReadDeadline(time.Time{}), rc.SetWriteDeadline(time.Time{})); err != nil {
+		logger.L().WithError(err).Warn("StreamPrices: cannot clear deadlines")
+	}
+	log := logger.L().WithFields(logger.Fields{"symbols": symbols, "remote": r.RemoteAddr})
+
+	// Подписываемся до чтения БД, чтобы не потерять цены, сохранённые между запросом и подпиской
+	updates, cancel := h.service.SubscribePrices(symbols)
+	defer cancel()
+
+	var replay []model.Price
+	if resume {
+		var err error
+		if replay, err = h.service.PricesSince(symbols, after); err != nil {
+			log.WithError(err).Error("StreamPrices: replay failed")
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx: не буферизовать
+	w.WriteHeader(http.StatusOK)
+
+	// Цены из досылки могут прийти и живыми — запоминаем их, чтобы не повторить
+	sent := make(map[int64]bool, len(replay))
+	for _, p := range replay {
+		if err := writePriceEvent(w, p); err != nil {
+			return
+		}
+		sent[p.ID] = true
+	}
+	if err := rc.Flush(); err != nil {
+		log.WithError(err).Warn("StreamPrices: streaming unsupported")
+		return
+	}
+	log.WithField("replayed", len(replay)).Debug("StreamPrices: connected")
+
+	ping := time.NewTicker(sseKeepAlive)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Debug("StreamPrices: client gone")
+			return
+		case <-h.streams.Done():
+			log.Debug("StreamPrices: server shutting down")
+			return
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case p, ok := <-updates:
+			if !ok {
+				return
+			}
+			if sent[p.ID] {
+				delete(sent, p.ID) // живой дубль приходит не больше одного раза
+				continue
+			}
+			if err := writePriceEvent(w, p); err != nil {
+				log.WithError(err).Debug("StreamPrices: write failed")
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writePriceEvent(w http.ResponseWriter, p model.Price) error {
+	data, err := json.Marshal(toPriceDTO(p))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d-%d\nevent: price\ndata: %s\n\n", p.TS, p.ID, data)
+	return err
+}
+
+// parseEventID разбирает Last-Event-ID "<ts>-<id>". Голый ts — id прежних версий:
+// досылаем всё строго после этого ts.
+func parseEventID(v string) (model.HistoryCursor, error) {
+	tsPart, idPart, withID := strings.Cut(v, "-")
+	ts, err := strconv.ParseInt(tsPart, 10, 64)
+	if err != nil {
+		return model.HistoryCursor{}, err
+	}
+	if !withID {
+		return model.HistoryCursor{TS: ts, ID: math.MaxInt64}, nil
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return model.HistoryCursor{}, err
+	}
+	return model.HistoryCursor{TS: ts, ID: id}, nil
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	id, event string
+	data      model.PriceDTO
+}
+
+// openStream подключается к /currency/stream; сервер — с короткими таймаутами,
+// чтобы проверить, что поток их переживает.
+func openStream(t *testing.T, f *fakeService, query, lastID string) *bufio.Reader {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(NewRouter(NewHandler(f)))
+	srv.Config.ReadTimeout = 100 * time.Millisecond
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/currency/stream"+query, nil)
+	require.NoError(t, err)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return bufio.NewReader(resp.Body)
+}
+
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && ev.id != "":
+			return ev
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data))
+		}
+	}
+}
+
+func TestStreamPrices_ReplaysSinceLastEventID(t *testing.T) {
+	f := &fakeService{
+		prices: make(chan model.Price, 2),
+		sinceResp: []model.Price{
+			{ID: 6, Symbol: "btc", Quote: "usd", TS: 10, Price: model.MustDecimal("100")},
+			{ID: 7, Symbol: "eth", Quote: "usd", TS: 12, Price: model.MustDecimal("0.5")},
+		},
+	}
+	r := openStream(t, f, "?symbols=btc,eth", "10-5")
+
+	ev := readEvent(t, r)
+	require.Equal(t, "10-6", ev.id)
+	require.Equal(t, "price", ev.event)
+	require.Equal(t, "100", ev.data.Price.String())
+	require.Equal(t, "12-7", readEvent(t, r).id)
+	require.Equal(t, model.HistoryCursor{TS: 10, ID: 5}, f.gotSince, "same-ts prices after the last id are replayed")
+
+	// уже досланная цена, пришедшая живой, не повторяется; другая цена на том же ts — доходит
+	f.prices <- model.Price{ID: 7, Symbol: "eth", Quote: "usd", TS: 12, Price: model.MustDecimal("0.5")}
+	f.prices <- model.Price{ID: 8, Symbol: "btc", Quote: "usd", TS: 12, Price: model.MustDecimal("101")}
+	ev = readEvent(t, r)
+	require.Equal(t, "12-8", ev.id)
+	require.Equal(t, "btc", ev.data.Coin)
+}
+
+func TestStreamPrices_LegacyLastEventID(t *testing.T) {
+	f := &fakeService{prices: make(chan model.Price)}
+	openStream(t, f, "?symbols=btc", "10")
+	require.Equal(t, model.HistoryCursor{TS: 10, ID: math.MaxInt64}, f.gotSince, "bare ts resumes strictly after it")
+}
+
+func TestStreamPrices_OutlivesServerTimeouts(t *testing.T) {
+	f := &fakeService{prices: make(chan model.Price, 1)}
+	r := openStream(t, f, "?symbols=btc", "")
+
+	time.Sleep(300 * time.Millisecond) // дольше Read/WriteTimeout сервера
+	f.prices <- model.Price{Symbol: "btc", Quote: "usd", TS: 20, Price: model.MustDecimal("1")}
+	require.Equal(t, "20-0", readEvent(t, r).id)
+	require.Zero(t, f.gotSince, "no Last-Event-ID — no replay")
+}
+
+func TestStreamPrices_EndsOnServerShutdown(t *testing.T) {
+	f := &fakeService{prices: make(chan model.Price)}
+	h := NewHandler(f)
+	srv := httptest.NewUnstartedServer(NewRouter(h))
+	srv.Config.RegisterOnShutdown(h.CloseStreams)
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/currency/stream?symbols=btc")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, srv.Config.Shutdown(ctx), "open stream must not hold shutdown")
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err, "stream ends cleanly")
+}
+
+func TestStreamPrices_BadRequest(t *testing.T) {
+	h := NewHandler(&fakeService{})
+	for _, tc := range []struct{ query, lastID string }{
+		{"", ""},
+		{"?symbols=,", ""},
+		{"?symbols=btc", "abc"},
+		{"?symbols=btc", "10-x"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/currency/stream"+tc.query, nil)
+		if tc.lastID != "" {
+			req.Header.Set("Last-Event-ID", tc.lastID)
+		}
+		rr := httptest.NewRecorder()
+		h.StreamPrices(rr, req)
+		require.Equal(t, http.StatusBadRequest, rr.Code, tc)
+	}
+}
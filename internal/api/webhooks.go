@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+// ListWebhookDeliveries — журнал доставок вебхуков: ?status=pending|delivered|failed&limit=50.
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	list, err := h.service.WebhookDeliveries(r.URL.Query().Get("status"), limit)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidDeliveryStatus) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logger.L().WithError(err).Error("ListWebhookDeliveries: service failed")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	out := make([]model.WebhookDeliveryDTO, 0, len(list))
+	for _, d := range list {
+		dto := model.WebhookDeliveryDTO{
+			ID:          d.ID,
+			Endpoint:    d.Endpoint,
+			URL:         d.URL,
+			Event:       d.Event,
+			Status:      d.Status,
+			Attempts:    d.Attempts,
+			LastError:   d.LastError,
+			LastCode:    d.LastCode,
+			CreatedAt:   d.CreatedAt,
+			DeliveredAt: d.DeliveredAt,
+			Payload:     json.RawMessage(d.Payload),
+		}
+		if d.Status == model.DeliveryPending {
+			dto.NextAttemptAt = d.NextAttemptAt
+		}
+		out = append(out, dto)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
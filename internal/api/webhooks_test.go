@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListWebhookDeliveries(t *testing.T) {
+	f := &fakeService{deliveries: []model.WebhookDelivery{
+		{ID: 2, Endpoint: "ops", Event: model.EventAlertFired, Status: model.DeliveryFailed, Attempts: 8,
+			LastError: "receiver responded 500", LastCode: 500, NextAttemptAt: 99, Payload: `{"event":"alert.fired"}`},
+	}}
+	rr := httptest.NewRecorder()
+	NewRouter(NewHandler(f)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/webhooks/deliveries?status=failed&limit=10", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "failed", f.gotDeliveries.status)
+	require.Equal(t, 10, f.gotDeliveries.limit)
+	require.JSONEq(t, `[{"id":2,"endpoint":"ops","url":"","event":"alert.fired","status":"failed","attempts":8,
+		"last_error":"receiver responded 500","last_code":500,"created_at":0,"payload":{"event":"alert.fired"}}]`, rr.Body.String())
+}
+
+func TestListWebhookDeliveries_BadRequest(t *testing.T) {
+	f := &fakeService{deliveriesErr: fmt.Errorf("%w: %q", model.ErrInvalidDeliveryStatus, "x")}
+	r := NewRouter(NewHandler(f))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/webhooks/deliveries?status=x", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/webhooks/deliveries?limit=abc", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
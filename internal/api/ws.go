@@ -0,0 +1,144 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+
+	"golang.org/x/net/websocket"
+)
+
+// Типы сообщений /ws/prices
+const (
+	wsSubscribed = "subscribed"
+	wsPrice      = "price"
+	wsError      = "error"
+)
+
+// PricesWS — поток сохранённых цен по WebSocket.
+// Начальные тикеры берутся из ?symbols=btc,eth, дальше клиент шлёт
+// {"action":"subscribe|unsubscribe","symbols":[...]}; на каждое изменение
+// сервер отвечает {"type":"subscribed","symbols":[...]}, цены приходят как
+// {"type":"price","data":{...}}.
+func (h *Handler) PricesWS(w http.ResponseWriter, r *http.Request) {
+	// websocket.Server без Handshake не проверяет Origin — клиенты не только браузерные
+	websocket.Server{Handler: h.servePricesWS}.ServeHTTP(w, r)
+}
+
+func (h *Handler) servePricesWS(ws *websocket.Conn) {
+	defer ws.Close()
+	log := logger.L().WithField("remote", ws.Request().RemoteAddr)
+	// соединение живёт дольше Read/WriteTimeout сервера — снимаем унаследованные дедлайны
+	_ = ws.SetDeadline(time.Time{})
+
+	symbols := make(map[string]bool)
+	for _, s := range splitSymbols(ws.Request().URL.Query().Get("symbols")) {
+		symbols[s] = true
+	}
+
+	// Читатель: сообщения клиента в ctl; при ошибке чтения (клиент ушёл) ctl закрывается
+	ctl := make(chan model.WSRequest)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(ctl)
+		for {
+			var req model.WSRequest
+			if err := websocket.JSON.Receive(ws, &req); err != nil {
+				return
+			}
+			select {
+			case ctl <- req:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var updates <-chan model.Price
+	cancel := func() {}
+	defer func() { cancel() }()
+	// resubscribe переподписывает на текущий набор; пустой набор — без подписки (nil-канал)
+	resubscribe := func() error {
+		cancel()
+		updates, cancel = nil, func() {}
+		if len(symbols) > 0 {
+			updates, cancel = h.service.SubscribePrices(sortedKeys(symbols))
+		}
+		return websocket.JSON.Send(ws, model.WSMessage{Type: wsSubscribed, Symbols: sortedKeys(symbols)})
+	}
+	if err := resubscribe(); err != nil {
+		return
+	}
+	log.WithField("symbols", sortedKeys(symbols)).Debug("PricesWS: connected")
+
+	for {
+		select {
+		case req, ok := <-ctl:
+			if !ok {
+				log.Debug("PricesWS: client gone")
+				return
+			}
+			switch strings.ToLower(req.Action) {
+			case "subscribe":
+				for _, s := range req.Symbols {
+					if s = normSymbol(s); s != "" {
+						symbols[s] = true
+					}
+				}
+			case "unsubscribe":
+				for _, s := range req.Symbols {
+					delete(symbols, normSymbol(s))
+				}
+			default:
+				if err := websocket.JSON.Send(ws, model.WSMessage{Type: wsError, Error: "unknown action: " + req.Action}); err != nil {
+					return
+				}
+				continue
+			}
+			if err := resubscribe(); err != nil {
+				return
+			}
+		case p, ok := <-updates:
+			if !ok {
+				return
+			}
+			dto := toPriceDTO(p)
+			if err := websocket.JSON.Send(ws, model.WSMessage{Type: wsPrice, Data: &dto}); err != nil {
+				log.WithError(err).Debug("PricesWS: send failed")
+				return
+			}
+		case <-h.streams.Done():
+			log.Debug("PricesWS: server shutting down")
+			return
+		}
+	}
+}
+
+// splitSymbols разбирает "btc, ETH,,sol" в [btc eth sol].
+func splitSymbols(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = normSymbol(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func normSymbol(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
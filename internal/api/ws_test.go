@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func dialPricesWS(t *testing.T, f *fakeService, query string) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(NewRouter(NewHandler(f)))
+	t.Cleanup(srv.Close)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/prices" + query
+	ws, err := websocket.Dial(url, "", srv.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { ws.Close() })
+	_ = ws.SetDeadline(time.Now().Add(2 * time.Second))
+	return ws
+}
+
+func recvWS(t *testing.T, ws *websocket.Conn) model.WSMessage {
+	t.Helper()
+	var msg model.WSMessage
+	require.NoError(t, websocket.JSON.Receive(ws, &msg))
+	return msg
+}
+
+func TestPricesWS_StreamsSubscribedSymbols(t *testing.T) {
+	f := &fakeService{prices: make(chan model.Price, 1), subscribe: make(chan []string, 4)}
+	ws := dialPricesWS(t, f, "?symbols=BTC,eth")
+
+	ack := recvWS(t, ws)
+	require.Equal(t, "subscribed", ack.Type)
+	require.Equal(t, []string{"btc", "eth"}, ack.Symbols)
+	require.Equal(t, []string{"btc", "eth"}, <-f.subscribe)
+
+	f.prices <- model.Price{Symbol: "btc", Quote: "usd", TS: 100, Price: model.MustDecimal("0.00001234")}
+	msg := recvWS(t, ws)
+	require.Equal(t, "price", msg.Type)
+	require.NotNil(t, msg.Data)
+	require.Equal(t, "btc", msg.Data.Coin)
+	require.Equal(t, "0.00001234", msg.Data.Price.String())
+}
+
+func TestPricesWS_SubscribeUnsubscribe(t *testing.T) {
+	f := &fakeService{prices: make(chan model.Price), subscribe: make(chan []string, 4)}
+	ws := dialPricesWS(t, f, "")
+
+	require.Empty(t, recvWS(t, ws).Symbols, "no symbols — no subscription")
+
+	require.NoError(t, websocket.JSON.Send(ws, model.WSRequest{Action: "subscribe", Symbols: []string{"sol", "btc"}}))
+	require.Equal(t, []string{"btc", "sol"}, recvWS(t, ws).Symbols)
+	require.Equal(t, []string{"btc", "sol"}, <-f.subscribe)
+
+	require.NoError(t, websocket.JSON.Send(ws, model.WSRequest{Action: "unsubscribe", Symbols: []string{"BTC"}}))
+	require.Equal(t, []string{"sol"}, recvWS(t, ws).Symbols)
+	require.Equal(t, []string{"sol"}, <-f.subscribe)
+
+	require.NoError(t, websocket.JSON.Send(ws, model.WSRequest{Action: "nope"}))
+	msg := recvWS(t, ws)
+	require.Equal(t, "error", msg.Type)
+	require.Contains(t, msg.Error, "unknown action")
+}
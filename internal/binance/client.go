@@ -0,0 +1,72 @@
+// internal/binance/client.go
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"crypto-observer/internal/model"
+)
+
+// Код ошибки Binance "Invalid symbol."
+const invalidSymbolCode = -1121
+
+// Client получает последнюю цену пары <SYMBOL><QUOTE> без потери точности.
+// Для usd берём пару к USDT и считаем их равными.
+type Client struct {
+	base string
+	http *http.Client
+}
+
+func New(base string, timeout time.Duration) *Client {
+	return &Client{
+		base: base,
+		http: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *Client) GetPrice(ctx context.Context, symbol, quote string) (model.Decimal, error) {
+	pair := strings.ToUpper(strings.TrimSpace(symbol)) + toQuote(quote)
+	url := fmt.Sprintf("%s/api/v3/ticker/price?symbol=%s", c.base, pair)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return model.Decimal{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code int `json:"code"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Code == invalidSymbolCode {
+			return model.Decimal{}, fmt.Errorf("binance: %w: %s", model.ErrUnknownCoin, pair)
+		}
+		return model.Decimal{}, fmt.Errorf("binance: unexpected status %d for %s", resp.StatusCode, pair)
+	}
+
+	var body struct {
+		Symbol string `json:"symbol"`
+		Price  string `json:"price"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return model.Decimal{}, err
+	}
+	v, err := model.ParseDecimal(body.Price)
+	if err != nil {
+		return model.Decimal{}, fmt.Errorf("binance: bad price %q: %w", body.Price, err)
+	}
+	return v, nil
+}
+
+func toQuote(quote string) string {
+	q := strings.ToUpper(strings.TrimSpace(quote))
+	if q == "USD" {
+		return "USDT"
+	}
+	return q
+}
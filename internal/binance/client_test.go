@@ -0,0 +1,94 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+)
+
+func TestGetPrice_OK(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path + "?" + r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"symbol":"BTCUSDT","price":"123.45000000"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	price, err := c.GetPrice(context.Background(), "btc", "usd")
+	if err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if price.String() != "123.45" {
+		t.Fatalf("want 123.45, got %s", price)
+	}
+	if gotPath != "/api/v3/ticker/price?symbol=BTCUSDT" {
+		t.Fatalf("unexpected request: %s", gotPath)
+	}
+}
+
+func TestGetPrice_BadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	if _, err := c.GetPrice(context.Background(), "nope", "usd"); !errors.Is(err, model.ErrUnknownCoin) {
+		t.Fatalf("want ErrUnknownCoin, got %v", err)
+	}
+}
+
+func TestGetPrice_ServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	_, err := c.GetPrice(context.Background(), "btc", "usd")
+	if err == nil || errors.Is(err, model.ErrUnknownCoin) {
+		t.Fatalf("want plain status error, got %v", err)
+	}
+}
+
+func TestGetPrice_NonUSDQuote(t *testing.T) {
+	var gotSymbol string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSymbol = r.URL.Query().Get("symbol")
+		_, _ = w.Write([]byte(`{"symbol":"BTCEUR","price":"90.10"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	price, err := c.GetPrice(context.Background(), "btc", "eur")
+	if err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if gotSymbol != "BTCEUR" || price.String() != "90.1" {
+		t.Fatalf("want BTCEUR 90.1, got %s %s", gotSymbol, price)
+	}
+}
+
+func TestGetPrice_SubCent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"symbol":"PEPEUSDT","price":"0.00001234"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	price, err := c.GetPrice(context.Background(), "pepe", "usd")
+	if err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if price.String() != "0.00001234" {
+		t.Fatalf("want 0.00001234, got %s", price)
+	}
+}
@@ -0,0 +1,58 @@
+// internal/coinbase/client.go
+package coinbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"crypto-observer/internal/model"
+)
+
+// Client получает спот-цену <SYMBOL>-<QUOTE> без потери точности
+type Client struct {
+	base string
+	http *http.Client
+}
+
+func New(base string, timeout time.Duration) *Client {
+	return &Client{
+		base: base,
+		http: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *Client) GetPrice(ctx context.Context, symbol, quote string) (model.Decimal, error) {
+	pair := strings.ToUpper(strings.TrimSpace(symbol)) + "-" + strings.ToUpper(strings.TrimSpace(quote))
+	url := fmt.Sprintf("%s/v2/prices/%s/spot", c.base, pair)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return model.Decimal{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return model.Decimal{}, fmt.Errorf("coinbase: %w: %s", model.ErrUnknownCoin, pair)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return model.Decimal{}, fmt.Errorf("coinbase: unexpected status %d for %s", resp.StatusCode, pair)
+	}
+
+	// {"data":{"amount":"123.45","base":"BTC","currency":"USD"}}
+	var body struct {
+		Data struct {
+			Amount string `json:"amount"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return model.Decimal{}, err
+	}
+	v, err := model.ParseDecimal(body.Data.Amount)
+	if err != nil {
+		return model.Decimal{}, fmt.Errorf("coinbase: bad price %q: %w", body.Data.Amount, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,64 @@
+package coinbase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+)
+
+func TestGetPrice_OK(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"amount":"123.45","base":"BTC","currency":"USD"}}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	price, err := c.GetPrice(context.Background(), "btc", "usd")
+	if err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if price.String() != "123.45" {
+		t.Fatalf("want 123.45, got %s", price)
+	}
+	if gotPath != "/v2/prices/BTC-USD/spot" {
+		t.Fatalf("unexpected path: %s", gotPath)
+	}
+}
+
+func TestGetPrice_BadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	if _, err := c.GetPrice(context.Background(), "nope", "usd"); !errors.Is(err, model.ErrUnknownCoin) {
+		t.Fatalf("want ErrUnknownCoin, got %v", err)
+	}
+}
+
+func TestGetPrice_EURQuote(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"data":{"amount":"90.00","base":"BTC","currency":"EUR"}}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	price, err := c.GetPrice(context.Background(), "btc", "eur")
+	if err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if gotPath != "/v2/prices/BTC-EUR/spot" || price.String() != "90" {
+		t.Fatalf("want BTC-EUR 90, got %s %s", gotPath, price)
+	}
+}
@@ -4,37 +4,228 @@ package coingecko
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"crypto-observer/internal/model"
 )
 
-// Client получает цену в USD и возвращает её в центах
+// Options — настройки устойчивости клиента; нулевые значения выключают ретраи и лимитер.
+type Options struct {
+	Timeout     time.Duration
+	MaxRetries  int           // повторов после первой попытки
+	BaseBackoff time.Duration // задержка перед первым повтором, дальше растёт вдвое
+	MaxBackoff  time.Duration // потолок экспоненты и ожидания по Retry-After
+	RatePerMin  float64       // лимит запросов в минуту; 0 — без лимитера
+	Burst       int
+}
+
+// Client получает точную десятичную цену монеты в нужной валюте (usd, eur, btc, ...)
 type Client struct {
-	base string
-	http *http.Client
+	base    string
+	http    *http.Client
+	opts    Options
+	limiter *tokenBucket
 }
 
 func New(base string, timeout time.Duration) *Client {
-	return &Client{
+	return NewWithOptions(base, Options{Timeout: timeout})
+}
+
+func NewWithOptions(base string, opts Options) *Client {
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 500 * time.Millisecond
+	}
+	if opts.MaxBackoff < opts.BaseBackoff {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	c := &Client{
 		base: base,
-		http: &http.Client{Timeout: timeout},
+		http: &http.Client{Timeout: opts.Timeout},
+		opts: opts,
+	}
+	if opts.RatePerMin > 0 {
+		c.limiter = newTokenBucket(opts.RatePerMin/60, opts.Burst)
+	}
+	return c
+}
+
+// GetPrice — цена монеты id в валюте quote (usd, eur, btc, ...).
+func (c *Client) GetPrice(ctx context.Context, id, quote string) (model.Decimal, error) {
+	m, err := c.simplePrice(ctx, []string{id}, []string{quote})
+	if err != nil {
+		return model.Decimal{}, err
+	}
+	q, ok := m[id]
+	if !ok {
+		// CoinGecko на неизвестный id отвечает 200 и пустым объектом
+		return model.Decimal{}, fmt.Errorf("coingecko: %w: %s", model.ErrUnknownCoin, id)
+	}
+	v, ok := q[quote]
+	if !ok {
+		return model.Decimal{}, fmt.Errorf("coingecko: %w: %s", model.ErrUnsupportedQuote, quote)
+	}
+	return v, nil
+}
+
+// GetPrices запрашивает цены нескольких монет в нескольких валютах
+// одним вызовом /simple/price. Результат: id → quote → цена; только то, что CoinGecko вернул.
+func (c *Client) GetPrices(ctx context.Context, ids, quotes []string) (map[string]map[string]model.Decimal, error) {
+	return c.simplePrice(ctx, ids, quotes)
+}
+
+// Coin — элемент справочника /coins/list
+type Coin struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+// ListCoins загружает полный справочник монет CoinGecko (десятки тысяч записей).
+func (c *Client) ListCoins(ctx context.Context) ([]Coin, error) {
+	var out []Coin
+	if err := c.getJSON(ctx, c.base+"/coins/list", &out); err != nil {
+		return nil, err
 	}
+	return out, nil
 }
 
-func (c *Client) GetPriceCents(ctx context.Context, symbol string) (int64, error) {
-	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=usd", c.base, symbol)
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// ChartPoint — точка исторического ряда цены.
+type ChartPoint struct {
+	TS    int64 // unix seconds
+	Price model.Decimal
+}
+
+// MarketChartRange — исторические цены монеты id в валюте quote за [from, to] (unix seconds)
+// из /coins/{id}/market_chart/range. Шаг CoinGecko выбирает сам по длине диапазона:
+// до суток — около 5 минут, до 90 дней — час, дальше — сутки.
+func (c *Client) MarketChartRange(ctx context.Context, id, quote string, from, to int64) ([]ChartPoint, error) {
+	u := fmt.Sprintf("%s/coins/%s/market_chart/range?vs_currency=%s&from=%d&to=%d", c.base,
+		url.PathEscape(id), url.QueryEscape(quote), from, to)
+	var resp struct {
+		Prices [][2]json.Number `json:"prices"`
+	}
+	if err := c.getJSON(ctx, u, &resp); err != nil {
+		var se *StatusError
+		if errors.As(err, &se) && se.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("coingecko: %w: %s", model.ErrUnknownCoin, id)
+		}
+		return nil, err
+	}
+	out := make([]ChartPoint, 0, len(resp.Prices))
+	for _, p := range resp.Prices {
+		ms, err := p[0].Int64()
+		if err != nil {
+			// метка времени иногда приходит дробной
+			f, ferr := p[0].Float64()
+			if ferr != nil {
+				return nil, fmt.Errorf("coingecko: bad timestamp %q", p[0])
+			}
+			ms = int64(f)
+		}
+		price, err := model.ParseDecimal(p[1].String())
+		if err != nil {
+			return nil, fmt.Errorf("coingecko: bad price at %d: %w", ms, err)
+		}
+		out = append(out, ChartPoint{TS: ms / 1000, Price: price})
+	}
+	return out, nil
+}
+
+// simplePrice разбирает цены прямо из текста JSON в model.Decimal: мелкие монеты
+// вроде 1.234e-05 не обнуляются округлением через float64.
+func (c *Client) simplePrice(ctx context.Context, ids, quotes []string) (map[string]map[string]model.Decimal, error) {
+	u := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=%s", c.base,
+		url.QueryEscape(strings.Join(ids, ",")), url.QueryEscape(strings.Join(quotes, ",")))
+	var m map[string]map[string]model.Decimal
+	if err := c.getJSON(ctx, u, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// getJSON выполняет GET с лимитером и повторами на 429/5xx/сетевых ошибках.
+func (c *Client) getJSON(ctx context.Context, u string, out any) error {
+	for attempt := 0; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		err := c.doJSON(ctx, u, out)
+		if err == nil || attempt >= c.opts.MaxRetries || !retryable(ctx, err) {
+			return err
+		}
+
+		delay := c.backoff(attempt)
+		var se *StatusError
+		retryAfter := errors.As(err, &se) && se.RetryAfter > 0
+		if retryAfter {
+			delay = min(se.RetryAfter, c.opts.MaxBackoff)
+		}
+		// повтор не успеет до дедлайна — отдаём ошибку сразу, не замораживая лимитер
+		if dl, ok := ctx.Deadline(); ok && time.Until(dl) < delay {
+			return err
+		}
+		if retryAfter && c.limiter != nil {
+			c.limiter.holdUntil(time.Now().Add(delay))
+		}
+		// токен на повтор берётся в начале цикла, уже после ожидания
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		}
+	}
+}
+
+func (c *Client) doJSON(ctx context.Context, u string, out any) error {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return 0, err
+		return err
 	}
 	defer resp.Body.Close()
 
-	var m map[string]map[string]float64
-	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
-		return 0, err
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Body:       strings.TrimSpace(string(body)),
+		}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// backoff — экспонента от BaseBackoff с "equal jitter": [d/2, d].
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.opts.BaseBackoff << attempt
+	if d <= 0 || d > c.opts.MaxBackoff {
+		d = c.opts.MaxBackoff
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Temporary()
 	}
-	usd := m[symbol]["usd"]
-	return int64(usd * 100), nil
+	// битый JSON повтором не лечится; остальное — сетевые ошибки
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) && !errors.Is(err, io.ErrUnexpectedEOF)
 }
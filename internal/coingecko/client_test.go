@@ -2,10 +2,14 @@ package coingecko
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"crypto-observer/internal/model"
 )
 
 func TestGetPriceUSD_OK(t *testing.T) {
@@ -16,12 +20,12 @@ func TestGetPriceUSD_OK(t *testing.T) {
 	defer srv.Close()
 
 	c := New(srv.URL, time.Second)
-	cents, err := c.GetPriceCents(context.Background(), "btc")
+	price, err := c.GetPrice(context.Background(), "btc", "usd")
 	if err != nil {
 		t.Fatalf("GetPriceUSD: %v", err)
 	}
-	if cents != 12345 {
-		t.Fatalf("want 12345, got %d", cents)
+	if price.String() != "123.45" {
+		t.Fatalf("want 123.45, got %s", price)
 	}
 }
 
@@ -32,7 +36,339 @@ func TestGetPriceUSD_BadStatus(t *testing.T) {
 	defer srv.Close()
 
 	c := New(srv.URL, time.Second)
-	if _, err := c.GetPriceCents(context.Background(), "btc"); err == nil {
+	if _, err := c.GetPrice(context.Background(), "btc", "usd"); err == nil {
 		t.Fatalf("expected error on bad status")
 	}
 }
+
+func TestGetPrices_Batch(t *testing.T) {
+	var gotIDs string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIDs = r.URL.Query().Get("ids")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":100.5},"ethereum":{"usd":2.25}}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	got, err := c.GetPrices(context.Background(), []string{"bitcoin", "ethereum", "nope"}, []string{"usd"})
+	if err != nil {
+		t.Fatalf("GetPrices: %v", err)
+	}
+	if gotIDs != "bitcoin,ethereum,nope" {
+		t.Fatalf("want one request for all ids, got ids=%q", gotIDs)
+	}
+	if got["bitcoin"]["usd"].String() != "100.5" || got["ethereum"]["usd"].String() != "2.25" {
+		t.Fatalf("unexpected prices: %v", got)
+	}
+	if _, ok := got["nope"]; ok {
+		t.Fatalf("unknown id must be absent from result")
+	}
+}
+
+func TestGetPrice_BadStatus_Typed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	_, err := c.GetPrice(context.Background(), "bitcoin", "usd")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("want ErrRateLimited, got %v", err)
+	}
+	var se *StatusError
+	if !errors.As(err, &se) || se.RetryAfter != 7*time.Second {
+		t.Fatalf("want StatusError with RetryAfter=7s, got %#v", err)
+	}
+}
+
+func TestGetPrice_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":1}}`))
+	}))
+	defer srv.Close()
+
+	c := NewWithOptions(srv.URL, Options{Timeout: time.Second, MaxRetries: 3, BaseBackoff: time.Millisecond})
+	price, err := c.GetPrice(context.Background(), "bitcoin", "usd")
+	if err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if price.String() != "1" || atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("want 1 after 3 calls, got %s after %d", price, calls)
+	}
+}
+
+func TestGetPrice_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewWithOptions(srv.URL, Options{Timeout: time.Second, MaxRetries: 2, BaseBackoff: time.Millisecond})
+	_, err := c.GetPrice(context.Background(), "bitcoin", "usd")
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("want ErrServer, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Fatalf("want 1 attempt + 2 retries, got %d calls", got)
+	}
+}
+
+func TestGetPrice_NoRetryOnClientError(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := NewWithOptions(srv.URL, Options{Timeout: time.Second, MaxRetries: 3, BaseBackoff: time.Millisecond})
+	if _, err := c.GetPrice(context.Background(), "bitcoin", "usd"); err == nil {
+		t.Fatalf("expected error on 404")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("4xx must not be retried, got %d calls", got)
+	}
+}
+
+func TestGetPrice_HonorsRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":1}}`))
+	}))
+	defer srv.Close()
+
+	c := NewWithOptions(srv.URL, Options{Timeout: time.Second, MaxRetries: 1, BaseBackoff: time.Millisecond})
+	start := time.Now()
+	if _, err := c.GetPrice(context.Background(), "bitcoin", "usd"); err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if el := time.Since(start); el < 900*time.Millisecond {
+		t.Fatalf("Retry-After must be honored, retried after %v", el)
+	}
+}
+
+func TestGetPrice_RetryAfterCapped(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":1}}`))
+	}))
+	defer srv.Close()
+
+	c := NewWithOptions(srv.URL, Options{Timeout: time.Second, MaxRetries: 1, BaseBackoff: time.Millisecond, MaxBackoff: 50 * time.Millisecond, RatePerMin: 6000, Burst: 1})
+	start := time.Now()
+	if _, err := c.GetPrice(context.Background(), "bitcoin", "usd"); err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if el := time.Since(start); el > time.Second {
+		t.Fatalf("Retry-After must be capped by MaxBackoff, took %v", el)
+	}
+}
+
+func TestGetPrice_RetryAfterBeyondDeadline(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := NewWithOptions(srv.URL, Options{Timeout: time.Second, MaxRetries: 3, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetPrice(ctx, "bitcoin", "usd")
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("want the 429 back, got %v", err)
+	}
+	if el := time.Since(start); el > 200*time.Millisecond {
+		t.Fatalf("must not wait past the deadline, took %v", el)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("want 1 call, got %d", n)
+	}
+}
+
+func TestGetPrice_RateLimiter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":1}}`))
+	}))
+	defer srv.Close()
+
+	// 1200/мин = 20/с, burst 1 → третий запрос не раньше чем через ~100мс
+	c := NewWithOptions(srv.URL, Options{Timeout: time.Second, RatePerMin: 1200, Burst: 1})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetPrice(context.Background(), "bitcoin", "usd"); err != nil {
+			t.Fatalf("GetPrice: %v", err)
+		}
+	}
+	if el := time.Since(start); el < 90*time.Millisecond {
+		t.Fatalf("limiter must space requests, took %v", el)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		"junk":                          0,
+		"Mon, 01 Jan 2024 00:00:30 GMT": 30 * time.Second,
+	}
+	for in, want := range cases {
+		if got := parseRetryAfter(in, now); got != want {
+			t.Fatalf("parseRetryAfter(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestGetPrice_UnknownCoin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	if _, err := c.GetPrice(context.Background(), "bitcoinn", "usd"); !errors.Is(err, model.ErrUnknownCoin) {
+		t.Fatalf("want ErrUnknownCoin, got %v", err)
+	}
+}
+
+func TestListCoins_OK(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/coins/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id":"bitcoin","symbol":"btc","name":"Bitcoin"},{"id":"uniswap","symbol":"uni","name":"Uniswap"}]`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	coins, err := c.ListCoins(context.Background())
+	if err != nil {
+		t.Fatalf("ListCoins: %v", err)
+	}
+	if len(coins) != 2 || coins[1] != (Coin{ID: "uniswap", Symbol: "uni", Name: "Uniswap"}) {
+		t.Fatalf("unexpected coins: %#v", coins)
+	}
+}
+
+func TestGetPrice_Quote(t *testing.T) {
+	var gotVs string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotVs = r.URL.Query().Get("vs_currencies")
+		_, _ = w.Write([]byte(`{"bitcoin":{"eur":90.5}}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	price, err := c.GetPrice(context.Background(), "bitcoin", "eur")
+	if err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if gotVs != "eur" || price.String() != "90.5" {
+		t.Fatalf("want eur 90.5, got vs=%q price=%s", gotVs, price)
+	}
+
+	if _, err := c.GetPrice(context.Background(), "bitcoin", "xyz"); !errors.Is(err, model.ErrUnsupportedQuote) {
+		t.Fatalf("want ErrUnsupportedQuote, got %v", err)
+	}
+}
+
+func TestGetPrices_MultiQuote(t *testing.T) {
+	var gotVs string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotVs = r.URL.Query().Get("vs_currencies")
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":2,"eur":1.5}}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	got, err := c.GetPrices(context.Background(), []string{"bitcoin"}, []string{"usd", "eur"})
+	if err != nil {
+		t.Fatalf("GetPrices: %v", err)
+	}
+	if gotVs != "usd,eur" || got["bitcoin"]["usd"].String() != "2" || got["bitcoin"]["eur"].String() != "1.5" {
+		t.Fatalf("unexpected: vs=%q got=%v", gotVs, got)
+	}
+}
+
+func TestGetPrice_SubCentExact(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"pepe":{"usd":1.234e-05},"shiba-inu":{"usd":0.00001337}}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	got, err := c.GetPrices(context.Background(), []string{"pepe", "shiba-inu"}, []string{"usd"})
+	if err != nil {
+		t.Fatalf("GetPrices: %v", err)
+	}
+	if got["pepe"]["usd"].String() != "0.00001234" || got["shiba-inu"]["usd"].String() != "0.00001337" {
+		t.Fatalf("sub-cent prices must survive parsing: %v", got)
+	}
+}
+
+func TestMarketChartRange_OK(t *testing.T) {
+	var gotPath, gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"prices":[[1711929600000,69702.3087473573],[1711933200123.5,1.234e-05]],"market_caps":[],"total_volumes":[]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	got, err := c.MarketChartRange(context.Background(), "bitcoin", "usd", 1711929600, 1711933300)
+	if err != nil {
+		t.Fatalf("MarketChartRange: %v", err)
+	}
+	if gotPath != "/coins/bitcoin/market_chart/range" || gotQuery != "vs_currency=usd&from=1711929600&to=1711933300" {
+		t.Fatalf("unexpected request %s?%s", gotPath, gotQuery)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 points, got %d", len(got))
+	}
+	if got[0].TS != 1711929600 || got[0].Price.String() != "69702.3087473573" {
+		t.Fatalf("unexpected first point %+v", got[0])
+	}
+	if got[1].TS != 1711933200 || got[1].Price.String() != "0.00001234" {
+		t.Fatalf("unexpected second point %d %s", got[1].TS, got[1].Price)
+	}
+}
+
+func TestMarketChartRange_UnknownCoin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"coin not found"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	if _, err := c.MarketChartRange(context.Background(), "nope", "usd", 0, 1); !errors.Is(err, model.ErrUnknownCoin) {
+		t.Fatalf("want ErrUnknownCoin, got %v", err)
+	}
+}
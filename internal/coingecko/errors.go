@@ -0,0 +1,58 @@
+package coingecko
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrRateLimited = errors.New("coingecko: rate limited")
+	ErrServer      = errors.New("coingecko: server error")
+)
+
+// StatusError — ответ CoinGecko с кодом, отличным от 200.
+// errors.Is(err, ErrRateLimited) / errors.Is(err, ErrServer) работают поверх него.
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // из заголовка Retry-After; 0 — не задан
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("coingecko: unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("coingecko: unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// Temporary — имеет ли смысл повторить запрос
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// parseRetryAfter понимает оба формата заголовка: секунды и HTTP-дату.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+package coingecko
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket — клиентский лимитер: rate токенов в секунду, не больше burst в запасе.
+// holdUntil позволяет заморозить выдачу после 429, чтобы не добивать API остальными запросами.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	hold   time.Time
+}
+
+func newTokenBucket(ratePerSec float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   ratePerSec,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait блокируется до появления токена или отмены контекста.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		delay := b.reserve(time.Now())
+		if delay <= 0 {
+			return nil
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve забирает токен, если он есть, иначе возвращает, сколько ждать.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Before(b.hold) {
+		return b.hold.Sub(now)
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) holdUntil(t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t.After(b.hold) {
+		b.hold = t
+	}
+}
@@ -3,7 +3,9 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"time"
 
 	"crypto-observer/internal/model"
 	"crypto-observer/pkg/logger"
@@ -17,6 +19,7 @@ type poolIface interface {
 	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Ping(ctx context.Context) error
 	Close()
 }
 
@@ -43,15 +46,125 @@ func NewStorage(dsn string) (*Storage, error) {
 
 func newWithPool(p poolIface) *Storage { return &Storage{pool: p} }
 
+// Ping проверяет, что до Postgres есть живое соединение (для /readyz).
+func (s *Storage) Ping(ctx context.Context) error { return s.pool.Ping(ctx) }
+
+// PoolStat — статистика пула для метрик; nil, если Storage создан не поверх pgxpool.
+func (s *Storage) PoolStat() *pgxpool.Stat {
+	if p, ok := s.pool.(*pgxpool.Pool); ok {
+		return p.Stat()
+	}
+	return nil
+}
+
 func (s *Storage) EnsureSchema(ctx context.Context) error {
 	const q = `
 CREATE TABLE IF NOT EXISTS prices (
     id           BIGSERIAL PRIMARY KEY,
     symbol       VARCHAR(32) NOT NULL,
     ts           BIGINT      NOT NULL,
-    price_cents  BIGINT      NOT NULL
+    price        NUMERIC     NOT NULL
 );
 CREATE INDEX IF NOT EXISTS idx_prices_symbol_ts ON prices(symbol, ts DESC);
+
+-- старые базы хранили центы в BIGINT; переводим в точные NUMERIC-значения
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'prices' AND column_name = 'price_cents') THEN
+        ALTER TABLE prices ALTER COLUMN price_cents TYPE NUMERIC USING round(price_cents::numeric / 100, 2);
+        ALTER TABLE prices RENAME COLUMN price_cents TO price;
+    END IF;
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'prices' AND column_name = 'spread_cents') THEN
+        ALTER TABLE prices ALTER COLUMN spread_cents TYPE NUMERIC USING round(spread_cents::numeric / 100, 2);
+        ALTER TABLE prices RENAME COLUMN spread_cents TO spread;
+    END IF;
+END $$;
+
+ALTER TABLE prices ADD COLUMN IF NOT EXISTS sources      INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE prices ADD COLUMN IF NOT EXISTS spread       NUMERIC NOT NULL DEFAULT 0;
+ALTER TABLE prices ADD COLUMN IF NOT EXISTS quote        VARCHAR(10) NOT NULL DEFAULT 'usd';
+
+-- одна цена на (symbol, quote, ts); повторы из старых баз убираем один раз, до индекса
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_indexes
+                   WHERE tablename = 'prices' AND indexname = 'uq_prices_symbol_quote_ts') THEN
+        DELETE FROM prices a USING prices b
+        WHERE a.symbol = b.symbol AND a.quote = b.quote AND a.ts = b.ts AND a.id > b.id;
+        CREATE UNIQUE INDEX uq_prices_symbol_quote_ts ON prices(symbol, quote, ts);
+    END IF;
+END $$;
+DROP INDEX IF EXISTS idx_prices_symbol_quote_ts;
+
+CREATE TABLE IF NOT EXISTS watchlist (
+    symbol      VARCHAR(32) PRIMARY KEY,
+    period_s    INTEGER     NOT NULL,
+    created_at  BIGINT      NOT NULL,
+    paused      BOOLEAN     NOT NULL DEFAULT FALSE
+);
+ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS provider_id VARCHAR(128) NOT NULL DEFAULT '';
+ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS quotes      TEXT[]       NOT NULL DEFAULT '{usd}';
+ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS aligned     BOOLEAN      NOT NULL DEFAULT FALSE;
+ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS cron        TEXT         NOT NULL DEFAULT '';
+ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS windows     JSONB        NOT NULL DEFAULT '[]';
+
+CREATE TABLE IF NOT EXISTS symbol_map (
+    symbol      VARCHAR(32)  PRIMARY KEY,
+    provider_id VARCHAR(128) NOT NULL,
+    updated_at  BIGINT       NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS alerts (
+    id          BIGSERIAL   PRIMARY KEY,
+    symbol      VARCHAR(32) NOT NULL,
+    quote       VARCHAR(10) NOT NULL DEFAULT 'usd',
+    kind        VARCHAR(16) NOT NULL,
+    threshold   NUMERIC     NOT NULL,
+    window_s    BIGINT      NOT NULL DEFAULT 0,
+    cooldown_s  BIGINT      NOT NULL DEFAULT 0,
+    state       VARCHAR(16) NOT NULL DEFAULT 'armed',
+    fired_at    BIGINT      NOT NULL DEFAULT 0,
+    fired_price NUMERIC     NOT NULL DEFAULT 0,
+    created_at  BIGINT      NOT NULL
+);
+
+-- очередь исходящих вебхуков: payload — TEXT, чтобы подписанные байты не переформатировались
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              BIGSERIAL   PRIMARY KEY,
+    endpoint        VARCHAR(64) NOT NULL,
+    url             TEXT        NOT NULL,
+    event           VARCHAR(64) NOT NULL,
+    payload         TEXT        NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT      NOT NULL,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    last_code       INTEGER     NOT NULL DEFAULT 0,
+    created_at      BIGINT      NOT NULL,
+    delivered_at    BIGINT      NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+    id          BIGSERIAL    PRIMARY KEY,
+    symbol      VARCHAR(32)  NOT NULL,
+    provider_id VARCHAR(128) NOT NULL,
+    quote       VARCHAR(10)  NOT NULL,
+    from_ts     BIGINT       NOT NULL,
+    to_ts       BIGINT       NOT NULL,
+    cursor_ts   BIGINT       NOT NULL,
+    state       VARCHAR(16)  NOT NULL DEFAULT 'pending',
+    fetched     INTEGER      NOT NULL DEFAULT 0,
+    inserted    INTEGER      NOT NULL DEFAULT 0,
+    error       TEXT         NOT NULL DEFAULT '',
+    created_at  BIGINT       NOT NULL,
+    started_at  BIGINT       NOT NULL DEFAULT 0,
+    updated_at  BIGINT       NOT NULL DEFAULT 0,
+    finished_at BIGINT       NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_backfill_jobs_active ON backfill_jobs(id) WHERE state IN ('pending', 'running');
 `
 	_, err := s.pool.Exec(ctx, q)
 	if err != nil {
@@ -60,35 +173,715 @@ CREATE INDEX IF NOT EXISTS idx_prices_symbol_ts ON prices(symbol, ts DESC);
 	return err
 }
 
-func (s *Storage) SavePrice(ctx context.Context, p model.Price) error {
-	const q = `INSERT INTO prices (symbol, ts, price_cents) VALUES ($1, $2, $3)`
-	_, err := s.pool.Exec(ctx, q, p.Symbol, p.TS, p.Price)
+// SavePrice пишет цену как есть: десятичная строка → NUMERIC без округлений.
+// Возвращает id строки — по (ts, id) клиенты потока досылают пропущенное.
+// Если на этот ts цена уже есть (например, из догрузки истории), её заменяет
+// свежая: строка одна, id у неё прежний.
+func (s *Storage) SavePrice(ctx context.Context, p model.Price) (int64, error) {
+	const q = `
+INSERT INTO prices (symbol, quote, ts, price, sources, spread) VALUES ($1, $2, $3, $4::numeric, $5, $6::numeric)
+ON CONFLICT (symbol, quote, ts) DO UPDATE SET price = EXCLUDED.price, sources = EXCLUDED.sources, spread = EXCLUDED.spread
+RETURNING id`
+	sources := p.Sources
+	if sources <= 0 {
+		sources = 1
+	}
+	quote := p.Quote
+	if quote == "" {
+		quote = model.DefaultQuote
+	}
+	var id int64
+	err := s.pool.QueryRow(ctx, q, p.Symbol, quote, p.TS, p.Price.String(), sources, p.Spread.String()).Scan(&id)
 	if err != nil {
 		logger.L().WithError(err).Error("DB: SavePrice failed")
+		return 0, err
 	}
-	return err
+	return id, nil
+}
+
+// InsertPrices пишет пачку цен одним запросом, пропуская те, что уже есть в prices
+// (та же тройка symbol, quote, ts) или повторяются в самой пачке. Возвращает, сколько записано.
+func (s *Storage) InsertPrices(ctx context.Context, ps []model.Price) (int, error) {
+	const q = `
+INSERT INTO prices (symbol, quote, ts, price, sources, spread)
+SELECT DISTINCT ON (t.symbol, t.quote, t.ts) t.symbol, t.quote, t.ts, t.price::numeric, t.sources, t.spread::numeric
+FROM unnest($1::text[], $2::text[], $3::bigint[], $4::text[], $5::int[], $6::text[])
+     AS t(symbol, quote, ts, price, sources, spread)
+ON CONFLICT (symbol, quote, ts) DO NOTHING`
+	if len(ps) == 0 {
+		return 0, nil
+	}
+	n := len(ps)
+	symbols, quotes, prices, spreads := make([]string, n), make([]string, n), make([]string, n), make([]string, n)
+	tss, sources := make([]int64, n), make([]int32, n)
+	for i, p := range ps {
+		symbols[i], quotes[i], tss[i] = p.Symbol, p.Quote, p.TS
+		prices[i], spreads[i], sources[i] = p.Price.String(), p.Spread.String(), int32(max(p.Sources, 1))
+		if quotes[i] == "" {
+			quotes[i] = model.DefaultQuote
+		}
+	}
+	tag, err := s.pool.Exec(ctx, q, symbols, quotes, tss, prices, sources, spreads)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: InsertPrices failed")
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
 }
 
-func (s *Storage) GetClosestPrice(ctx context.Context, symbol string, ts int64) (*model.Price, error) {
+func (s *Storage) GetClosestPrice(ctx context.Context, symbol, quote string, ts int64) (*model.Price, error) {
 	const q = `
-SELECT symbol, ts, price_cents
+SELECT symbol, quote, ts, price::text, sources, spread::text
 FROM prices
-WHERE symbol = $1 AND ts <= $2
+WHERE symbol = $1 AND quote = $2 AND ts <= $3
 ORDER BY ts DESC
 LIMIT 1`
-	row := s.pool.QueryRow(ctx, q, symbol, ts)
+	row := s.pool.QueryRow(ctx, q, symbol, quote, ts)
 
-	var out model.Price
-	if err := row.Scan(&out.Symbol, &out.TS, &out.Price); err != nil {
+	var (
+		out           model.Price
+		price, spread string
+	)
+	if err := row.Scan(&out.Symbol, &out.Quote, &out.TS, &price, &out.Sources, &spread); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
 		logger.L().WithError(err).Error("DB: GetClosestPrice failed")
 		return nil, err
 	}
+	if err := scanDecimals([]string{price, spread}, &out.Price, &out.Spread); err != nil {
+		logger.L().WithError(err).Error("DB: GetClosestPrice failed")
+		return nil, err
+	}
 	return &out, nil
 }
 
+// GetPriceRange читает ряд цен за [from, to] в порядке ts, начиная строго после
+// курсора after (nil — с начала диапазона). Берём limit+1 строку, чтобы понять,
+// есть ли следующая страница.
+func (s *Storage) GetPriceRange(ctx context.Context, symbol, quote string, from, to int64, after *model.HistoryCursor, limit int) (*model.HistoryPage, error) {
+	const q = `
+SELECT id, symbol, quote, ts, price::text, sources, spread::text
+FROM prices
+WHERE symbol = $1 AND quote = $2 AND ts >= $3 AND ts <= $4 AND (ts, id) > ($5, $6)
+ORDER BY ts, id
+LIMIT $7`
+	cur := model.HistoryCursor{TS: from}
+	if after != nil {
+		cur = *after
+	}
+	rows, err := s.pool.Query(ctx, q, symbol, quote, from, to, cur.TS, cur.ID, limit+1)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: GetPriceRange failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &model.HistoryPage{Items: make([]model.Price, 0, limit)}
+	var last model.HistoryCursor
+	for rows.Next() {
+		var (
+			p     model.Price
+			price [2]string
+		)
+		if err := rows.Scan(&p.ID, &p.Symbol, &p.Quote, &p.TS, &price[0], &p.Sources, &price[1]); err != nil {
+			logger.L().WithError(err).Error("DB: GetPriceRange scan failed")
+			return nil, err
+		}
+		if err := scanDecimals(price[:], &p.Price, &p.Spread); err != nil {
+			logger.L().WithError(err).Error("DB: GetPriceRange scan failed")
+			return nil, err
+		}
+		if len(page.Items) == limit {
+			page.Next = &last
+			break
+		}
+		page.Items = append(page.Items, p)
+		last = model.HistoryCursor{TS: p.TS, ID: p.ID}
+	}
+	if err := rows.Err(); err != nil {
+		logger.L().WithError(err).Error("DB: GetPriceRange failed")
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetPricesSince читает сохранённые цены тикеров строго после позиции after по (ts, id)
+// (во всех валютах котировки), не больше limit строк. На одном ts бывает много строк
+// (выровненные тики, несколько валют), поэтому одного ts для позиции мало.
+func (s *Storage) GetPricesSince(ctx context.Context, symbols []string, after model.HistoryCursor, limit int) ([]model.Price, error) {
+	const q = `
+SELECT id, symbol, quote, ts, price::text, sources, spread::text
+FROM prices
+WHERE symbol = ANY($1) AND (ts, id) > ($2, $3)
+ORDER BY ts, id
+LIMIT $4`
+	rows, err := s.pool.Query(ctx, q, symbols, after.TS, after.ID, limit)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: GetPricesSince failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.Price{}
+	for rows.Next() {
+		var (
+			p     model.Price
+			price [2]string
+		)
+		if err := rows.Scan(&p.ID, &p.Symbol, &p.Quote, &p.TS, &price[0], &p.Sources, &price[1]); err != nil {
+			logger.L().WithError(err).Error("DB: GetPricesSince scan failed")
+			return nil, err
+		}
+		if err := scanDecimals(price[:], &p.Price, &p.Spread); err != nil {
+			logger.L().WithError(err).Error("DB: GetPricesSince scan failed")
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		logger.L().WithError(err).Error("DB: GetPricesSince failed")
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetCandles агрегирует prices за [from, to] в бакеты по bucketSec секунд.
+// open/close — первая и последняя цена бакета по (ts, id).
+func (s *Storage) GetCandles(ctx context.Context, symbol, quote string, bucketSec, from, to int64) ([]model.Candle, error) {
+	const q = `
+SELECT (ts / $3) * $3 AS bucket,
+       ((array_agg(price ORDER BY ts, id))[1])::text           AS open,
+       MAX(price)::text                                        AS high,
+       MIN(price)::text                                        AS low,
+       ((array_agg(price ORDER BY ts DESC, id DESC))[1])::text AS close,
+       COUNT(*)                                                AS cnt
+FROM prices
+WHERE symbol = $1 AND quote = $2 AND ts >= $4 AND ts <= $5
+GROUP BY bucket
+ORDER BY bucket`
+	rows, err := s.pool.Query(ctx, q, symbol, quote, bucketSec, from, to)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: GetCandles failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.Candle{}
+	for rows.Next() {
+		var (
+			c    = model.Candle{Symbol: symbol}
+			ohlc [4]string
+		)
+		if err := rows.Scan(&c.TS, &ohlc[0], &ohlc[1], &ohlc[2], &ohlc[3], &c.Count); err != nil {
+			logger.L().WithError(err).Error("DB: GetCandles scan failed")
+			return nil, err
+		}
+		if err := scanDecimals(ohlc[:], &c.Open, &c.High, &c.Low, &c.Close); err != nil {
+			logger.L().WithError(err).Error("DB: GetCandles scan failed")
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		logger.L().WithError(err).Error("DB: GetCandles failed")
+		return nil, err
+	}
+	return out, nil
+}
+
+// FindGaps — пары соседних цен тикера в [from, to], между которыми больше minGap секунд,
+// по времени, не больше limit. Разрыв на краях диапазона не виден: нужны цены с обеих сторон.
+func (s *Storage) FindGaps(ctx context.Context, symbol, quote string, from, to, minGap int64, limit int) ([]model.Gap, error) {
+	const q = `
+SELECT prev_ts, ts
+FROM (
+    SELECT ts, lag(ts) OVER (ORDER BY ts) AS prev_ts
+    FROM prices
+    WHERE symbol = $1 AND quote = $2 AND ts >= $3 AND ts <= $4
+) t
+WHERE ts - prev_ts > $5
+ORDER BY ts
+LIMIT $6`
+	rows, err := s.pool.Query(ctx, q, symbol, quote, from, to, minGap, limit)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: FindGaps failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []model.Gap{}
+	for rows.Next() {
+		g := model.Gap{Symbol: symbol, Quote: quote}
+		if err := rows.Scan(&g.From, &g.To); err != nil {
+			logger.L().WithError(err).Error("DB: FindGaps scan failed")
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	if err := rows.Err(); err != nil {
+		logger.L().WithError(err).Error("DB: FindGaps failed")
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpsertWatch добавляет монету в watchlist или обновляет период и снимает паузу.
+// created_at сохраняется от первой вставки.
+func (s *Storage) UpsertWatch(ctx context.Context, w model.WatchItem) error {
+	const q = `
+INSERT INTO watchlist (symbol, period_s, created_at, paused, provider_id, quotes, aligned, cron, windows)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (symbol) DO UPDATE
+SET period_s = EXCLUDED.period_s, paused = EXCLUDED.paused,
+    provider_id = EXCLUDED.provider_id, quotes = EXCLUDED.quotes, aligned = EXCLUDED.aligned,
+    cron = EXCLUDED.cron, windows = EXCLUDED.windows`
+	quotes := w.Quotes
+	if len(quotes) == 0 {
+		quotes = []string{model.DefaultQuote}
+	}
+	windows, err := marshalWindows(w.Windows)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx, q, w.Symbol, w.Period, w.CreatedAt, w.Paused, w.ProviderID, quotes, w.Align, w.Cron, windows)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: UpsertWatch failed")
+	}
+	return err
+}
+
+// UpdateWatch меняет расписание и валюты котировки тикера; паузу, provider_id и created_at не трогает.
+func (s *Storage) UpdateWatch(ctx context.Context, w model.WatchItem) error {
+	const q = `UPDATE watchlist SET period_s = $2, quotes = $3, aligned = $4, cron = $5, windows = $6 WHERE symbol = $1`
+	windows, err := marshalWindows(w.Windows)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx, q, w.Symbol, w.Period, w.Quotes, w.Align, w.Cron, windows)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: UpdateWatch failed")
+	}
+	return err
+}
+
+func (s *Storage) SetWatchPaused(ctx context.Context, symbol string, paused bool) error {
+	const q = `UPDATE watchlist SET paused = $2 WHERE symbol = $1`
+	_, err := s.pool.Exec(ctx, q, symbol, paused)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: SetWatchPaused failed")
+	}
+	return err
+}
+
+func (s *Storage) ListWatchlist(ctx context.Context) ([]model.WatchItem, error) {
+	const q = `
+SELECT symbol, period_s, created_at, paused, provider_id, quotes, aligned, cron, windows
+FROM watchlist
+ORDER BY created_at, symbol`
+	rows, err := s.pool.Query(ctx, q)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: ListWatchlist failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.WatchItem
+	for rows.Next() {
+		var (
+			w       model.WatchItem
+			windows []byte
+		)
+		if err := rows.Scan(&w.Symbol, &w.Period, &w.CreatedAt, &w.Paused, &w.ProviderID, &w.Quotes, &w.Align, &w.Cron, &windows); err != nil {
+			logger.L().WithError(err).Error("DB: ListWatchlist scan failed")
+			return nil, err
+		}
+		if w.Windows, err = unmarshalWindows(windows); err != nil {
+			logger.L().WithError(err).WithField("symbol", w.Symbol).Error("DB: ListWatchlist bad windows")
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	if err := rows.Err(); err != nil {
+		logger.L().WithError(err).Error("DB: ListWatchlist failed")
+		return nil, err
+	}
+	return out, nil
+}
+
+// windowRow — окно расписания в колонке watchlist.windows.
+type windowRow struct {
+	Days   []int  `json:"days,omitempty"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+	TZ     string `json:"tz,omitempty"`
+	Period int    `json:"period"`
+}
+
+func marshalWindows(ws []model.ScheduleWindow) (string, error) {
+	rows := make([]windowRow, 0, len(ws))
+	for _, w := range ws {
+		r := windowRow{From: w.From, To: w.To, TZ: w.TZ, Period: w.Period}
+		for _, d := range w.Days {
+			r.Days = append(r.Days, int(d))
+		}
+		rows = append(rows, r)
+	}
+	b, err := json.Marshal(rows)
+	return string(b), err
+}
+
+func unmarshalWindows(b []byte) ([]model.ScheduleWindow, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var rows []windowRow
+	if err := json.Unmarshal(b, &rows); err != nil {
+		return nil, err
+	}
+	var out []model.ScheduleWindow
+	for _, r := range rows {
+		w := model.ScheduleWindow{From: r.From, To: r.To, TZ: r.TZ, Period: r.Period}
+		for _, d := range r.Days {
+			w.Days = append(w.Days, time.Weekday(d))
+		}
+		out = append(out, w)
+	}
+	return out, nil
+}
+
+func (s *Storage) UpsertSymbolMapping(ctx context.Context, m model.SymbolMapping) error {
+	const q = `
+INSERT INTO symbol_map (symbol, provider_id, updated_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (symbol) DO UPDATE
+SET provider_id = EXCLUDED.provider_id, updated_at = EXCLUDED.updated_at`
+	_, err := s.pool.Exec(ctx, q, m.Symbol, m.ProviderID, m.UpdatedAt)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: UpsertSymbolMapping failed")
+	}
+	return err
+}
+
+func (s *Storage) DeleteSymbolMapping(ctx context.Context, symbol string) error {
+	const q = `DELETE FROM symbol_map WHERE symbol = $1`
+	_, err := s.pool.Exec(ctx, q, symbol)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: DeleteSymbolMapping failed")
+	}
+	return err
+}
+
+func (s *Storage) ListSymbolMappings(ctx context.Context) ([]model.SymbolMapping, error) {
+	const q = `SELECT symbol, provider_id, updated_at FROM symbol_map ORDER BY symbol`
+	rows, err := s.pool.Query(ctx, q)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: ListSymbolMappings failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.SymbolMapping
+	for rows.Next() {
+		var m model.SymbolMapping
+		if err := rows.Scan(&m.Symbol, &m.ProviderID, &m.UpdatedAt); err != nil {
+			logger.L().WithError(err).Error("DB: ListSymbolMappings scan failed")
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		logger.L().WithError(err).Error("DB: ListSymbolMappings failed")
+		return nil, err
+	}
+	return out, nil
+}
+
+// InsertAlert сохраняет новое правило и возвращает его id.
+func (s *Storage) InsertAlert(ctx context.Context, a model.Alert) (int64, error) {
+	const q = `
+INSERT INTO alerts (symbol, quote, kind, threshold, window_s, cooldown_s, state, fired_at, fired_price, created_at)
+VALUES ($1, $2, $3, $4::numeric, $5, $6, $7, $8, $9::numeric, $10)
+RETURNING id`
+	var id int64
+	err := s.pool.QueryRow(ctx, q, a.Symbol, a.Quote, a.Kind, a.Threshold.String(), a.Window, a.Cooldown,
+		a.State, a.FiredAt, a.FiredPrice.String(), a.CreatedAt).Scan(&id)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: InsertAlert failed")
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateAlert перезаписывает правило целиком (кроме created_at), включая состояние.
+func (s *Storage) UpdateAlert(ctx context.Context, a model.Alert) error {
+	const q = `
+UPDATE alerts
+SET symbol = $2, quote = $3, kind = $4, threshold = $5::numeric, window_s = $6, cooldown_s = $7,
+    state = $8, fired_at = $9, fired_price = $10::numeric
+WHERE id = $1`
+	_, err := s.pool.Exec(ctx, q, a.ID, a.Symbol, a.Quote, a.Kind, a.Threshold.String(), a.Window, a.Cooldown,
+		a.State, a.FiredAt, a.FiredPrice.String())
+	if err != nil {
+		logger.L().WithError(err).Error("DB: UpdateAlert failed")
+	}
+	return err
+}
+
+func (s *Storage) DeleteAlert(ctx context.Context, id int64) error {
+	const q = `DELETE FROM alerts WHERE id = $1`
+	_, err := s.pool.Exec(ctx, q, id)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: DeleteAlert failed")
+	}
+	return err
+}
+
+func (s *Storage) ListAlerts(ctx context.Context) ([]model.Alert, error) {
+	const q = `
+SELECT id, symbol, quote, kind, threshold::text, window_s, cooldown_s, state, fired_at, fired_price::text, created_at
+FROM alerts
+ORDER BY id`
+	rows, err := s.pool.Query(ctx, q)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: ListAlerts failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []model.Alert
+	for rows.Next() {
+		var (
+			a   model.Alert
+			num [2]string
+		)
+		if err := rows.Scan(&a.ID, &a.Symbol, &a.Quote, &a.Kind, &num[0], &a.Window, &a.Cooldown,
+			&a.State, &a.FiredAt, &num[1], &a.CreatedAt); err != nil {
+			logger.L().WithError(err).Error("DB: ListAlerts scan failed")
+			return nil, err
+		}
+		if err := scanDecimals(num[:], &a.Threshold, &a.FiredPrice); err != nil {
+			logger.L().WithError(err).Error("DB: ListAlerts scan failed")
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		logger.L().WithError(err).Error("DB: ListAlerts failed")
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Storage) EnqueueDelivery(ctx context.Context, d model.WebhookDelivery) error {
+	const q = `
+INSERT INTO webhook_deliveries (endpoint, url, event, payload, status, next_attempt_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := s.pool.Exec(ctx, q, d.Endpoint, d.URL, d.Event, d.Payload, d.Status, d.NextAttemptAt, d.CreatedAt)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: EnqueueDelivery failed")
+	}
+	return err
+}
+
+// ClaimDeliveries забирает до limit доставок, чей срок подошёл к now, и сдвигает
+// их next_attempt_at на leaseUntil: другой воркер их не возьмёт, а если этот упадёт
+// посреди отправки — доставка вернётся в очередь после истечения аренды.
+func (s *Storage) ClaimDeliveries(ctx context.Context, now, leaseUntil int64, limit int) ([]model.WebhookDelivery, error) {
+	const q = `
+UPDATE webhook_deliveries SET next_attempt_at = $2
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= $1
+    ORDER BY next_attempt_at, id
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + deliveryColumns
+	rows, err := s.pool.Query(ctx, q, now, leaseUntil, limit)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: ClaimDeliveries failed")
+		return nil, err
+	}
+	return scanDeliveries(rows, "ClaimDeliveries")
+}
+
+// FinishDelivery сохраняет итог попытки: статус, счётчик, время следующей попытки и ошибку.
+func (s *Storage) FinishDelivery(ctx context.Context, d model.WebhookDelivery) error {
+	const q = `
+UPDATE webhook_deliveries
+SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, last_code = $6, delivered_at = $7
+WHERE id = $1`
+	_, err := s.pool.Exec(ctx, q, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.LastCode, d.DeliveredAt)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: FinishDelivery failed")
+	}
+	return err
+}
+
+// ListDeliveries — журнал доставок, новые сначала; status пустой — все.
+func (s *Storage) ListDeliveries(ctx context.Context, status string, limit int) ([]model.WebhookDelivery, error) {
+	const q = `
+SELECT ` + deliveryColumns + `
+FROM webhook_deliveries
+WHERE $1 = '' OR status = $1
+ORDER BY id DESC
+LIMIT $2`
+	rows, err := s.pool.Query(ctx, q, status, limit)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: ListDeliveries failed")
+		return nil, err
+	}
+	return scanDeliveries(rows, "ListDeliveries")
+}
+
+// InsertBackfillJob сохраняет новую задачу догрузки истории и возвращает её id.
+func (s *Storage) InsertBackfillJob(ctx context.Context, j model.BackfillJob) (int64, error) {
+	const q = `
+INSERT INTO backfill_jobs (symbol, provider_id, quote, from_ts, to_ts, cursor_ts, state, created_at, started_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id`
+	var id int64
+	err := s.pool.QueryRow(ctx, q, j.Symbol, j.ProviderID, j.Quote, j.From, j.To, j.Cursor, j.State,
+		j.CreatedAt, j.StartedAt, j.UpdatedAt).Scan(&id)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: InsertBackfillJob failed")
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateBackfillJob сохраняет прогресс и состояние задачи.
+func (s *Storage) UpdateBackfillJob(ctx context.Context, j model.BackfillJob) error {
+	const q = `
+UPDATE backfill_jobs
+SET cursor_ts = $2, state = $3, fetched = $4, inserted = $5, error = $6,
+    started_at = $7, updated_at = $8, finished_at = $9
+WHERE id = $1`
+	_, err := s.pool.Exec(ctx, q, j.ID, j.Cursor, j.State, j.Fetched, j.Inserted, j.Error,
+		j.StartedAt, j.UpdatedAt, j.FinishedAt)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: UpdateBackfillJob failed")
+	}
+	return err
+}
+
+// ClaimBackfillJob забирает самую старую ожидающую задачу — или зависшую в running, если её
+// не обновляли с staleBefore (процесс упал посреди догрузки), — и переводит её в running.
+// Задач нет — nil без ошибки.
+func (s *Storage) ClaimBackfillJob(ctx context.Context, now, staleBefore int64) (*model.BackfillJob, error) {
+	const q = `
+UPDATE backfill_jobs
+SET state = 'running', updated_at = $1, started_at = CASE WHEN started_at = 0 THEN $1 ELSE started_at END
+WHERE id = (
+    SELECT id FROM backfill_jobs
+    WHERE state = 'pending' OR (state = 'running' AND updated_at < $2)
+    ORDER BY id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + backfillColumns
+	j, err := scanBackfillJob(s.pool.QueryRow(ctx, q, now, staleBefore))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logger.L().WithError(err).Error("DB: ClaimBackfillJob failed")
+		return nil, err
+	}
+	return j, nil
+}
+
+// GetBackfillJob — задача по id; нет такой — nil без ошибки.
+func (s *Storage) GetBackfillJob(ctx context.Context, id int64) (*model.BackfillJob, error) {
+	const q = `SELECT ` + backfillColumns + ` FROM backfill_jobs WHERE id = $1`
+	j, err := scanBackfillJob(s.pool.QueryRow(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logger.L().WithError(err).Error("DB: GetBackfillJob failed")
+		return nil, err
+	}
+	return j, nil
+}
+
+// ListBackfillJobs — задачи догрузки, новые сначала; symbol пустой — все.
+func (s *Storage) ListBackfillJobs(ctx context.Context, symbol string, limit int) ([]model.BackfillJob, error) {
+	const q = `
+SELECT ` + backfillColumns + `
+FROM backfill_jobs
+WHERE $1 = '' OR symbol = $1
+ORDER BY id DESC
+LIMIT $2`
+	rows, err := s.pool.Query(ctx, q, symbol, limit)
+	if err != nil {
+		logger.L().WithError(err).Error("DB: ListBackfillJobs failed")
+		return nil, err
+	}
+	defer rows.Close()
+	out := []model.BackfillJob{}
+	for rows.Next() {
+		j, err := scanBackfillJob(rows)
+		if err != nil {
+			logger.L().WithError(err).Error("DB: ListBackfillJobs scan failed")
+			return nil, err
+		}
+		out = append(out, *j)
+	}
+	if err := rows.Err(); err != nil {
+		logger.L().WithError(err).Error("DB: ListBackfillJobs failed")
+		return nil, err
+	}
+	return out, nil
+}
+
+const backfillColumns = `id, symbol, provider_id, quote, from_ts, to_ts, cursor_ts, state, fetched, inserted,
+       error, created_at, started_at, updated_at, finished_at`
+
+func scanBackfillJob(row pgx.Row) (*model.BackfillJob, error) {
+	var j model.BackfillJob
+	if err := row.Scan(&j.ID, &j.Symbol, &j.ProviderID, &j.Quote, &j.From, &j.To, &j.Cursor, &j.State,
+		&j.Fetched, &j.Inserted, &j.Error, &j.CreatedAt, &j.StartedAt, &j.UpdatedAt, &j.FinishedAt); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+const deliveryColumns = `id, endpoint, url, event, payload, status, attempts, next_attempt_at,
+       last_error, last_code, created_at, delivered_at`
+
+func scanDeliveries(rows pgx.Rows, op string) ([]model.WebhookDelivery, error) {
+	defer rows.Close()
+	out := []model.WebhookDelivery{}
+	for rows.Next() {
+		var d model.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.Endpoint, &d.URL, &d.Event, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastError, &d.LastCode, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			logger.L().WithError(err).Error("DB: " + op + " scan failed")
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		logger.L().WithError(err).Error("DB: " + op + " failed")
+		return nil, err
+	}
+	return out, nil
+}
+
+// scanDecimals разбирает NUMERIC, прочитанные как ::text, в model.Decimal.
+func scanDecimals(src []string, dst ...*model.Decimal) error {
+	for i, v := range src {
+		d, err := model.ParseDecimal(v)
+		if err != nil {
+			return err
+		}
+		*dst[i] = d
+	}
+	return nil
+}
+
 func (s *Storage) Close() {
 	if s.pool != nil {
 		s.pool.Close()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"crypto-observer/internal/model"
 	"crypto-observer/pkg/logger"
@@ -22,17 +23,34 @@ func init() { logger.Init() }
 */
 
 type fakePool struct {
-	execErr error
-	row     pgx.Row
+	execErr  error
+	execTag  pgconn.CommandTag
+	row      pgx.Row
+	rows     pgx.Rows
+	queryErr error
+	pingErr  error
+
+	lastSQL  string
+	lastArgs []any
 }
 
 func (p *fakePool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
-	return pgconn.CommandTag{}, p.execErr
+	p.lastSQL, p.lastArgs = sql, args
+	return p.execTag, p.execErr
 }
+func (p *fakePool) Ping(ctx context.Context) error { return p.pingErr }
 func (p *fakePool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
-	return nil, errors.New("not used")
+	p.lastSQL, p.lastArgs = sql, args
+	if p.queryErr != nil {
+		return nil, p.queryErr
+	}
+	if p.rows == nil {
+		return nil, errors.New("not used")
+	}
+	return p.rows, nil
 }
 func (p *fakePool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	p.lastSQL, p.lastArgs = sql, args
 	if p.row == nil {
 		return fakeRow{scan: func(dest ...any) error { return pgx.ErrNoRows }}
 	}
@@ -46,6 +64,29 @@ type fakeRow struct {
 
 func (r fakeRow) Scan(dest ...any) error { return r.scan(dest...) }
 
+// fakeRows — минимальная реализация pgx.Rows поверх набора scan-функций.
+type fakeRows struct {
+	scans []func(dest ...any) error
+	i     int
+	err   error
+}
+
+func (r *fakeRows) Close()                                       {}
+func (r *fakeRows) Err() error                                   { return r.err }
+func (r *fakeRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *fakeRows) Next() bool {
+	if r.i >= len(r.scans) {
+		return false
+	}
+	r.i++
+	return true
+}
+func (r *fakeRows) Scan(dest ...any) error { return r.scans[r.i-1](dest...) }
+func (r *fakeRows) Values() ([]any, error) { return nil, nil }
+func (r *fakeRows) RawValues() [][]byte    { return nil }
+func (r *fakeRows) Conn() *pgx.Conn        { return nil }
+
 func TestStorage_EnsureSchema_OK(t *testing.T) {
 	fp := &fakePool{execErr: nil}
 	st := newWithPool(fp)
@@ -54,36 +95,76 @@ func TestStorage_EnsureSchema_OK(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// idRow — строка RETURNING id.
+func idRow(id int64) fakeRow {
+	return fakeRow{scan: func(dest ...any) error {
+		*(dest[0].(*int64)) = id
+		return nil
+	}}
+}
+
 func TestStorage_SavePrice_OK(t *testing.T) {
-	fp := &fakePool{execErr: nil}
+	fp := &fakePool{row: idRow(42)}
 	st := newWithPool(fp)
 
-	err := st.SavePrice(context.Background(), model.Price{
+	id, err := st.SavePrice(context.Background(), model.Price{
 		Symbol: "btc",
 		TS:     111,
-		Price:  12345,
+		Price:  model.MustDecimal("123.45"),
 	})
 	require.NoError(t, err)
+	require.Equal(t, int64(42), id)
+	require.Contains(t, fp.lastSQL, "RETURNING id")
+	require.Contains(t, fp.lastSQL, "ON CONFLICT (symbol, quote, ts) DO UPDATE", "a repeated tick does not fail on the unique index")
+}
+
+func TestStorage_SavePrice_DBError(t *testing.T) {
+	fp := &fakePool{row: fakeRow{scan: func(dest ...any) error { return errors.New("db boom") }}}
+	st := newWithPool(fp)
+
+	id, err := st.SavePrice(context.Background(), model.Price{Symbol: "btc", TS: 1, Price: model.MustDecimal("2")})
+	require.Error(t, err)
+	require.Zero(t, id)
+}
+
+func TestStorage_SavePrice_DefaultsSources(t *testing.T) {
+	fp := &fakePool{row: idRow(1)}
+	st := newWithPool(fp)
+
+	_, err := st.SavePrice(context.Background(), model.Price{Symbol: "btc", TS: 1, Price: model.MustDecimal("2")})
+	require.NoError(t, err)
+	require.Equal(t, []any{"btc", "usd", int64(1), "2", 1, "0"}, fp.lastArgs)
+
+	p := model.Price{Symbol: "pepe", Quote: "eur", TS: 1, Price: model.MustDecimal("0.00001234"), Sources: 3, Spread: model.MustDecimal("0.0000001")}
+	_, err = st.SavePrice(context.Background(), p)
+	require.NoError(t, err)
+	require.Equal(t, []any{"pepe", "eur", int64(1), "0.00001234", 3, "0.0000001"}, fp.lastArgs, "sub-cent prices are written exactly")
 }
 
 func TestStorage_GetClosestPrice_Found(t *testing.T) {
 	row := fakeRow{
 		scan: func(dest ...any) error {
 			*(dest[0].(*string)) = "btc"
-			*(dest[1].(*int64)) = 222
-			*(dest[2].(*int64)) = 23456
+			*(dest[1].(*string)) = "eur"
+			*(dest[2].(*int64)) = 222
+			*(dest[3].(*string)) = "234.56"
+			*(dest[4].(*int)) = 3
+			*(dest[5].(*string)) = "0.40"
 			return nil
 		},
 	}
 	fp := &fakePool{row: row}
 	st := newWithPool(fp)
 
-	got, err := st.GetClosestPrice(context.Background(), "btc", 999)
+	got, err := st.GetClosestPrice(context.Background(), "btc", "eur", 999)
 	require.NoError(t, err)
 	require.NotNil(t, got)
 	require.Equal(t, "btc", got.Symbol)
+	require.Equal(t, "eur", got.Quote)
 	require.Equal(t, int64(222), got.TS)
-	require.Equal(t, int64(23456), got.Price)
+	require.Equal(t, model.MustDecimal("234.56"), got.Price)
+	require.Equal(t, 3, got.Sources)
+	require.Equal(t, model.MustDecimal("0.4"), got.Spread)
 }
 
 func TestStorage_GetClosestPrice_NotFound(t *testing.T) {
@@ -91,18 +172,520 @@ func TestStorage_GetClosestPrice_NotFound(t *testing.T) {
 	fp := &fakePool{row: row}
 	st := newWithPool(fp)
 
-	got, err := st.GetClosestPrice(context.Background(), "btc", 999)
+	got, err := st.GetClosestPrice(context.Background(), "btc", "usd", 999)
 	require.NoError(t, err)
 	require.Nil(t, got)
 }
 
+func TestStorage_GetClosestPrice_BadNumeric(t *testing.T) {
+	row := fakeRow{
+		scan: func(dest ...any) error {
+			*(dest[3].(*string)) = "NaN"
+			*(dest[5].(*string)) = "0"
+			return nil
+		},
+	}
+	st := newWithPool(&fakePool{row: row})
+
+	got, err := st.GetClosestPrice(context.Background(), "btc", "usd", 1)
+	require.ErrorIs(t, err, model.ErrInvalidDecimal)
+	require.Nil(t, got)
+}
+
 func TestStorage_GetClosestPrice_DBError(t *testing.T) {
 	// вернём произвольную ошибку из Scan
 	row := fakeRow{scan: func(dest ...any) error { return errors.New("db boom") }}
 	fp := &fakePool{row: row}
 	st := newWithPool(fp)
 
-	got, err := st.GetClosestPrice(context.Background(), "btc", 100)
+	got, err := st.GetClosestPrice(context.Background(), "btc", "usd", 100)
+	require.Error(t, err)
+	require.Nil(t, got)
+}
+
+func TestStorage_UpsertWatch_PassesArgs(t *testing.T) {
+	fp := &fakePool{}
+	st := newWithPool(fp)
+
+	err := st.UpsertWatch(context.Background(), model.WatchItem{Symbol: "btc", Period: 5, CreatedAt: 100})
+	require.NoError(t, err)
+	require.Equal(t, []any{"btc", 5, int64(100), false, "", []string{"usd"}, false, "", "[]"}, fp.lastArgs)
+
+	err = st.UpsertWatch(context.Background(), model.WatchItem{Symbol: "btc", Period: 5, CreatedAt: 100, Quotes: []string{"usd", "eur"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"usd", "eur"}, fp.lastArgs[5])
+}
+
+func TestStorage_UpdateWatch_PassesArgs(t *testing.T) {
+	fp := &fakePool{}
+	st := newWithPool(fp)
+
+	require.NoError(t, st.UpdateWatch(context.Background(), model.WatchItem{Symbol: "btc", Period: 30, Quotes: []string{"usd", "eur"}, Align: true}))
+	require.Contains(t, fp.lastSQL, "UPDATE watchlist SET period_s")
+	require.Equal(t, []any{"btc", 30, []string{"usd", "eur"}, true, "", "[]"}, fp.lastArgs)
+
+	w := model.WatchItem{Symbol: "btc", Period: 300, Cron: "*/5 * * * *", Windows: []model.ScheduleWindow{
+		{Days: []time.Weekday{time.Monday, time.Friday}, From: 9 * 60, To: 17*60 + 30, TZ: "America/New_York", Period: 10},
+	}}
+	require.NoError(t, st.UpdateWatch(context.Background(), w))
+	require.Equal(t, "*/5 * * * *", fp.lastArgs[4])
+	require.JSONEq(t, `[{"days":[1,5],"from":540,"to":1050,"tz":"America/New_York","period":10}]`, fp.lastArgs[5].(string))
+}
+
+func TestStorage_SetWatchPaused_DBError(t *testing.T) {
+	fp := &fakePool{execErr: errors.New("db boom")}
+	st := newWithPool(fp)
+
+	require.Error(t, st.SetWatchPaused(context.Background(), "btc", true))
+}
+
+func TestStorage_ListWatchlist_OK(t *testing.T) {
+	item := func(sym string, per int, paused bool) func(dest ...any) error {
+		return func(dest ...any) error {
+			*(dest[0].(*string)) = sym
+			*(dest[1].(*int)) = per
+			*(dest[2].(*int64)) = 1
+			*(dest[3].(*bool)) = paused
+			*(dest[4].(*string)) = sym + "-id"
+			*(dest[5].(*[]string)) = []string{"usd"}
+			*(dest[6].(*bool)) = !paused
+			*(dest[7].(*string)) = ""
+			*(dest[8].(*[]byte)) = []byte("[]")
+			if paused {
+				*(dest[7].(*string)) = "0 * * * *"
+				*(dest[8].(*[]byte)) = []byte(`[{"days":[0],"from":60,"to":120,"period":5}]`)
+			}
+			return nil
+		}
+	}
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{
+		item("btc", 5, false),
+		item("eth", 10, true),
+	}}}
+	st := newWithPool(fp)
+
+	got, err := st.ListWatchlist(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []model.WatchItem{
+		{Symbol: "btc", ProviderID: "btc-id", Quotes: []string{"usd"}, Period: 5, Align: true, CreatedAt: 1},
+		{Symbol: "eth", ProviderID: "eth-id", Quotes: []string{"usd"}, Period: 10, CreatedAt: 1, Paused: true,
+			Cron: "0 * * * *", Windows: []model.ScheduleWindow{{Days: []time.Weekday{time.Sunday}, From: 60, To: 120, Period: 5}}},
+	}, got)
+}
+
+func TestStorage_ListWatchlist_QueryError(t *testing.T) {
+	fp := &fakePool{queryErr: errors.New("db boom")}
+	st := newWithPool(fp)
+
+	got, err := st.ListWatchlist(context.Background())
+	require.Error(t, err)
+	require.Nil(t, got)
+}
+
+func priceRow(id, ts int64, price string) func(dest ...any) error {
+	return func(dest ...any) error {
+		*(dest[0].(*int64)) = id
+		*(dest[1].(*string)) = "btc"
+		*(dest[2].(*string)) = "usd"
+		*(dest[3].(*int64)) = ts
+		*(dest[4].(*string)) = price
+		*(dest[5].(*int)) = 2
+		*(dest[6].(*string)) = "0.5"
+		return nil
+	}
+}
+
+func TestStorage_GetPriceRange_LastPage(t *testing.T) {
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{
+		priceRow(1, 10, "100"),
+		priceRow(2, 20, "200"),
+	}}}
+	st := newWithPool(fp)
+
+	page, err := st.GetPriceRange(context.Background(), "btc", "usd", 0, 100, nil, 5)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	require.Nil(t, page.Next)
+	require.Equal(t, model.MustDecimal("200"), page.Items[1].Price)
+	require.Equal(t, 2, page.Items[1].Sources, "consensus metadata is read back")
+	require.Equal(t, "0.5", page.Items[1].Spread.String())
+	// без курсора стартуем с (from, 0); лимит запрашивается с запасом в одну строку
+	require.Equal(t, []any{"btc", "usd", int64(0), int64(100), int64(0), int64(0), 6}, fp.lastArgs)
+}
+
+func TestStorage_GetPriceRange_HasNext(t *testing.T) {
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{
+		priceRow(7, 10, "100"),
+		priceRow(8, 10, "101"),
+		priceRow(9, 30, "300"),
+	}}}
+	st := newWithPool(fp)
+
+	after := &model.HistoryCursor{TS: 5, ID: 3}
+	page, err := st.GetPriceRange(context.Background(), "btc", "usd", 0, 100, after, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	require.Equal(t, &model.HistoryCursor{TS: 10, ID: 8}, page.Next)
+	require.Equal(t, int64(5), fp.lastArgs[4])
+	require.Equal(t, int64(3), fp.lastArgs[5])
+}
+
+func TestStorage_GetPriceRange_QueryError(t *testing.T) {
+	fp := &fakePool{queryErr: errors.New("db boom")}
+	st := newWithPool(fp)
+
+	page, err := st.GetPriceRange(context.Background(), "btc", "usd", 0, 100, nil, 5)
+	require.Error(t, err)
+	require.Nil(t, page)
+}
+
+func TestStorage_GetPricesSince_OK(t *testing.T) {
+	row := func(id int64, symbol, quote string, ts int64, price, spread string) func(dest ...any) error {
+		return func(dest ...any) error {
+			*(dest[0].(*int64)) = id
+			*(dest[1].(*string)) = symbol
+			*(dest[2].(*string)) = quote
+			*(dest[3].(*int64)) = ts
+			*(dest[4].(*string)) = price
+			*(dest[5].(*int)) = 2
+			*(dest[6].(*string)) = spread
+			return nil
+		}
+	}
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{
+		row(8, "btc", "usd", 11, "29150.32", "1.5"),
+		row(9, "eth", "eur", 12, "0.00001234", "0"),
+	}}}
+	st := newWithPool(fp)
+
+	got, err := st.GetPricesSince(context.Background(), []string{"btc", "eth"}, model.HistoryCursor{TS: 10, ID: 7}, 500)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, model.Price{ID: 8, Symbol: "btc", Quote: "usd", TS: 11, Price: model.MustDecimal("29150.32"), Sources: 2, Spread: model.MustDecimal("1.5")}, got[0])
+	require.Equal(t, "eur", got[1].Quote)
+	require.Equal(t, []any{[]string{"btc", "eth"}, int64(10), int64(7), 500}, fp.lastArgs)
+	require.Contains(t, fp.lastSQL, "(ts, id) > ($2, $3)")
+}
+
+func TestStorage_GetPricesSince_QueryError(t *testing.T) {
+	st := newWithPool(&fakePool{queryErr: errors.New("db boom")})
+
+	got, err := st.GetPricesSince(context.Background(), []string{"btc"}, model.HistoryCursor{}, 10)
+	require.Error(t, err)
+	require.Nil(t, got)
+}
+
+func TestStorage_GetCandles_OK(t *testing.T) {
+	d := model.MustDecimal
+	candle := func(ts int64, o, h, l, c string, n int64) func(dest ...any) error {
+		return func(dest ...any) error {
+			*(dest[0].(*int64)) = ts
+			for i, v := range []string{o, h, l, c} {
+				*(dest[i+1].(*string)) = v
+			}
+			*(dest[5].(*int64)) = n
+			return nil
+		}
+	}
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{
+		candle(60, "1.00", "1.5", "0.9", "1.2", 4),
+		candle(120, "0.0000012", "0.0000013", "0.0000011", "0.0000012", 2),
+	}}}
+	st := newWithPool(fp)
+
+	got, err := st.GetCandles(context.Background(), "btc", "eur", 60, 0, 200)
+	require.NoError(t, err)
+	require.Equal(t, []model.Candle{
+		{Symbol: "btc", TS: 60, Open: d("1"), High: d("1.5"), Low: d("0.9"), Close: d("1.2"), Count: 4},
+		{Symbol: "btc", TS: 120, Open: d("0.0000012"), High: d("0.0000013"), Low: d("0.0000011"), Close: d("0.0000012"), Count: 2},
+	}, got)
+	require.Equal(t, []any{"btc", "eur", int64(60), int64(0), int64(200)}, fp.lastArgs)
+}
+
+func TestStorage_GetCandles_Empty(t *testing.T) {
+	fp := &fakePool{rows: &fakeRows{}}
+	st := newWithPool(fp)
+
+	got, err := st.GetCandles(context.Background(), "btc", "usd", 60, 0, 200)
+	require.NoError(t, err)
+	require.Empty(t, got)
+	require.NotNil(t, got, "empty result should be an empty slice, not nil")
+}
+
+func TestStorage_GetCandles_ScanError(t *testing.T) {
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{
+		func(dest ...any) error { return errors.New("scan boom") },
+	}}}
+	st := newWithPool(fp)
+
+	got, err := st.GetCandles(context.Background(), "btc", "usd", 60, 0, 200)
+	require.Error(t, err)
+	require.Nil(t, got)
+}
+
+func TestStorage_UpsertSymbolMapping_PassesArgs(t *testing.T) {
+	fp := &fakePool{}
+	st := newWithPool(fp)
+
+	require.NoError(t, st.UpsertSymbolMapping(context.Background(), model.SymbolMapping{Symbol: "uni", ProviderID: "uniswap", UpdatedAt: 5}))
+	require.Equal(t, []any{"uni", "uniswap", int64(5)}, fp.lastArgs)
+}
+
+func TestStorage_DeleteSymbolMapping_DBError(t *testing.T) {
+	fp := &fakePool{execErr: errors.New("db boom")}
+	st := newWithPool(fp)
+
+	require.Error(t, st.DeleteSymbolMapping(context.Background(), "uni"))
+}
+
+func TestStorage_ListSymbolMappings_OK(t *testing.T) {
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{
+		func(dest ...any) error {
+			*(dest[0].(*string)) = "uni"
+			*(dest[1].(*string)) = "uniswap"
+			*(dest[2].(*int64)) = 7
+			return nil
+		},
+	}}}
+	st := newWithPool(fp)
+
+	got, err := st.ListSymbolMappings(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []model.SymbolMapping{{Symbol: "uni", ProviderID: "uniswap", UpdatedAt: 7}}, got)
+}
+
+func TestStorage_InsertAlert_ReturnsID(t *testing.T) {
+	fp := &fakePool{row: fakeRow{scan: func(dest ...any) error {
+		*(dest[0].(*int64)) = 42
+		return nil
+	}}}
+	st := newWithPool(fp)
+
+	id, err := st.InsertAlert(context.Background(), model.Alert{
+		Symbol: "btc", Quote: "usd", Kind: model.AlertAbove, Threshold: model.MustDecimal("70000"), State: model.AlertArmed,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(42), id)
+}
+
+func TestStorage_UpdateAlert_PassesDecimalsAsText(t *testing.T) {
+	fp := &fakePool{}
+	st := newWithPool(fp)
+
+	require.NoError(t, st.UpdateAlert(context.Background(), model.Alert{
+		ID: 3, Symbol: "eth", Quote: "usd", Kind: model.AlertChange, Threshold: model.MustDecimal("-5"),
+		Window: 3600, State: model.AlertFired, FiredAt: 100, FiredPrice: model.MustDecimal("1850.5"),
+	}))
+	require.Equal(t, []any{int64(3), "eth", "usd", "change", "-5", int64(3600), int64(0),
+		"fired", int64(100), "1850.5"}, fp.lastArgs)
+}
+
+func TestStorage_ListAlerts_OK(t *testing.T) {
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{
+		func(dest ...any) error {
+			*(dest[0].(*int64)) = 1
+			*(dest[1].(*string)) = "btc"
+			*(dest[2].(*string)) = "usd"
+			*(dest[3].(*string)) = "above"
+			*(dest[4].(*string)) = "70000.00"
+			*(dest[5].(*int64)) = 0
+			*(dest[6].(*int64)) = 600
+			*(dest[7].(*string)) = "cooldown"
+			*(dest[8].(*int64)) = 50
+			*(dest[9].(*string)) = "70001.5"
+			*(dest[10].(*int64)) = 10
+			return nil
+		},
+	}}}
+	st := newWithPool(fp)
+
+	got, err := st.ListAlerts(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []model.Alert{{
+		ID: 1, Symbol: "btc", Quote: "usd", Kind: "above", Threshold: model.MustDecimal("70000"),
+		Cooldown: 600, State: "cooldown", FiredAt: 50, FiredPrice: model.MustDecimal("70001.5"), CreatedAt: 10,
+	}}, got)
+}
+
+func TestStorage_DeleteAlert_DBError(t *testing.T) {
+	st := newWithPool(&fakePool{execErr: errors.New("db boom")})
+
+	require.Error(t, st.DeleteAlert(context.Background(), 1))
+}
+
+func deliveryRow(id int64, status string) func(dest ...any) error {
+	return func(dest ...any) error {
+		*(dest[0].(*int64)) = id
+		*(dest[1].(*string)) = "ops"
+		*(dest[2].(*string)) = "http://hook"
+		*(dest[3].(*string)) = "alert.fired"
+		*(dest[4].(*string)) = `{"event":"alert.fired"}`
+		*(dest[5].(*string)) = status
+		*(dest[6].(*int)) = 1
+		*(dest[7].(*int64)) = 100
+		*(dest[8].(*string)) = ""
+		*(dest[9].(*int)) = 0
+		*(dest[10].(*int64)) = 50
+		*(dest[11].(*int64)) = 0
+		return nil
+	}
+}
+
+func TestStorage_ClaimDeliveries_LeasesDue(t *testing.T) {
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{deliveryRow(3, "pending")}}}
+	st := newWithPool(fp)
+
+	got, err := st.ClaimDeliveries(context.Background(), 100, 160, 20)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, int64(3), got[0].ID)
+	require.Equal(t, `{"event":"alert.fired"}`, got[0].Payload)
+	require.Contains(t, fp.lastSQL, "FOR UPDATE SKIP LOCKED")
+	require.Equal(t, []any{int64(100), int64(160), 20}, fp.lastArgs)
+}
+
+func TestStorage_FinishDelivery_PassesArgs(t *testing.T) {
+	fp := &fakePool{}
+	st := newWithPool(fp)
+
+	require.NoError(t, st.FinishDelivery(context.Background(), model.WebhookDelivery{
+		ID: 3, Status: model.DeliveryPending, Attempts: 2, NextAttemptAt: 200, LastError: "receiver responded 503", LastCode: 503,
+	}))
+	require.Equal(t, []any{int64(3), "pending", 2, int64(200), "receiver responded 503", 503, int64(0)}, fp.lastArgs)
+}
+
+func TestStorage_ListDeliveries_FilterAndError(t *testing.T) {
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{deliveryRow(2, "failed"), deliveryRow(1, "failed")}}}
+	st := newWithPool(fp)
+
+	got, err := st.ListDeliveries(context.Background(), "failed", 50)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, []any{"failed", 50}, fp.lastArgs)
+
+	_, err = newWithPool(&fakePool{queryErr: errors.New("db boom")}).ListDeliveries(context.Background(), "", 50)
+	require.Error(t, err)
+}
+
+func TestStorage_PoolStat_NilWithoutPgxpool(t *testing.T) {
+	st := newWithPool(&fakePool{})
+	require.Nil(t, st.PoolStat())
+}
+
+func TestStorage_Ping(t *testing.T) {
+	require.NoError(t, newWithPool(&fakePool{}).Ping(context.Background()))
+	require.Error(t, newWithPool(&fakePool{pingErr: errors.New("down")}).Ping(context.Background()))
+}
+
+func TestStorage_InsertPrices_PassesArraysAndCount(t *testing.T) {
+	fp := &fakePool{execTag: pgconn.NewCommandTag("INSERT 0 1")}
+	st := newWithPool(fp)
+
+	n, err := st.InsertPrices(context.Background(), []model.Price{
+		{Symbol: "btc", Quote: "usd", TS: 100, Price: model.MustDecimal("1.5")},
+		{Symbol: "btc", TS: 200, Price: model.MustDecimal("0.00001234"), Sources: 3},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Contains(t, fp.lastSQL, "ON CONFLICT (symbol, quote, ts) DO NOTHING")
+	require.Equal(t, []any{
+		[]string{"btc", "btc"}, []string{"usd", "usd"}, []int64{100, 200},
+		[]string{"1.5", "0.00001234"}, []int32{1, 3}, []string{"0", "0"},
+	}, fp.lastArgs)
+
+	fp.lastSQL = ""
+	n, err = st.InsertPrices(context.Background(), nil)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	require.Empty(t, fp.lastSQL, "empty batch does not hit the DB")
+}
+
+func TestStorage_BackfillJobs(t *testing.T) {
+	job := func(dest ...any) error {
+		*(dest[0].(*int64)) = 7
+		*(dest[1].(*string)) = "btc"
+		*(dest[2].(*string)) = "bitcoin"
+		*(dest[3].(*string)) = "usd"
+		*(dest[4].(*int64)) = 1000
+		*(dest[5].(*int64)) = 5000
+		*(dest[6].(*int64)) = 2000
+		*(dest[7].(*string)) = model.BackfillRunning
+		*(dest[8].(*int)) = 10
+		*(dest[9].(*int)) = 8
+		*(dest[10].(*string)) = ""
+		*(dest[11].(*int64)) = 1
+		*(dest[12].(*int64)) = 2
+		*(dest[13].(*int64)) = 3
+		*(dest[14].(*int64)) = 0
+		return nil
+	}
+	want := model.BackfillJob{ID: 7, Symbol: "btc", ProviderID: "bitcoin", Quote: "usd", From: 1000, To: 5000, Cursor: 2000,
+		State: model.BackfillRunning, Fetched: 10, Inserted: 8, CreatedAt: 1, StartedAt: 2, UpdatedAt: 3}
+
+	fp := &fakePool{row: fakeRow{scan: job}}
+	st := newWithPool(fp)
+	got, err := st.ClaimBackfillJob(context.Background(), 100, 40)
+	require.NoError(t, err)
+	require.Equal(t, &want, got)
+	require.Equal(t, []any{int64(100), int64(40)}, fp.lastArgs)
+	require.Contains(t, fp.lastSQL, "SKIP LOCKED")
+
+	got, err = st.GetBackfillJob(context.Background(), 7)
+	require.NoError(t, err)
+	require.Equal(t, &want, got)
+
+	fp.rows = &fakeRows{scans: []func(dest ...any) error{job}}
+	list, err := st.ListBackfillJobs(context.Background(), "btc", 10)
+	require.NoError(t, err)
+	require.Equal(t, []model.BackfillJob{want}, list)
+
+	// пустая очередь и неизвестный id — nil без ошибки
+	st = newWithPool(&fakePool{})
+	got, err = st.ClaimBackfillJob(context.Background(), 100, 40)
+	require.NoError(t, err)
+	require.Nil(t, got)
+	got, err = st.GetBackfillJob(context.Background(), 7)
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func TestStorage_UpdateBackfillJob_PassesArgs(t *testing.T) {
+	fp := &fakePool{}
+	st := newWithPool(fp)
+
+	require.NoError(t, st.UpdateBackfillJob(context.Background(), model.BackfillJob{
+		ID: 7, Cursor: 3000, State: model.BackfillFailed, Fetched: 5, Inserted: 4, Error: "boom",
+		StartedAt: 2, UpdatedAt: 3, FinishedAt: 3,
+	}))
+	require.Equal(t, []any{int64(7), int64(3000), model.BackfillFailed, 5, 4, "boom", int64(2), int64(3), int64(3)}, fp.lastArgs)
+}
+
+func TestStorage_FindGaps_OK(t *testing.T) {
+	gap := func(from, to int64) func(dest ...any) error {
+		return func(dest ...any) error {
+			*(dest[0].(*int64)) = from
+			*(dest[1].(*int64)) = to
+			return nil
+		}
+	}
+	fp := &fakePool{rows: &fakeRows{scans: []func(dest ...any) error{gap(100, 200), gap(500, 900)}}}
+	st := newWithPool(fp)
+
+	got, err := st.FindGaps(context.Background(), "btc", "usd", 0, 1000, 30, 100)
+	require.NoError(t, err)
+	require.Equal(t, []model.Gap{
+		{Symbol: "btc", Quote: "usd", From: 100, To: 200},
+		{Symbol: "btc", Quote: "usd", From: 500, To: 900},
+	}, got)
+	require.Equal(t, []any{"btc", "usd", int64(0), int64(1000), int64(30), 100}, fp.lastArgs)
+	require.Contains(t, fp.lastSQL, "lag(ts)")
+}
+
+func TestStorage_FindGaps_QueryError(t *testing.T) {
+	fp := &fakePool{queryErr: errors.New("db boom")}
+	st := newWithPool(fp)
+
+	got, err := st.FindGaps(context.Background(), "btc", "usd", 0, 1000, 30, 100)
 	require.Error(t, err)
 	require.Nil(t, got)
 }
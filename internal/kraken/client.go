@@ -0,0 +1,86 @@
+// internal/kraken/client.go
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"crypto-observer/internal/model"
+)
+
+// У Kraken свои коды для части активов
+var krakenBase = map[string]string{
+	"btc":  "XBT",
+	"doge": "XDG",
+}
+
+// Client получает последнюю сделку пары <BASE><QUOTE> из публичного Ticker API
+// и возвращает цену без потери точности.
+type Client struct {
+	base string
+	http *http.Client
+}
+
+func New(base string, timeout time.Duration) *Client {
+	return &Client{
+		base: base,
+		http: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *Client) GetPrice(ctx context.Context, symbol, quote string) (model.Decimal, error) {
+	pair := toAsset(symbol) + toAsset(quote)
+	url := fmt.Sprintf("%s/0/public/Ticker?pair=%s", c.base, pair)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return model.Decimal{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return model.Decimal{}, fmt.Errorf("kraken: unexpected status %d for %s", resp.StatusCode, pair)
+	}
+
+	// {"error":[],"result":{"XXBTZUSD":{"c":["123.45","0.01"], ...}}}
+	var body struct {
+		Error  []string `json:"error"`
+		Result map[string]struct {
+			Close []string `json:"c"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return model.Decimal{}, err
+	}
+	if len(body.Error) > 0 {
+		msg := strings.Join(body.Error, "; ")
+		if strings.Contains(msg, "Unknown asset pair") {
+			return model.Decimal{}, fmt.Errorf("kraken: %w: %s", model.ErrUnknownCoin, pair)
+		}
+		return model.Decimal{}, fmt.Errorf("kraken: %s", msg)
+	}
+	// ключ в result — каноническое имя пары (XXBTZUSD), а не то, что мы спросили
+	for _, t := range body.Result {
+		if len(t.Close) == 0 {
+			break
+		}
+		v, err := model.ParseDecimal(t.Close[0])
+		if err != nil {
+			return model.Decimal{}, fmt.Errorf("kraken: bad price %q: %w", t.Close[0], err)
+		}
+		return v, nil
+	}
+	return model.Decimal{}, errors.New("kraken: empty ticker for " + pair)
+}
+
+func toAsset(sym string) string {
+	s := strings.ToLower(strings.TrimSpace(sym))
+	if b, ok := krakenBase[s]; ok {
+		return b
+	}
+	return strings.ToUpper(s)
+}
@@ -0,0 +1,76 @@
+package kraken
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+)
+
+func TestGetPrice_OK(t *testing.T) {
+	var gotPair string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPair = r.URL.Query().Get("pair")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"error":[],"result":{"XXBTZUSD":{"a":["1","1","1"],"c":["123.45000","0.001"]}}}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	price, err := c.GetPrice(context.Background(), "btc", "usd")
+	if err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if price.String() != "123.45" {
+		t.Fatalf("want 123.45, got %s", price)
+	}
+	if gotPair != "XBTUSD" {
+		t.Fatalf("want pair XBTUSD, got %s", gotPair)
+	}
+}
+
+func TestGetPrice_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"error":["EQuery:Unknown asset pair"]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	if _, err := c.GetPrice(context.Background(), "nope", "usd"); !errors.Is(err, model.ErrUnknownCoin) {
+		t.Fatalf("want ErrUnknownCoin, got %v", err)
+	}
+}
+
+func TestGetPrice_BadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	if _, err := c.GetPrice(context.Background(), "eth", "usd"); err == nil {
+		t.Fatalf("expected error on bad status")
+	}
+}
+
+func TestGetPrice_BTCQuote(t *testing.T) {
+	var gotPair string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPair = r.URL.Query().Get("pair")
+		_, _ = w.Write([]byte(`{"error":[],"result":{"XETHXXBT":{"c":["0.05","1"]}}}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, time.Second)
+	price, err := c.GetPrice(context.Background(), "eth", "btc")
+	if err != nil {
+		t.Fatalf("GetPrice: %v", err)
+	}
+	if gotPair != "ETHXBT" || price.String() != "0.05" {
+		t.Fatalf("want ETHXBT 0.05, got %s %s", gotPair, price)
+	}
+}
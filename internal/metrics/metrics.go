@@ -0,0 +1,87 @@
+// Package metrics — метрики Prometheus для /metrics.
+// Все метрики регистрируются в собственном Registry, а не в глобальном,
+// чтобы тесты и повторная инициализация не ловили паники дублирующей регистрации.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "crypto_observer"
+
+// Этапы тика коллектора для счётчика ошибок
+const (
+	StageFetch = "fetch"
+	StageSave  = "save"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	// CollectorFetchSeconds — сколько занял запрос цены у провайдера (успешный или нет).
+	CollectorFetchSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "fetch_duration_seconds",
+		Help:      "Price provider fetch latency per symbol.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"symbol"})
+
+	CollectorErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "errors_total",
+		Help:      "Collector errors per symbol and stage (fetch|save).",
+	}, []string{"symbol", "stage"})
+
+	CollectorLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of the last saved price per symbol.",
+	}, []string{"symbol"})
+
+	CollectorsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "collectors_active",
+		Help:      "Number of running collectors.",
+	})
+
+	// PriceGaps — разрывы в истории, найденные последним проходом аудитора.
+	PriceGaps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "price_gaps",
+		Help:      "Gaps in stored price history per symbol and quote found by the last audit.",
+	}, []string{"symbol", "quote"})
+
+	// HTTPRequestSeconds — длительность запросов по шаблону маршрута chi (/alerts/{id}, не /alerts/7).
+	HTTPRequestSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency per chi route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		CollectorFetchSeconds,
+		CollectorErrors,
+		CollectorLastSuccess,
+		CollectorsActive,
+		PriceGaps,
+		HTTPRequestSeconds,
+	)
+}
+
+// Handler отдаёт метрики Registry в текстовом формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
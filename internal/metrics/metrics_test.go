@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ExposesRegisteredMetrics(t *testing.T) {
+	CollectorErrors.WithLabelValues("btc", StageFetch).Inc()
+	CollectorsActive.Set(2)
+
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	body := rr.Body.String()
+	require.Contains(t, body, `crypto_observer_collector_errors_total{stage="fetch",symbol="btc"} 1`)
+	require.Contains(t, body, "crypto_observer_collectors_active 2")
+	require.Contains(t, body, "go_goroutines")
+}
+
+func TestPoolCollector_NilStat(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewPoolCollector(func() *pgxpool.Stat { return nil }))
+
+	n, err := testutil.GatherAndCount(reg)
+	require.NoError(t, err)
+	require.Zero(t, n, "no pool — no series")
+}
+
+func TestPoolCollector_Describe(t *testing.T) {
+	ch := make(chan *prometheus.Desc, 16)
+	NewPoolCollector(nil).Describe(ch)
+	close(ch)
+
+	var names []string
+	for d := range ch {
+		names = append(names, d.String())
+	}
+	require.Len(t, names, 8)
+	require.Contains(t, strings.Join(names, " "), "crypto_observer_db_pool_acquired_conns")
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector снимает pgxpool.Stat в момент scrape.
+type PoolCollector struct {
+	stat func() *pgxpool.Stat
+
+	acquired, idle, total, max *prometheus.Desc
+	acquires, acquireSeconds   *prometheus.Desc
+	emptyAcquires, canceled    *prometheus.Desc
+}
+
+// NewPoolCollector — stat может вернуть nil (пула нет), тогда метрики не отдаются.
+func NewPoolCollector(stat func() *pgxpool.Stat) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &PoolCollector{
+		stat:           stat,
+		acquired:       desc("acquired_conns", "Connections currently acquired from the pool."),
+		idle:           desc("idle_conns", "Idle connections in the pool."),
+		total:          desc("total_conns", "Total connections in the pool."),
+		max:            desc("max_conns", "Maximum pool size."),
+		acquires:       desc("acquires_total", "Successful connection acquires."),
+		acquireSeconds: desc("acquire_duration_seconds_total", "Total time spent waiting for a connection."),
+		emptyAcquires:  desc("empty_acquires_total", "Acquires that had to wait because the pool was empty."),
+		canceled:       desc("canceled_acquires_total", "Acquires canceled by context."),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.acquired, c.idle, c.total, c.max, c.acquires, c.acquireSeconds, c.emptyAcquires, c.canceled} {
+		ch <- d
+	}
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stat()
+	if s == nil {
+		return
+	}
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+	gauge(c.acquired, float64(s.AcquiredConns()))
+	gauge(c.idle, float64(s.IdleConns()))
+	gauge(c.total, float64(s.TotalConns()))
+	gauge(c.max, float64(s.MaxConns()))
+	counter(c.acquires, float64(s.AcquireCount()))
+	counter(c.acquireSeconds, s.AcquireDuration().Seconds())
+	counter(c.emptyAcquires, float64(s.EmptyAcquireCount()))
+	counter(c.canceled, float64(s.CanceledAcquireCount()))
+}
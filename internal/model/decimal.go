@@ -0,0 +1,226 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MaxDecimalScale — сколько знаков после запятой храним; дальше округляем.
+const MaxDecimalScale = 18
+
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// Decimal — точное десятичное число coef × 10^-scale без округления через float64.
+// Значение всегда нормализовано (без хвостовых нулей в дробной части),
+// поэтому равные числа равны и по ==. Нулевое значение — 0.
+// В JSON сериализуется строкой: "0.00001234".
+type Decimal struct {
+	coef  int64
+	scale int32
+}
+
+// NewDecimal возвращает coef × 10^-scale, например NewDecimal(12345, 2) == 123.45.
+func NewDecimal(coef int64, scale int32) Decimal {
+	d, err := fromBig(big.NewInt(coef), scale)
+	if err != nil {
+		// int64 с неотрицательным scale всегда представим; отрицательный — ошибка вызывающего
+		panic(err)
+	}
+	return d
+}
+
+// ParseDecimal разбирает десятичную запись, в том числе экспоненциальную (1.2e-05),
+// как её отдают JSON-API провайдеров. Знаки дальше MaxDecimalScale округляются.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	mant, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+		}
+		mant, exp = s[:i], e
+	}
+	neg := false
+	switch {
+	case strings.HasPrefix(mant, "-"):
+		neg, mant = true, mant[1:]
+	case strings.HasPrefix(mant, "+"):
+		mant = mant[1:]
+	}
+	intPart, frac, _ := strings.Cut(mant, ".")
+	digits := intPart + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		coef.Neg(coef)
+	}
+	scale := int64(len(frac)) - exp
+	switch {
+	case coef.Sign() == 0, scale > MaxDecimalScale+int64(len(digits))+1:
+		// ноль или меньше любой точности, которую храним
+		return Decimal{}, nil
+	case scale < -19:
+		return Decimal{}, fmt.Errorf("%w: out of range: %q", ErrInvalidDecimal, s)
+	case scale < 0:
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	d, err := fromBig(coef, int32(scale))
+	if err != nil {
+		return Decimal{}, fmt.Errorf("%w: %q", err, s)
+	}
+	return d, nil
+}
+
+// MustDecimal — ParseDecimal для констант и тестов; паникует на кривой записи.
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// fromBig округляет coef × 10^-scale до MaxDecimalScale знаков (и дальше, если
+// коэффициент не влезает в int64), затем нормализует.
+func fromBig(coef *big.Int, scale int32) (Decimal, error) {
+	if scale < 0 {
+		return Decimal{}, fmt.Errorf("%w: negative scale %d", ErrInvalidDecimal, scale)
+	}
+	c := new(big.Int).Set(coef)
+	for scale > MaxDecimalScale || (!c.IsInt64() && scale > 0) {
+		c = roundDiv(c, big.NewInt(10))
+		scale--
+	}
+	if !c.IsInt64() {
+		return Decimal{}, fmt.Errorf("%w: out of range", ErrInvalidDecimal)
+	}
+	v := c.Int64()
+	for scale > 0 && v%10 == 0 {
+		v /= 10
+		scale--
+	}
+	if v == 0 {
+		scale = 0
+	}
+	return Decimal{coef: v, scale: scale}, nil
+}
+
+// roundDiv — деление с округлением половины от нуля.
+func roundDiv(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(new(big.Int).Abs(b)) >= 0 {
+		if a.Sign()*b.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+// scaled приводит d к scale знаков (scale >= d.scale).
+func (d Decimal) scaled(scale int32) *big.Int {
+	c := big.NewInt(d.coef)
+	return c.Mul(c, pow10(int64(scale-d.scale)))
+}
+
+func (d Decimal) Sign() int {
+	switch {
+	case d.coef > 0:
+		return 1
+	case d.coef < 0:
+		return -1
+	}
+	return 0
+}
+
+func (d Decimal) IsZero() bool { return d.coef == 0 }
+
+// Cmp возвращает -1, 0 или 1.
+func (d Decimal) Cmp(o Decimal) int {
+	s := max(d.scale, o.scale)
+	return d.scaled(s).Cmp(o.scaled(s))
+}
+
+// Sub возвращает d - o. Для цен (оба положительные) переполнение невозможно.
+func (d Decimal) Sub(o Decimal) Decimal {
+	s := max(d.scale, o.scale)
+	out, err := fromBig(new(big.Int).Sub(d.scaled(s), o.scaled(s)), s)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Mean — среднее арифметическое, округлённое до MaxDecimalScale знаков.
+// Пустой список — 0.
+func Mean(xs ...Decimal) Decimal {
+	if len(xs) == 0 {
+		return Decimal{}
+	}
+	sum := new(big.Int)
+	for _, x := range xs {
+		sum.Add(sum, x.scaled(MaxDecimalScale))
+	}
+	out, err := fromBig(roundDiv(sum, big.NewInt(int64(len(xs)))), MaxDecimalScale)
+	if err != nil {
+		// среднее не больше максимума, а каждое слагаемое представимо
+		panic(err)
+	}
+	return out
+}
+
+// Float64 — приближённое значение; только для метрик и процентов, не для хранения.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String — запись без экспоненты: "123.45", "0.00001234", "-1".
+func (d Decimal) String() string {
+	digits := strconv.FormatInt(d.coef, 10)
+	sign := ""
+	if d.coef < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON принимает и строку ("1.5"), и число (1.5) — числа разбираются
+// из исходного текста, без float64.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, `"`), `"`)
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
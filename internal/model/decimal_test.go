@@ -0,0 +1,71 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"123.45", "123.45"},
+		{"29150.320000", "29150.32"},
+		{"0.00001234", "0.00001234"},
+		{"1.234e-05", "0.00001234"},
+		{"6.5E-8", "0.000000065"},
+		{"1.5e3", "1500"},
+		{"-0.10", "-0.1"},
+		{"+7", "7"},
+		{"0.000", "0"},
+		{".5", "0.5"},
+		{"5.", "5"},
+		{"0.1234567890123456789", "0.123456789012345679"}, // округление до MaxDecimalScale
+		{"1e-40", "0"},
+	}
+	for _, tc := range tests {
+		d, err := ParseDecimal(tc.in)
+		require.NoError(t, err, tc.in)
+		require.Equal(t, tc.want, d.String(), tc.in)
+	}
+}
+
+func TestParseDecimal_Invalid(t *testing.T) {
+	for _, in := range []string{"", "abc", "1.2.3", "1e", "--1", ".", "1e30"} {
+		_, err := ParseDecimal(in)
+		require.ErrorIs(t, err, ErrInvalidDecimal, in)
+	}
+}
+
+func TestDecimal_NormalizedEquality(t *testing.T) {
+	require.Equal(t, MustDecimal("1.50"), MustDecimal("1.5"))
+	require.Equal(t, NewDecimal(12345, 2), MustDecimal("123.45"))
+	require.Equal(t, Decimal{}, MustDecimal("0.00"))
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a, b := MustDecimal("0.00001"), MustDecimal("0.000012")
+	require.Equal(t, -1, a.Cmp(b))
+	require.Equal(t, 0, a.Cmp(MustDecimal("1e-5")))
+	require.Equal(t, "0.000002", b.Sub(a).String())
+	require.Equal(t, "0.000011", Mean(a, b).String())
+	require.Equal(t, "0.333333333333333333", Mean(NewDecimal(1, 0), Decimal{}, Decimal{}).String())
+	require.Equal(t, 1, a.Sign())
+	require.True(t, Decimal{}.IsZero())
+	require.InDelta(t, 1e-5, a.Float64(), 1e-12)
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	b, err := json.Marshal(struct {
+		P Decimal `json:"p"`
+	}{MustDecimal("0.0000089")})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"p":"0.0000089"}`, string(b))
+
+	var v struct{ A, B Decimal }
+	require.NoError(t, json.Unmarshal([]byte(`{"A":"1.10","B":8.9e-06}`), &v))
+	require.Equal(t, "1.1", v.A.String())
+	require.Equal(t, "0.0000089", v.B.String())
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Ошибки валидации, которые сервис отдаёт наружу, а API мапит в 4xx.
+var (
+	ErrInvalidInterval = errors.New("unsupported interval")
+	ErrRangeTooLarge   = errors.New("requested range is too large")
+	ErrInvalidQuote    = errors.New("invalid quote currency")
+	ErrInvalidAlert    = errors.New("invalid alert")
+	ErrInvalidPeriod   = errors.New("invalid period")
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	ErrInvalidBackfill = errors.New("invalid backfill request")
+
+	ErrInvalidDeliveryStatus = errors.New("invalid delivery status")
+)
+
+// ErrNotTracked — тикер не отслеживается (его не добавляли через /currency/add).
+var ErrNotTracked = errors.New("symbol is not tracked")
+
+// ErrShuttingDown — сервис останавливается, новые коллекторы не запускаются.
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// ErrBackfillNotFound — задачи догрузки истории с таким id нет.
+var ErrBackfillNotFound = errors.New("backfill job not found")
+
+// ErrBackfillUnavailable — догрузка истории не настроена (нет источника исторических цен).
+var ErrBackfillUnavailable = errors.New("backfill is not available")
+
+// ErrAlertNotFound — правила оповещения с таким id нет.
+var ErrAlertNotFound = errors.New("alert not found")
+
+// Ошибки источников цены: провайдер не знает монету или отдал пустую цену.
+// Адаптеры оборачивают их через %w, проверять — errors.Is.
+var (
+	ErrUnknownCoin = errors.New("unknown coin")
+	ErrZeroPrice   = errors.New("zero price")
+
+	ErrUnsupportedQuote = errors.New("unsupported quote currency")
+)
+
+// ErrSymbolExists — тикер уже сопоставлен другой монете; заменить можно только явным оверрайдом.
+var ErrSymbolExists = errors.New("symbol mapping already exists")
+
+// ErrAmbiguousSymbol — под одним тикером несколько монет, нужен явный provider_id.
+var ErrAmbiguousSymbol = errors.New("ambiguous symbol")
+
+// SymbolError — тикер не удалось однозначно сопоставить монете провайдера.
+// Err — ErrUnknownCoin или ErrAmbiguousSymbol; Suggestions — что можно выбрать взамен.
+type SymbolError struct {
+	Symbol      string
+	Err         error
+	Suggestions []CoinRef
+}
+
+func (e *SymbolError) Error() string { return fmt.Sprintf("%s: %s", e.Err, e.Symbol) }
+func (e *SymbolError) Unwrap() error { return e.Err }
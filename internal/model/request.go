@@ -10,7 +10,7 @@ type RemoveReq struct {
 }
 
 type PriceResponse struct {
-	Symbol string `json:"coin"`
-	TS     int64  `json:"timestamp"`
-	Price  int64  `json:"price"`
+	Symbol string  `json:"coin"`
+	TS     int64   `json:"timestamp"`
+	Price  Decimal `json:"price"` // десятичная строка, как в PriceDTO
 }
@@ -1,26 +1,465 @@
 package model
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// DefaultQuote — валюта котировки, если клиент её не указал.
+const DefaultQuote = "usd"
+
 type Price struct {
+	ID      int64 // prices.id; 0 — ещё не сохранена
+	Symbol  string
+	Quote   string // валюта котировки: usd, eur, btc, ...
+	TS      int64
+	Price   Decimal
+	Sources int     // сколько источников вошло в цену
+	Spread  Decimal // max - min между источниками
+}
+
+// WatchItem — запись watchlist: что отслеживаем и с каким периодом.
+type WatchItem struct {
+	Symbol     string
+	ProviderID string   // id монеты у провайдера (CoinGecko), определён при добавлении
+	Quotes     []string // валюты котировки; пусто — только DefaultQuote
+	Period     int      // период опроса в секундах
+	Align      bool     // тики на границах, кратных периоду (:00, :10, :20…)
+	Cron       string   // cron-выражение вместо периода; "" — по периоду
+	Windows    []ScheduleWindow
+	CreatedAt  int64 // unix seconds
+	Paused     bool
+}
+
+// ScheduleWindow — интервал времени со своим периодом опроса; вне окон действует
+// период тикера. Например, торговые часы NYSE: пн–пт 09:30–16:00 America/New_York.
+type ScheduleWindow struct {
+	Days     []time.Weekday // пусто — каждый день
+	From, To int            // минуты от полуночи в TZ, From < To
+	TZ       string         // IANA-зона; "" — UTC
+	Period   int            // секунды
+}
+
+// SymbolMapping — соответствие локального тикера id монеты у провайдера.
+type SymbolMapping struct {
+	Symbol     string
+	ProviderID string
+	Source     string // builtin|file|watchlist|admin
+	UpdatedAt  int64
+}
+
+// HistoryCursor — позиция keyset-пагинации по (ts, id) в таблице prices.
+type HistoryCursor struct {
+	TS int64
+	ID int64
+}
+
+// HistoryPage — страница ряда цен; Next == nil, если дальше строк нет.
+type HistoryPage struct {
+	Items []Price
+	Next  *HistoryCursor
+}
+
+// Candle — OHLC-свеча; TS — начало бакета (unix seconds).
+type Candle struct {
 	Symbol string
 	TS     int64
-	Price  int64
+	Open   Decimal
+	High   Decimal
+	Low    Decimal
+	Close  Decimal
+	Count  int64
+}
+
+// CollectorStatus — что сейчас происходит со сбором цены по тикеру.
+type CollectorStatus struct {
+	Symbol    string
+	Running   bool
+	State     string // pending|ok|error|unknown_coin
+	Error     string
+	PeriodSec int64 // текущий период: для cron — до следующего срабатывания, для окон — действующий сейчас
+	Aligned   bool
+	Cron      string
+	Windows   []ScheduleWindow
+	Quotes    []string
+	LastFetch int64              // unix последнего тика; 0 — ещё не было
+	LastPrice map[string]Decimal // последняя сохранённая цена по валютам котировки
+	Failures  int                // неудачных тиков подряд
+}
+
+// Readiness — готовность сервиса к работе. Без БД сервис не готов;
+// провайдеры и застывшие коллекторы — для сведения, трафик не снимают.
+type Readiness struct {
+	DBError   string // "" — ping прошёл
+	Providers []ProviderHealth
+	Stale     []StaleCollector
+}
+
+// ProviderHealth — получал ли провайдер цены в последнее время.
+type ProviderHealth struct {
+	Name        string
+	LastSuccess int64 // unix; 0 — ещё ни разу
+	OK          bool
+}
+
+// StaleCollector — работающий коллектор, который давно ничего не сохранял.
+type StaleCollector struct {
+	Symbol    string
+	PeriodSec int64
+	LastSave  int64 // unix; 0 — ещё ни разу
+}
+
+// Виды правил оповещения
+const (
+	AlertAbove  = "above"  // цена выше порога
+	AlertBelow  = "below"  // цена ниже порога
+	AlertChange = "change" // изменение за окно на Threshold процентов (минус — падение)
+)
+
+// Состояния правила оповещения
+const (
+	AlertArmed    = "armed"    // ждёт условия
+	AlertFired    = "fired"    // сработало, условие ещё выполняется
+	AlertCooldown = "cooldown" // условие ушло, но пауза после срабатывания не истекла
+)
+
+// Alert — правило оповещения по цене тикера в валюте Quote.
+type Alert struct {
+	ID         int64
+	Symbol     string
+	Quote      string
+	Kind       string  // above|below|change
+	Threshold  Decimal // above/below — цена; change — процент
+	Window     int64   // change: с какой давности цену сравниваем, секунды
+	Cooldown   int64   // секунд после срабатывания, когда правило не взводится снова
+	State      string  // armed|fired|cooldown
+	FiredAt    int64
+	FiredPrice Decimal
+	CreatedAt  int64
+}
+
+// События исходящих вебхуков
+const (
+	EventAlertFired     = "alert.fired"
+	EventCollectorError = "collector.error"
+)
+
+// Статусы доставки вебхука
+const (
+	DeliveryPending   = "pending"   // ждёт отправки или повтора
+	DeliveryDelivered = "delivered" // получатель ответил 2xx
+	DeliveryFailed    = "failed"    // попытки исчерпаны
+)
+
+// WebhookDelivery — одна отправка события одному получателю (строка очереди webhook_deliveries).
+// Payload — ровно те байты, что уходят в теле POST и подписываются.
+type WebhookDelivery struct {
+	ID            int64
+	Endpoint      string // имя получателя из конфига
+	URL           string
+	Event         string
+	Payload       string
+	Status        string // pending|delivered|failed
+	Attempts      int
+	NextAttemptAt int64
+	LastError     string
+	LastCode      int // HTTP-код последней попытки; 0 — ответа не было
+	CreatedAt     int64
+	DeliveredAt   int64
+}
+
+// Состояния задачи догрузки истории
+const (
+	BackfillPending = "pending" // ждёт воркера (или прервана остановкой и продолжится с Cursor)
+	BackfillRunning = "running"
+	BackfillDone    = "done"
+	BackfillFailed  = "failed"
+)
+
+// BackfillJob — догрузка истории цен тикера за [From, To] из market_chart провайдера
+// (строка backfill_jobs). Диапазон проходится кусками; Cursor — начало следующего куска.
+type BackfillJob struct {
+	ID         int64
+	Symbol     string
+	ProviderID string // id монеты у CoinGecko
+	Quote      string
+	From, To   int64 // unix seconds
+	Cursor     int64
+	State      string // pending|running|done|failed
+	Fetched    int    // точек получено от провайдера
+	Inserted   int    // из них записано; остальные уже были в prices
+	Error      string
+	CreatedAt  int64
+	StartedAt  int64
+	UpdatedAt  int64 // последний сохранённый прогресс; по нему находят задачи упавшего процесса
+	FinishedAt int64
+}
+
+// Gap — разрыв в истории тикера: между соседними ценами From и To (unix seconds)
+// коллектор пропустил больше тиков своего расписания, чем допускает аудитор.
+type Gap struct {
+	Symbol      string
+	Quote       string
+	From, To    int64
+	Missed      int   // сколько тиков расписания пропущено (счёт обрывается на большом числе)
+	BackfillJob int64 // задача догрузки, покрывающая разрыв; 0 — нет
 }
 
 type PriceDTO struct {
+	Coin      string   `json:"coin"`
+	Quote     string   `json:"quote"`
+	Timestamp int64    `json:"timestamp"`
+	Price     Decimal  `json:"price"` // десятичная строка: "0.00001234"
+	Sources   int      `json:"sources,omitempty"`
+	Spread    *Decimal `json:"spread,omitempty"`
+}
+
+type HistoryResponse struct {
+	Coin       string     `json:"coin"`
+	Quote      string     `json:"quote"`
+	Items      []PriceDTO `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+type CandleDTO struct {
+	Timestamp int64   `json:"timestamp"`
+	Open      Decimal `json:"open"`
+	High      Decimal `json:"high"`
+	Low       Decimal `json:"low"`
+	Close     Decimal `json:"close"`
+	Count     int64   `json:"count"`
+}
+
+type CandlesResponse struct {
+	Coin     string      `json:"coin"`
+	Quote    string      `json:"quote"`
+	Interval string      `json:"interval"`
+	Candles  []CandleDTO `json:"candles"`
+}
+
+type CollectorStatusDTO struct {
+	Coin      string              `json:"coin"`
+	Running   bool                `json:"running"`
+	State     string              `json:"state"`
+	Error     string              `json:"error,omitempty"`
+	PeriodSec int64               `json:"period_sec"`
+	Aligned   bool                `json:"align"`
+	Cron      string              `json:"cron,omitempty"`
+	Windows   []ScheduleWindowDTO `json:"windows,omitempty"`
+	Quotes    []string            `json:"quotes"`
+	LastFetch int64               `json:"last_fetch,omitempty"`
+	LastPrice map[string]Decimal  `json:"last_price,omitempty"`
+	Failures  int                 `json:"consecutive_failures"`
+}
+
+// Итог /readyz
+const (
+	ReadyOK       = "ready"
+	ReadyDegraded = "degraded"  // БД доступна, но провайдер молчит или есть застывшие коллекторы
+	ReadyNotReady = "not_ready" // БД недоступна
+)
+
+type ReadinessDTO struct {
+	Status    string              `json:"status"`
+	DB        string              `json:"db"`
+	Providers []ProviderHealthDTO `json:"providers"`
+	Stale     []StaleCollectorDTO `json:"stale_collectors"`
+}
+
+type ProviderHealthDTO struct {
+	Name        string `json:"name"`
+	OK          bool   `json:"ok"`
+	LastSuccess int64  `json:"last_success,omitempty"`
+}
+
+type StaleCollectorDTO struct {
 	Coin      string `json:"coin"`
-	Timestamp int64  `json:"timestamp"`
-	Price     int64  `json:"price"`
+	PeriodSec int64  `json:"period_sec"`
+	LastSave  int64  `json:"last_save,omitempty"`
+}
+
+type SymbolMappingDTO struct {
+	Symbol     string `json:"symbol"`
+	ProviderID string `json:"provider_id"`
+	Source     string `json:"source"`
+	UpdatedAt  int64  `json:"updated_at,omitempty"`
+}
+
+type SymbolMappingReq struct {
+	Symbol     string `json:"symbol"`
+	ProviderID string `json:"provider_id"`
 }
 
 type AddReq struct {
-	Symbol string `json:"symbol"` // например: "btc"
-	Period int    `json:"period"` // период опроса в секундах
+	Symbol     string   `json:"symbol"`                // например: "btc"
+	Period     int      `json:"period"`                // период опроса в секундах
+	ProviderID string   `json:"provider_id,omitempty"` // явный id CoinGecko, если тикер неоднозначен
+	Quotes     []string `json:"quotes,omitempty"`      // валюты котировки; по умолчанию ["usd"]
+	Align      bool     `json:"align,omitempty"`       // тики на границах периода по часам
+	// Cron — расписание вместо period: "*/5 * * * * *" (с секундами) или "0 * * * *";
+	// зона — префиксом "CRON_TZ=America/New_York ...", по умолчанию UTC.
+	Cron string `json:"cron,omitempty"`
+	// Windows — окна со своим периодом; вне окон — period. С cron не сочетается.
+	Windows []ScheduleWindowDTO `json:"windows,omitempty"`
+}
+
+// ScheduleWindowDTO — окно расписания в запросах и ответах:
+// {"days":["mon","fri"],"from":"09:30","to":"16:00","tz":"America/New_York","period":5}.
+type ScheduleWindowDTO struct {
+	Days   []string `json:"days,omitempty"` // mon..sun; пусто — каждый день
+	From   string   `json:"from"`           // HH:MM
+	To     string   `json:"to"`             // HH:MM, позже from
+	TZ     string   `json:"tz,omitempty"`   // по умолчанию UTC
+	Period int      `json:"period"`         // секунды
+}
+
+// UpdateReq — изменение настроек отслеживаемого тикера (PATCH /currency/{symbol}).
+// Нулевые поля не меняются.
+type UpdateReq struct {
+	Period int      `json:"period,omitempty"` // новый период опроса в секундах
+	Quotes []string `json:"quotes,omitempty"` // новый список валют котировки
+	Align  *bool    `json:"align,omitempty"`  // включить/выключить выравнивание тиков
+	// Cron — новое cron-выражение; "" — вернуться к периоду.
+	Cron *string `json:"cron,omitempty"`
+	// Windows — новый список окон; [] — убрать окна.
+	Windows []ScheduleWindowDTO `json:"windows,omitempty"`
+}
+
+// AlertReq — создание/изменение правила оповещения.
+// Примеры: {"symbol":"btc","kind":"above","threshold":"70000"},
+// {"symbol":"eth","kind":"change","threshold":"-5","window_sec":3600}.
+type AlertReq struct {
+	Symbol      string  `json:"symbol"`
+	Quote       string  `json:"quote,omitempty"` // по умолчанию usd
+	Kind        string  `json:"kind"`            // above|below|change
+	Threshold   Decimal `json:"threshold"`
+	WindowSec   int64   `json:"window_sec,omitempty"`
+	CooldownSec int64   `json:"cooldown_sec,omitempty"`
+}
+
+type AlertDTO struct {
+	ID          int64    `json:"id"`
+	Symbol      string   `json:"symbol"`
+	Quote       string   `json:"quote"`
+	Kind        string   `json:"kind"`
+	Threshold   Decimal  `json:"threshold"`
+	WindowSec   int64    `json:"window_sec,omitempty"`
+	CooldownSec int64    `json:"cooldown_sec"`
+	State       string   `json:"state"`
+	FiredAt     int64    `json:"fired_at,omitempty"`
+	FiredPrice  *Decimal `json:"fired_price,omitempty"`
+	CreatedAt   int64    `json:"created_at"`
+}
+
+// BackfillReq — запуск догрузки истории (POST /currency/backfill).
+// Пример: {"symbol":"btc","quote":"usd","from":1704067200,"to":1706745600}.
+type BackfillReq struct {
+	Symbol     string `json:"symbol"`
+	Quote      string `json:"quote,omitempty"`       // по умолчанию usd
+	From       int64  `json:"from"`                  // unix seconds
+	To         int64  `json:"to,omitempty"`          // unix seconds; по умолчанию — сейчас
+	ProviderID string `json:"provider_id,omitempty"` // явный id CoinGecko, если тикер неоднозначен
+}
+
+type BackfillJobDTO struct {
+	ID         int64   `json:"id"`
+	Symbol     string  `json:"symbol"`
+	ProviderID string  `json:"provider_id"`
+	Quote      string  `json:"quote"`
+	From       int64   `json:"from"`
+	To         int64   `json:"to"`
+	State      string  `json:"state"`
+	Progress   float64 `json:"progress"` // доля пройденного диапазона, 0..1
+	Fetched    int     `json:"fetched"`
+	Inserted   int     `json:"inserted"`
+	Skipped    int     `json:"skipped"` // дубли уже сохранённых цен
+	Error      string  `json:"error,omitempty"`
+	CreatedAt  int64   `json:"created_at"`
+	StartedAt  int64   `json:"started_at,omitempty"`
+	FinishedAt int64   `json:"finished_at,omitempty"`
+}
+
+type GapDTO struct {
+	Symbol        string `json:"symbol"`
+	Quote         string `json:"quote"`
+	From          int64  `json:"from"` // ts последней цены перед разрывом
+	To            int64  `json:"to"`   // ts первой цены после разрыва
+	DurationSec   int64  `json:"duration_sec"`
+	Missed        int    `json:"missed"`
+	BackfillJobID int64  `json:"backfill_job_id,omitempty"`
+}
+
+// WebhookEvent — тело POST вебхука.
+type WebhookEvent struct {
+	Event     string `json:"event"` // alert.fired|collector.error
+	Timestamp int64  `json:"timestamp"`
+	Data      any    `json:"data"`
+}
+
+// AlertFiredData — data события alert.fired.
+type AlertFiredData struct {
+	AlertID   int64   `json:"alert_id"`
+	Symbol    string  `json:"symbol"`
+	Quote     string  `json:"quote"`
+	Kind      string  `json:"kind"`
+	Threshold Decimal `json:"threshold"`
+	WindowSec int64   `json:"window_sec,omitempty"`
+	Price     Decimal `json:"price"`
+	PriceTS   int64   `json:"price_timestamp"`
+}
+
+// CollectorErrorData — data события collector.error.
+type CollectorErrorData struct {
+	Symbol string `json:"symbol"`
+	State  string `json:"state"` // error|unknown_coin
+	Error  string `json:"error"`
+}
+
+type WebhookDeliveryDTO struct {
+	ID            int64           `json:"id"`
+	Endpoint      string          `json:"endpoint"`
+	URL           string          `json:"url"`
+	Event         string          `json:"event"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt int64           `json:"next_attempt_at,omitempty"`
+	LastError     string          `json:"last_error,omitempty"`
+	LastCode      int             `json:"last_code,omitempty"`
+	CreatedAt     int64           `json:"created_at"`
+	DeliveredAt   int64           `json:"delivered_at,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// WSRequest — сообщение клиента в /ws/prices.
+type WSRequest struct {
+	Action  string   `json:"action"` // subscribe|unsubscribe
+	Symbols []string `json:"symbols"`
+}
+
+// WSMessage — сообщение сервера в /ws/prices: подтверждение подписки, цена или ошибка.
+type WSMessage struct {
+	Type    string    `json:"type"` // subscribed|price|error
+	Symbols []string  `json:"symbols,omitempty"`
+	Data    *PriceDTO `json:"data,omitempty"`
+	Error   string    `json:"error,omitempty"`
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// CoinRef — монета из справочника провайдера
+type CoinRef struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+type SymbolErrorResponse struct {
+	Error       string    `json:"error"`
+	Suggestions []CoinRef `json:"suggestions,omitempty"`
+}
+
 func StatusOK() map[string]string {
 	return map[string]string{"status": "ok"}
 }
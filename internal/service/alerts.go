@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+// alertBook — кэш правил оповещения; источник правды — таблица alerts,
+// но проверять правила на каждом тике через БД слишком дорого.
+type alertBook struct {
+	mu   sync.Mutex
+	byID map[int64]*model.Alert
+}
+
+func newAlertBook() *alertBook {
+	return &alertBook{byID: make(map[int64]*model.Alert)}
+}
+
+// ListAlerts — все правила по возрастанию id.
+func (s *Service) ListAlerts() []model.Alert {
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	out := make([]model.Alert, 0, len(s.alerts.byID))
+	for _, a := range s.alerts.byID {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (s *Service) GetAlert(id int64) (*model.Alert, error) {
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	a, ok := s.alerts.byID[id]
+	if !ok {
+		return nil, model.ErrAlertNotFound
+	}
+	out := *a
+	return &out, nil
+}
+
+// CreateAlert проверяет и сохраняет правило; новое правило сразу взведено.
+func (s *Service) CreateAlert(req model.AlertReq) (*model.Alert, error) {
+	a, err := alertFromReq(req)
+	if err != nil {
+		return nil, err
+	}
+	a.State, a.CreatedAt = model.AlertArmed, time.Now().Unix()
+
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	if a.ID, err = s.st.InsertAlert(context.Background(), a); err != nil {
+		return nil, err
+	}
+	s.alerts.byID[a.ID] = &a
+	logger.L().WithFields(logger.Fields{"id": a.ID, "symbol": a.Symbol, "kind": a.Kind}).Info("Service: CreateAlert")
+	out := a
+	return &out, nil
+}
+
+// UpdateAlert заменяет условие правила и взводит его заново.
+func (s *Service) UpdateAlert(id int64, req model.AlertReq) (*model.Alert, error) {
+	a, err := alertFromReq(req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	cur, ok := s.alerts.byID[id]
+	if !ok {
+		return nil, model.ErrAlertNotFound
+	}
+	a.ID, a.CreatedAt, a.State = id, cur.CreatedAt, model.AlertArmed
+	if err := s.st.UpdateAlert(context.Background(), a); err != nil {
+		return nil, err
+	}
+	*cur = a
+	logger.L().WithField("id", id).Info("Service: UpdateAlert")
+	out := a
+	return &out, nil
+}
+
+func (s *Service) DeleteAlert(id int64) error {
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+	if _, ok := s.alerts.byID[id]; !ok {
+		return model.ErrAlertNotFound
+	}
+	if err := s.st.DeleteAlert(context.Background(), id); err != nil {
+		return err
+	}
+	delete(s.alerts.byID, id)
+	logger.L().WithField("id", id).Info("Service: DeleteAlert")
+	return nil
+}
+
+// alertFromReq нормализует и проверяет условие правила.
+func alertFromReq(req model.AlertReq) (model.Alert, error) {
+	a := model.Alert{
+		Symbol:    normSymbol(req.Symbol),
+		Kind:      req.Kind,
+		Threshold: req.Threshold,
+		Window:    req.WindowSec,
+		Cooldown:  req.CooldownSec,
+	}
+	if a.Symbol == "" {
+		return a, fmt.Errorf("%w: symbol is required", model.ErrInvalidAlert)
+	}
+	quote, err := normQuote(req.Quote)
+	if err != nil {
+		return a, err
+	}
+	a.Quote = quote
+	if a.Cooldown < 0 {
+		return a, fmt.Errorf("%w: cooldown_sec must not be negative", model.ErrInvalidAlert)
+	}
+	switch a.Kind {
+	case model.AlertAbove, model.AlertBelow:
+		if a.Threshold.Sign() <= 0 {
+			return a, fmt.Errorf("%w: threshold must be a positive price", model.ErrInvalidAlert)
+		}
+		a.Window = 0
+	case model.AlertChange:
+		if a.Threshold.IsZero() {
+			return a, fmt.Errorf("%w: threshold must be a non-zero percent", model.ErrInvalidAlert)
+		}
+		if a.Window <= 0 {
+			return a, fmt.Errorf("%w: window_sec is required for change alerts", model.ErrInvalidAlert)
+		}
+	default:
+		return a, fmt.Errorf("%w: unknown kind %q", model.ErrInvalidAlert, a.Kind)
+	}
+	return a, nil
+}
+
+// evaluateAlerts проверяет правила тикера по только что сохранённой цене.
+// Вызывается из коллектора после SavePrice; ошибки только логируются.
+func (s *Service) evaluateAlerts(p model.Price) {
+	s.alerts.mu.Lock()
+	var due []model.Alert
+	for _, a := range s.alerts.byID {
+		if a.Symbol == normSymbol(p.Symbol) && a.Quote == p.Quote {
+			due = append(due, *a)
+		}
+	}
+	s.alerts.mu.Unlock()
+
+	for _, a := range due {
+		log := logger.L().WithFields(logger.Fields{"alert": a.ID, "symbol": a.Symbol, "quote": a.Quote})
+		hit, err := s.alertHit(a, p)
+		if err != nil {
+			log.WithError(err).Error("Alerts: evaluate failed")
+			continue
+		}
+		next, fired := stepAlert(a, hit, p)
+		if next == a {
+			continue
+		}
+
+		// правило могли изменить или удалить, пока шла проверка
+		s.alerts.mu.Lock()
+		cur, ok := s.alerts.byID[a.ID]
+		if !ok || *cur != a {
+			s.alerts.mu.Unlock()
+			continue
+		}
+		*cur = next
+		s.alerts.mu.Unlock()
+
+		if err := s.st.UpdateAlert(context.Background(), next); err != nil {
+			log.WithError(err).Error("Alerts: save state failed")
+		}
+		if fired {
+			log.WithFields(logger.Fields{"kind": a.Kind, "threshold": a.Threshold.String(), "price": p.Price.String()}).
+				Warn("Alerts: fired")
+			s.notify(model.EventAlertFired, model.AlertFiredData{
+				AlertID:   a.ID,
+				Symbol:    a.Symbol,
+				Quote:     a.Quote,
+				Kind:      a.Kind,
+				Threshold: a.Threshold,
+				WindowSec: a.Window,
+				Price:     p.Price,
+				PriceTS:   p.TS,
+			})
+		}
+	}
+}
+
+// alertHit — выполняется ли условие правила для цены p.
+// Для change цена сравнивается с последней сохранённой не позже p.TS - Window;
+// если истории столько нет — условие не выполнено.
+func (s *Service) alertHit(a model.Alert, p model.Price) (bool, error) {
+	switch a.Kind {
+	case model.AlertAbove:
+		return p.Price.Cmp(a.Threshold) > 0, nil
+	case model.AlertBelow:
+		return p.Price.Cmp(a.Threshold) < 0, nil
+	case model.AlertChange:
+		ref, err := s.st.GetClosestPrice(context.Background(), a.Symbol, a.Quote, p.TS-a.Window)
+		if err != nil || ref == nil || ref.Price.Sign() <= 0 {
+			return false, err
+		}
+		pct := p.Price.Sub(ref.Price).Float64() / ref.Price.Float64() * 100
+		th := a.Threshold.Float64()
+		if th < 0 {
+			return pct <= th, nil
+		}
+		return pct >= th, nil
+	}
+	return false, nil
+}
+
+// stepAlert — переход состояния правила по результату проверки:
+//
+//	armed    → fired    условие выполнилось (это и есть срабатывание);
+//	fired    → cooldown условие ушло, пауза после срабатывания ещё идёт;
+//	fired    → armed    условие ушло, пауза уже истекла;
+//	cooldown → armed    пауза истекла — и правило сразу проверяется заново.
+//
+// Пока правило в fired, повторно оно не срабатывает, сколько бы тиков ни держалось условие.
+func stepAlert(a model.Alert, hit bool, p model.Price) (model.Alert, bool) {
+	cooled := p.TS >= a.FiredAt+a.Cooldown
+	if a.State == model.AlertCooldown && cooled {
+		a.State = model.AlertArmed
+	}
+	switch a.State {
+	case model.AlertArmed:
+		if hit {
+			a.State, a.FiredAt, a.FiredPrice = model.AlertFired, p.TS, p.Price
+			return a, true
+		}
+	case model.AlertFired:
+		if !hit {
+			a.State = model.AlertCooldown
+			if cooled {
+				a.State = model.AlertArmed
+			}
+		}
+	}
+	return a, false
+}
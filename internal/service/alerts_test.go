@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func btcAt(ts int64, price string) model.Price {
+	return model.Price{Symbol: "btc", Quote: "usd", TS: ts, Price: model.MustDecimal(price)}
+}
+
+func TestService_CreateAlert_Validation(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	th := model.MustDecimal
+
+	bad := []model.AlertReq{
+		{Kind: model.AlertAbove, Threshold: th("1")},
+		{Symbol: "btc", Kind: "sideways", Threshold: th("1")},
+		{Symbol: "btc", Kind: model.AlertAbove},
+		{Symbol: "btc", Kind: model.AlertBelow, Threshold: th("-1")},
+		{Symbol: "btc", Kind: model.AlertChange, Threshold: th("-5")},
+		{Symbol: "btc", Kind: model.AlertChange, WindowSec: 3600},
+		{Symbol: "btc", Kind: model.AlertAbove, Threshold: th("1"), CooldownSec: -1},
+	}
+	for _, req := range bad {
+		_, err := s.CreateAlert(req)
+		require.ErrorIs(t, err, model.ErrInvalidAlert, req)
+	}
+	_, err := s.CreateAlert(model.AlertReq{Symbol: "btc", Quote: "$", Kind: model.AlertAbove, Threshold: th("1")})
+	require.ErrorIs(t, err, model.ErrInvalidQuote)
+	require.Empty(t, s.ListAlerts())
+}
+
+func TestService_AlertCRUD(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+
+	a, err := s.CreateAlert(model.AlertReq{Symbol: " BTC", Kind: model.AlertAbove, Threshold: model.MustDecimal("70000")})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), a.ID)
+	require.Equal(t, "btc", a.Symbol)
+	require.Equal(t, "usd", a.Quote)
+	require.Equal(t, model.AlertArmed, a.State)
+	require.Equal(t, *a, fs.alerts[1])
+
+	upd, err := s.UpdateAlert(a.ID, model.AlertReq{Symbol: "btc", Kind: model.AlertBelow, Threshold: model.MustDecimal("60000"), CooldownSec: 60})
+	require.NoError(t, err)
+	require.Equal(t, a.CreatedAt, upd.CreatedAt)
+	got, err := s.GetAlert(a.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.AlertBelow, got.Kind)
+	require.Equal(t, int64(60), fs.alerts[1].Cooldown)
+
+	require.NoError(t, s.DeleteAlert(a.ID))
+	require.Empty(t, fs.alerts)
+	require.ErrorIs(t, s.DeleteAlert(a.ID), model.ErrAlertNotFound)
+	_, err = s.GetAlert(a.ID)
+	require.ErrorIs(t, err, model.ErrAlertNotFound)
+	_, err = s.UpdateAlert(a.ID, model.AlertReq{Symbol: "btc", Kind: model.AlertAbove, Threshold: model.MustDecimal("1")})
+	require.ErrorIs(t, err, model.ErrAlertNotFound)
+}
+
+func TestStepAlert_Transitions(t *testing.T) {
+	a := model.Alert{Kind: model.AlertAbove, Cooldown: 100, State: model.AlertArmed}
+
+	// условие выполнилось — срабатывание
+	a, fired := stepAlert(a, true, btcAt(10, "71000"))
+	require.True(t, fired)
+	require.Equal(t, model.AlertFired, a.State)
+	require.Equal(t, int64(10), a.FiredAt)
+	require.Equal(t, "71000", a.FiredPrice.String())
+
+	// пока условие держится — повторно не срабатывает
+	a, fired = stepAlert(a, true, btcAt(20, "72000"))
+	require.False(t, fired)
+	require.Equal(t, model.AlertFired, a.State)
+
+	// условие ушло внутри паузы — cooldown; вернулось — всё ещё без срабатывания
+	a, _ = stepAlert(a, false, btcAt(30, "69000"))
+	require.Equal(t, model.AlertCooldown, a.State)
+	a, fired = stepAlert(a, true, btcAt(50, "71000"))
+	require.False(t, fired)
+	require.Equal(t, model.AlertCooldown, a.State)
+
+	// пауза истекла — правило взводится и сразу срабатывает
+	a, fired = stepAlert(a, true, btcAt(110, "71000"))
+	require.True(t, fired)
+	require.Equal(t, int64(110), a.FiredAt)
+
+	// условие ушло после паузы — сразу armed
+	a, _ = stepAlert(a, false, btcAt(300, "69000"))
+	require.Equal(t, model.AlertArmed, a.State)
+}
+
+func TestService_EvaluateAlerts_ThresholdFiresOnce(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+	a, err := s.CreateAlert(model.AlertReq{Symbol: "btc", Kind: model.AlertAbove, Threshold: model.MustDecimal("70000")})
+	require.NoError(t, err)
+	other, err := s.CreateAlert(model.AlertReq{Symbol: "btc", Quote: "eur", Kind: model.AlertAbove, Threshold: model.MustDecimal("1")})
+	require.NoError(t, err)
+
+	s.evaluateAlerts(btcAt(1, "69999.99"))
+	got, _ := s.GetAlert(a.ID)
+	require.Equal(t, model.AlertArmed, got.State)
+
+	s.evaluateAlerts(btcAt(2, "70000.01"))
+	got, _ = s.GetAlert(a.ID)
+	require.Equal(t, model.AlertFired, got.State)
+	require.Equal(t, "70000.01", got.FiredPrice.String())
+	require.Equal(t, *got, fs.alerts[a.ID], "state persisted")
+
+	s.evaluateAlerts(btcAt(3, "70500"))
+	got, _ = s.GetAlert(a.ID)
+	require.Equal(t, int64(2), got.FiredAt, "no refire while condition holds")
+
+	got, _ = s.GetAlert(other.ID)
+	require.Equal(t, model.AlertArmed, got.State, "usd price does not touch eur alert")
+}
+
+func TestService_EvaluateAlerts_MixedCaseSymbol(t *testing.T) {
+	fs := &fakeStorage{}
+	reg := NewProviderRegistry()
+	reg.Register("fake", &fakePriceClient{val: model.MustDecimal("71000")})
+	s := NewService(fs /*defaultPeriod*/, 1, reg, NewSymbolRegistry())
+	a, err := s.CreateAlert(model.AlertReq{Symbol: "btc", Kind: model.AlertAbove, Threshold: model.MustDecimal("70000")})
+	require.NoError(t, err)
+
+	// тикер добавлен как " BTC ", а правило — на "btc": цена коллектора должна до него дойти
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: " BTC "}))
+	require.Eventually(t, func() bool {
+		got, _ := s.GetAlert(a.ID)
+		return got.State == model.AlertFired
+	}, 3*time.Second, 20*time.Millisecond)
+	require.Contains(t, s.collectors, "btc")
+	require.NotNil(t, s.Status("BTC"))
+
+	require.NoError(t, s.RemoveCurrency("Btc"))
+	require.False(t, s.collectors["btc"].Running())
+	sleepMS(20)
+}
+
+func TestService_EvaluateAlerts_PercentChange(t *testing.T) {
+	fs := &fakeStorage{retPrice: &model.Price{Symbol: "eth", Quote: "usd", TS: 400, Price: model.MustDecimal("2000")}}
+	s := newSvcWith(fs)
+	a, err := s.CreateAlert(model.AlertReq{Symbol: "eth", Kind: model.AlertChange, Threshold: model.MustDecimal("-5"), WindowSec: 3600})
+	require.NoError(t, err)
+
+	eth := func(price string) model.Price {
+		return model.Price{Symbol: "eth", Quote: "usd", TS: 4000, Price: model.MustDecimal(price)}
+	}
+	s.evaluateAlerts(eth("1910")) // -4.5%
+	got, _ := s.GetAlert(a.ID)
+	require.Equal(t, model.AlertArmed, got.State)
+	require.Equal(t, int64(400), fs.gotTS, "reference price taken window ago")
+
+	s.evaluateAlerts(eth("1900")) // -5%
+	got, _ = s.GetAlert(a.ID)
+	require.Equal(t, model.AlertFired, got.State)
+}
+
+func TestService_Restore_LoadsAlerts(t *testing.T) {
+	fs := &fakeStorage{alerts: map[int64]model.Alert{
+		7: {ID: 7, Symbol: "btc", Quote: "usd", Kind: model.AlertBelow, Threshold: model.MustDecimal("10"), State: model.AlertFired},
+	}}
+	s := newSvcWith(fs)
+	require.NoError(t, s.Restore(context.Background()))
+
+	got, err := s.GetAlert(7)
+	require.NoError(t, err)
+	require.Equal(t, model.AlertFired, got.State)
+}
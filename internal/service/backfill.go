@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"crypto-observer/internal/coingecko"
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+type backfillStore interface {
+	InsertPrices(ctx context.Context, ps []model.Price) (int, error)
+	InsertBackfillJob(ctx context.Context, j model.BackfillJob) (int64, error)
+	UpdateBackfillJob(ctx context.Context, j model.BackfillJob) error
+	ClaimBackfillJob(ctx context.Context, now, staleBefore int64) (*model.BackfillJob, error)
+	GetBackfillJob(ctx context.Context, id int64) (*model.BackfillJob, error)
+	ListBackfillJobs(ctx context.Context, symbol string, limit int) ([]model.BackfillJob, error)
+}
+
+// ChartSource — источник исторических цен (coingecko.Client).
+type ChartSource interface {
+	MarketChartRange(ctx context.Context, id, quote string, from, to int64) ([]coingecko.ChartPoint, error)
+}
+
+type BackfillOptions struct {
+	Chunk      time.Duration // диапазон одного запроса к провайдеру; от него зависит шаг точек
+	Batch      int           // сколько цен писать в БД одним INSERT
+	Poll       time.Duration // как часто смотреть в очередь без новых задач
+	StaleAfter time.Duration // running-задачу без прогресса дольше этого забирает другой воркер
+}
+
+// Backfiller — догрузка истории цен из market_chart провайдера.
+// Задачи лежат в backfill_jobs: API ставит их в очередь, фоновый воркер выполняет по одной
+// (чтобы не съедать лимит запросов коллекторов), CLI выполняет свою задачу сразу.
+// Прогресс сохраняется после каждого куска, так что прерванная задача продолжается с места остановки.
+type Backfiller struct {
+	st   backfillStore
+	src  ChartSource
+	opts BackfillOptions
+	now  func() time.Time
+	wake chan struct{}
+}
+
+func NewBackfiller(st backfillStore, src ChartSource, opts BackfillOptions) *Backfiller {
+	if opts.Chunk <= 0 {
+		// до 90 дней CoinGecko отдаёт часовые точки
+		opts.Chunk = 90 * 24 * time.Hour
+	}
+	if opts.Batch <= 0 {
+		opts.Batch = 500
+	}
+	if opts.Poll <= 0 {
+		opts.Poll = 5 * time.Second
+	}
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = 10 * time.Minute
+	}
+	return &Backfiller{
+		st:   st,
+		src:  src,
+		opts: opts,
+		now:  time.Now,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Submit проверяет задачу и ставит её в очередь воркера.
+func (b *Backfiller) Submit(j model.BackfillJob) (*model.BackfillJob, error) {
+	if err := b.prepare(&j); err != nil {
+		return nil, err
+	}
+	j.State = model.BackfillPending
+	id, err := b.st.InsertBackfillJob(context.Background(), j)
+	if err != nil {
+		return nil, err
+	}
+	j.ID = id
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+	logger.L().WithFields(logger.Fields{"job": j.ID, "symbol": j.Symbol, "from": j.From, "to": j.To}).Info("Backfill: queued")
+	return &j, nil
+}
+
+// RunNow проверяет задачу, записывает её сразу в состоянии running и выполняет в текущей горутине.
+// Итоговое состояние задачи возвращается и при ошибке.
+func (b *Backfiller) RunNow(ctx context.Context, j model.BackfillJob) (*model.BackfillJob, error) {
+	if err := b.prepare(&j); err != nil {
+		return nil, err
+	}
+	now := b.now().Unix()
+	j.State, j.StartedAt, j.UpdatedAt = model.BackfillRunning, now, now
+	id, err := b.st.InsertBackfillJob(ctx, j)
+	if err != nil {
+		return nil, err
+	}
+	j.ID = id
+	err = b.run(ctx, &j)
+	return &j, err
+}
+
+// prepare нормализует задачу: to по умолчанию — сейчас, курсор — в начало диапазона.
+func (b *Backfiller) prepare(j *model.BackfillJob) error {
+	now := b.now().Unix()
+	if j.To <= 0 || j.To > now {
+		j.To = now
+	}
+	switch {
+	case j.ProviderID == "":
+		return fmt.Errorf("%w: provider id for %q is unknown, pass provider_id", model.ErrInvalidBackfill, j.Symbol)
+	case j.From <= 0:
+		return fmt.Errorf("%w: from is required", model.ErrInvalidBackfill)
+	case j.From >= j.To:
+		return fmt.Errorf("%w: from must be before to and in the past", model.ErrInvalidBackfill)
+	}
+	j.Cursor, j.CreatedAt = j.From, now
+	return nil
+}
+
+// Start запускает воркер очереди до отмены ctx.
+func (b *Backfiller) Start(ctx context.Context) {
+	go func() {
+		t := time.NewTicker(b.opts.Poll)
+		defer t.Stop()
+		for {
+			for ctx.Err() == nil {
+				now := b.now()
+				j, err := b.st.ClaimBackfillJob(ctx, now.Unix(), now.Add(-b.opts.StaleAfter).Unix())
+				if err != nil {
+					logger.L().WithError(err).Error("Backfill: claim failed")
+					break
+				}
+				if j == nil {
+					break
+				}
+				_ = b.run(ctx, j)
+			}
+			select {
+			case <-t.C:
+			case <-b.wake:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	logger.L().Info("Backfill: start")
+}
+
+// Job — задача по id; nil, если такой нет.
+func (b *Backfiller) Job(id int64) (*model.BackfillJob, error) {
+	return b.st.GetBackfillJob(context.Background(), id)
+}
+
+// Jobs — задачи, новые сначала; symbol пустой — все.
+func (b *Backfiller) Jobs(symbol string, limit int) ([]model.BackfillJob, error) {
+	return b.st.ListBackfillJobs(context.Background(), symbol, limit)
+}
+
+// run проходит диапазон задачи кусками по opts.Chunk от Cursor до To.
+// Отмена ctx возвращает задачу в очередь (pending) с сохранённым курсором;
+// ошибка провайдера или БД — failed.
+func (b *Backfiller) run(ctx context.Context, j *model.BackfillJob) error {
+	log := logger.L().WithFields(logger.Fields{"job": j.ID, "symbol": j.Symbol, "quote": j.Quote})
+	log.WithField("cursor", j.Cursor).Info("Backfill: running")
+	for j.Cursor < j.To {
+		end := min(j.Cursor+int64(b.opts.Chunk/time.Second), j.To)
+		fetched, inserted, err := b.chunk(ctx, j, end)
+		if err != nil {
+			return b.stop(ctx, j, err)
+		}
+		j.Cursor = end
+		j.Fetched += fetched
+		j.Inserted += inserted
+		j.UpdatedAt = b.now().Unix()
+		if err := b.st.UpdateBackfillJob(ctx, *j); err != nil {
+			return b.stop(ctx, j, err)
+		}
+		log.WithFields(logger.Fields{"cursor": j.Cursor, "fetched": j.Fetched, "inserted": j.Inserted}).Info("Backfill: progress")
+	}
+	j.State, j.FinishedAt, j.UpdatedAt = model.BackfillDone, b.now().Unix(), b.now().Unix()
+	if err := b.st.UpdateBackfillJob(context.WithoutCancel(ctx), *j); err != nil {
+		return err
+	}
+	log.WithFields(logger.Fields{"fetched": j.Fetched, "inserted": j.Inserted}).Info("Backfill: done")
+	return nil
+}
+
+// chunk загружает [Cursor, end) (последний кусок — включая To) и пишет пачками по opts.Batch.
+func (b *Backfiller) chunk(ctx context.Context, j *model.BackfillJob, end int64) (fetched, inserted int, err error) {
+	pts, err := b.src.MarketChartRange(ctx, j.ProviderID, j.Quote, j.Cursor, end)
+	if err != nil {
+		return 0, 0, err
+	}
+	ps := make([]model.Price, 0, len(pts))
+	for _, p := range pts {
+		// граница соседних кусков достаётся только следующему
+		if p.TS < j.Cursor || p.TS > end || (p.TS == end && end < j.To) {
+			continue
+		}
+		ps = append(ps, model.Price{Symbol: j.Symbol, Quote: j.Quote, TS: p.TS, Price: p.Price, Sources: 1})
+	}
+	for i := 0; i < len(ps); i += b.opts.Batch {
+		n, err := b.st.InsertPrices(ctx, ps[i:min(i+b.opts.Batch, len(ps))])
+		if err != nil {
+			return 0, 0, err
+		}
+		inserted += n
+	}
+	return len(ps), inserted, nil
+}
+
+// stop записывает итог прерванной задачи: после отмены ctx — обратно в очередь, иначе — failed.
+// Цены недокачанного куска могли частично записаться; повтор куска их пропустит как дубли.
+func (b *Backfiller) stop(ctx context.Context, j *model.BackfillJob, cause error) error {
+	log := logger.L().WithFields(logger.Fields{"job": j.ID, "symbol": j.Symbol, "cursor": j.Cursor})
+	j.UpdatedAt = b.now().Unix()
+	if ctx.Err() != nil {
+		j.State = model.BackfillPending
+		log.Info("Backfill: interrupted, will resume")
+	} else {
+		j.State, j.Error, j.FinishedAt = model.BackfillFailed, cause.Error(), j.UpdatedAt
+		log.WithError(cause).Error("Backfill: failed")
+	}
+	if err := b.st.UpdateBackfillJob(context.WithoutCancel(ctx), *j); err != nil {
+		log.WithError(err).Error("Backfill: save state failed")
+	}
+	return cause
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/coingecko"
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memBackfill — backfill_jobs и prices в памяти; дубли цен по (symbol, quote, ts) не пишутся, как в БД.
+type memBackfill struct {
+	mu      sync.Mutex
+	seq     int64
+	jobs    map[int64]model.BackfillJob
+	prices  map[model.Price]bool // ключ — Price без цены
+	inserts int                  // вызовов InsertPrices
+	failOn  int                  // InsertPrices с этим номером вызова падает
+}
+
+func (m *memBackfill) InsertPrices(ctx context.Context, ps []model.Price) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inserts++
+	if m.inserts == m.failOn {
+		return 0, errors.New("db boom")
+	}
+	if m.prices == nil {
+		m.prices = make(map[model.Price]bool)
+	}
+	n := 0
+	for _, p := range ps {
+		k := model.Price{Symbol: p.Symbol, Quote: p.Quote, TS: p.TS}
+		if !m.prices[k] {
+			m.prices[k] = true
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memBackfill) InsertBackfillJob(ctx context.Context, j model.BackfillJob) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.jobs == nil {
+		m.jobs = make(map[int64]model.BackfillJob)
+	}
+	m.seq++
+	j.ID = m.seq
+	m.jobs[j.ID] = j
+	return j.ID, nil
+}
+
+func (m *memBackfill) UpdateBackfillJob(ctx context.Context, j model.BackfillJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[j.ID] = j
+	return nil
+}
+
+func (m *memBackfill) ClaimBackfillJob(ctx context.Context, now, staleBefore int64) (*model.BackfillJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]int64, 0, len(m.jobs))
+	for id := range m.jobs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		j := m.jobs[id]
+		if j.State == model.BackfillPending || (j.State == model.BackfillRunning && j.UpdatedAt < staleBefore) {
+			j.State, j.UpdatedAt = model.BackfillRunning, now
+			if j.StartedAt == 0 {
+				j.StartedAt = now
+			}
+			m.jobs[id] = j
+			return &j, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memBackfill) GetBackfillJob(ctx context.Context, id int64) (*model.BackfillJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &j, nil
+}
+
+func (m *memBackfill) ListBackfillJobs(ctx context.Context, symbol string, limit int) ([]model.BackfillJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []model.BackfillJob{}
+	for _, j := range m.jobs {
+		if symbol == "" || j.Symbol == symbol {
+			out = append(out, j)
+		}
+	}
+	return out, nil
+}
+
+func (m *memBackfill) job(id int64) model.BackfillJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.jobs[id]
+}
+
+// fakeChart отдаёт точку каждые step секунд внутри запрошенного [from, to], включая обе границы.
+type fakeChart struct {
+	mu    sync.Mutex
+	step  int64
+	calls [][2]int64
+	err   error
+	block chan struct{} // не nil — запрос ждёт его закрытия или отмены ctx
+}
+
+func (f *fakeChart) MarketChartRange(ctx context.Context, id, quote string, from, to int64) ([]coingecko.ChartPoint, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, [2]int64{from, to})
+	err, block := f.err, f.block
+	f.mu.Unlock()
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []coingecko.ChartPoint
+	for ts := (from + f.step - 1) / f.step * f.step; ts <= to; ts += f.step {
+		out = append(out, coingecko.ChartPoint{TS: ts, Price: model.NewDecimal(ts, 0)})
+	}
+	return out, nil
+}
+
+func newTestBackfiller(st *memBackfill, src *fakeChart) *Backfiller {
+	b := NewBackfiller(st, src, BackfillOptions{Chunk: 100 * time.Second, Batch: 3, Poll: 10 * time.Millisecond})
+	b.now = func() time.Time { return time.Unix(10_000, 0) }
+	return b
+}
+
+func TestBackfiller_RunNow_ChunksAndDedupes(t *testing.T) {
+	st := &memBackfill{}
+	// часть истории уже есть: коллектор писал в 1100 и 1150
+	_, _ = st.InsertPrices(context.Background(), []model.Price{{Symbol: "btc", Quote: "usd", TS: 1100}, {Symbol: "btc", Quote: "usd", TS: 1150}})
+	src := &fakeChart{step: 50}
+	b := newTestBackfiller(st, src)
+
+	j, err := b.RunNow(context.Background(), model.BackfillJob{Symbol: "btc", ProviderID: "bitcoin", Quote: "usd", From: 1000, To: 1250})
+	require.NoError(t, err)
+	require.Equal(t, [][2]int64{{1000, 1100}, {1100, 1200}, {1200, 1250}}, src.calls)
+
+	// 1000..1250 через 50 — 6 точек; граница 1100/1200 достаётся одному куску
+	require.Equal(t, model.BackfillDone, j.State)
+	require.Equal(t, 6, j.Fetched)
+	require.Equal(t, 4, j.Inserted, "1100 and 1150 were already stored")
+	require.Equal(t, int64(1250), j.Cursor)
+	require.Equal(t, *j, st.job(j.ID))
+	require.Equal(t, int64(10_000), j.FinishedAt)
+}
+
+func TestBackfiller_Validation(t *testing.T) {
+	b := newTestBackfiller(&memBackfill{}, &fakeChart{step: 1})
+	for name, j := range map[string]model.BackfillJob{
+		"no provider id": {Symbol: "btc", From: 1000},
+		"no from":        {Symbol: "btc", ProviderID: "bitcoin"},
+		"from after to":  {Symbol: "btc", ProviderID: "bitcoin", From: 2000, To: 1000},
+		"future":         {Symbol: "btc", ProviderID: "bitcoin", From: 20_000, To: 30_000},
+	} {
+		_, err := b.Submit(j)
+		require.ErrorIs(t, err, model.ErrInvalidBackfill, name)
+	}
+
+	// to в будущем обрезается до сейчас
+	j, err := b.Submit(model.BackfillJob{Symbol: "btc", ProviderID: "bitcoin", From: 1000, To: 99_999})
+	require.NoError(t, err)
+	require.Equal(t, int64(10_000), j.To)
+	require.Equal(t, model.BackfillPending, j.State)
+	require.Equal(t, int64(1000), j.Cursor)
+}
+
+func TestBackfiller_ProviderError_Fails(t *testing.T) {
+	st := &memBackfill{}
+	b := newTestBackfiller(st, &fakeChart{step: 50, err: errors.New("coingecko: unexpected status 401")})
+
+	j, err := b.RunNow(context.Background(), model.BackfillJob{Symbol: "btc", ProviderID: "bitcoin", Quote: "usd", From: 1000, To: 1250})
+	require.Error(t, err)
+	require.Equal(t, model.BackfillFailed, j.State)
+	require.Contains(t, j.Error, "401")
+	require.Equal(t, model.BackfillFailed, st.job(j.ID).State)
+}
+
+func TestBackfiller_DBError_KeepsProgress(t *testing.T) {
+	st := &memBackfill{failOn: 3} // первый кусок — две пачки, второй падает сразу
+	b := newTestBackfiller(st, &fakeChart{step: 20})
+
+	j, err := b.RunNow(context.Background(), model.BackfillJob{Symbol: "btc", ProviderID: "bitcoin", Quote: "usd", From: 1000, To: 1250})
+	require.Error(t, err)
+	require.Equal(t, model.BackfillFailed, j.State)
+	require.Equal(t, int64(1100), j.Cursor, "first chunk is saved")
+	require.Equal(t, 5, j.Inserted)
+}
+
+func TestBackfiller_Worker_RunsQueuedAndResumesAfterStop(t *testing.T) {
+	st := &memBackfill{}
+	src := &fakeChart{step: 50, block: make(chan struct{})}
+	b := newTestBackfiller(st, src)
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Start(ctx)
+
+	j, err := b.Submit(model.BackfillJob{Symbol: "btc", ProviderID: "bitcoin", Quote: "usd", From: 1000, To: 1250})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return st.job(j.ID).State == model.BackfillRunning }, time.Second, 5*time.Millisecond)
+
+	// остановка посреди запроса — задача возвращается в очередь с тем же курсором
+	cancel()
+	require.Eventually(t, func() bool { return st.job(j.ID).State == model.BackfillPending }, time.Second, 5*time.Millisecond)
+	require.Equal(t, int64(1000), st.job(j.ID).Cursor)
+	require.Empty(t, st.job(j.ID).Error)
+
+	// новый воркер доделывает
+	close(src.block)
+	b2 := newTestBackfiller(st, src)
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	b2.Start(ctx2)
+	require.Eventually(t, func() bool { return st.job(j.ID).State == model.BackfillDone }, time.Second, 5*time.Millisecond)
+	require.Equal(t, 6, st.job(j.ID).Inserted)
+}
+
+func TestService_StartBackfill(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	_, err := s.StartBackfill(model.BackfillReq{Symbol: "btc", From: 1000})
+	require.ErrorIs(t, err, model.ErrBackfillUnavailable)
+
+	st := &memBackfill{}
+	s.UseBackfill(newTestBackfiller(st, &fakeChart{step: 50}))
+
+	// btc есть во встроенной карте
+	j, err := s.StartBackfill(model.BackfillReq{Symbol: " BTC ", Quote: "EUR", From: 1000, To: 2000})
+	require.NoError(t, err)
+	require.Equal(t, "btc", j.Symbol)
+	require.Equal(t, "bitcoin", j.ProviderID)
+	require.Equal(t, "eur", j.Quote)
+
+	_, err = s.StartBackfill(model.BackfillReq{Symbol: "zzz", From: 1000})
+	require.ErrorIs(t, err, model.ErrInvalidBackfill, "unknown id without catalog")
+	j, err = s.StartBackfill(model.BackfillReq{Symbol: "zzz", ProviderID: "zzz-coin", From: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "zzz-coin", j.ProviderID)
+
+	_, err = s.StartBackfill(model.BackfillReq{Symbol: "btc", Quote: "e1", From: 1000})
+	require.ErrorIs(t, err, model.ErrInvalidQuote)
+
+	got, err := s.BackfillJob(1)
+	require.NoError(t, err)
+	require.Equal(t, "btc", got.Symbol)
+	_, err = s.BackfillJob(99)
+	require.ErrorIs(t, err, model.ErrBackfillNotFound)
+
+	list, err := s.BackfillJobs("ZZZ", 0)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+// Сколько тикеров максимум уходит в один запрос провайдера
+const maxBatchSymbols = 100
+
+// BatchPriceProvider — провайдер, который умеет отдать цены нескольких тикеров
+// в нескольких валютах за один запрос: symbol → quote → цена.
+type BatchPriceProvider interface {
+	GetPrices(ctx context.Context, symbols, quotes []string) (map[string]map[string]model.Decimal, error)
+}
+
+type batchResult struct {
+	price model.Decimal
+	err   error
+}
+
+// batchWaiter — коллектор, ждущий цену из окна, и его ctx.
+type batchWaiter struct {
+	ctx context.Context
+	ch  chan batchResult
+}
+
+// Batcher — общий планировщик запросов: коллекторы, которым пора за ценой
+// в пределах одного окна, получают её из одного пакетного запроса к провайдеру.
+// Снаружи выглядит как обычный PriceProvider, поэтому подключается через реестр.
+type Batcher struct {
+	ctx    context.Context // корневой: пакетные запросы отменяются вместе с ним
+	bp     BatchPriceProvider
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[string]map[string][]batchWaiter // symbol → quote → ожидающие
+}
+
+// NewBatcher — ctx ограничивает жизнь пакетных запросов (обычно живёт до остановки сервиса).
+func NewBatcher(ctx context.Context, bp BatchPriceProvider, window time.Duration) *Batcher {
+	return &Batcher{
+		ctx:     ctx,
+		bp:      bp,
+		window:  window,
+		pending: make(map[string]map[string][]batchWaiter),
+	}
+}
+
+// GetPrice ставит пару тикер/валюта в текущее окно и ждёт общего ответа.
+func (b *Batcher) GetPrice(ctx context.Context, symbol, quote string) (model.Decimal, error) {
+	ch := make(chan batchResult, 1)
+
+	b.mu.Lock()
+	if len(b.pending) == 0 {
+		// первый запрос в окне — заводим таймер отправки
+		time.AfterFunc(b.window, b.flush)
+	}
+	byQuote, ok := b.pending[symbol]
+	if !ok {
+		byQuote = make(map[string][]batchWaiter)
+		b.pending[symbol] = byQuote
+	}
+	byQuote[quote] = append(byQuote[quote], batchWaiter{ctx: ctx, ch: ch})
+	b.mu.Unlock()
+
+	select {
+	case r := <-ch:
+		return r.price, r.err
+	case <-ctx.Done():
+		return model.Decimal{}, ctx.Err()
+	}
+}
+
+// flush отправляет накопленное окно. Валюты объединяются: каждый пакет
+// запрашивает все валюты, которые кто-то ждал в этом окне.
+func (b *Batcher) flush() {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[string]map[string][]batchWaiter)
+	b.mu.Unlock()
+
+	symbols := make([]string, 0, len(pending))
+	quoteSet := make(map[string]struct{})
+	for sym, byQuote := range pending {
+		symbols = append(symbols, sym)
+		for q := range byQuote {
+			quoteSet[q] = struct{}{}
+		}
+	}
+	sort.Strings(symbols)
+	quotes := make([]string, 0, len(quoteSet))
+	for q := range quoteSet {
+		quotes = append(quotes, q)
+	}
+	sort.Strings(quotes)
+
+	for start := 0; start < len(symbols); start += maxBatchSymbols {
+		chunk := symbols[start:min(start+maxBatchSymbols, len(symbols))]
+		var waiters []batchWaiter
+		for _, sym := range chunk {
+			for _, ws := range pending[sym] {
+				waiters = append(waiters, ws...)
+			}
+		}
+		ctx, cancel := b.requestCtx(waiters)
+		prices, err := b.bp.GetPrices(ctx, chunk, quotes)
+		cancel()
+		if err != nil {
+			logger.L().WithError(err).WithField("symbols", len(chunk)).Error("Batcher: batch fetch failed")
+		}
+		for _, sym := range chunk {
+			for q, waiters := range pending[sym] {
+				r := batchResult{err: err}
+				if err == nil {
+					r.price, r.err = pick(prices, sym, q)
+				}
+				for _, w := range waiters {
+					w.ch <- r
+				}
+			}
+		}
+	}
+}
+
+// requestCtx — контекст пакетного запроса: дочерний от корневого и отменяется,
+// когда ctx всех ждущих этого пакета отменены — ответ уже никому не нужен.
+func (b *Batcher) requestCtx(waiters []batchWaiter) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(b.ctx)
+	var left atomic.Int32
+	left.Store(int32(len(waiters)))
+	stops := make([]func() bool, 0, len(waiters))
+	for _, w := range waiters {
+		stops = append(stops, context.AfterFunc(w.ctx, func() {
+			if left.Add(-1) == 0 {
+				cancel()
+			}
+		}))
+	}
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel()
+	}
+}
+
+func pick(prices map[string]map[string]model.Decimal, symbol, quote string) (model.Decimal, error) {
+	byQuote, ok := prices[symbol]
+	if !ok {
+		return model.Decimal{}, fmt.Errorf("batch: %w: %s", model.ErrUnknownCoin, symbol)
+	}
+	p, ok := byQuote[quote]
+	if !ok {
+		return model.Decimal{}, fmt.Errorf("batch: %w: %s/%s", model.ErrUnsupportedQuote, symbol, quote)
+	}
+	return p, nil
+}
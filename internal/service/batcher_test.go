@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBatchProvider отдаёт цены из prices (в USD, целые); остальные валюты — из quotes.
+type fakeBatchProvider struct {
+	mu     sync.Mutex
+	prices map[string]int64
+	quotes map[string]map[string]model.Decimal
+	err    error
+	calls  [][]string
+	qcalls [][]string
+}
+
+func (f *fakeBatchProvider) GetPrices(ctx context.Context, symbols, quotes []string) (map[string]map[string]model.Decimal, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, append([]string(nil), symbols...))
+	f.qcalls = append(f.qcalls, append([]string(nil), quotes...))
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := make(map[string]map[string]model.Decimal)
+	for _, s := range symbols {
+		if p, ok := f.prices[s]; ok {
+			out[s] = map[string]model.Decimal{"usd": model.NewDecimal(p, 0)}
+		}
+		for q, p := range f.quotes[s] {
+			if out[s] == nil {
+				out[s] = make(map[string]model.Decimal)
+			}
+			out[s][q] = p
+		}
+	}
+	return out, nil
+}
+
+// fetchAll параллельно запрашивает тикеры в USD через батчер и возвращает цены/ошибки по порядку
+func fetchAll(b *Batcher, symbols ...string) ([]string, []error) {
+	pairs := make([][2]string, len(symbols))
+	for i, s := range symbols {
+		pairs[i] = [2]string{s, "usd"}
+	}
+	return fetchPairs(b, pairs...)
+}
+
+// fetchPairs возвращает цены строками — так их удобно сравнивать
+func fetchPairs(b *Batcher, pairs ...[2]string) ([]string, []error) {
+	prices := make([]string, len(pairs))
+	errs := make([]error, len(pairs))
+	var wg sync.WaitGroup
+	for i, p := range pairs {
+		wg.Add(1)
+		go func(i int, sym, quote string) {
+			defer wg.Done()
+			p, err := b.GetPrice(context.Background(), sym, quote)
+			prices[i], errs[i] = p.String(), err
+		}(i, p[0], p[1])
+	}
+	wg.Wait()
+	return prices, errs
+}
+
+func TestBatcher_GroupsSymbolsInWindow(t *testing.T) {
+	bp := &fakeBatchProvider{prices: map[string]int64{"btc": 100, "eth": 20, "sol": 3}}
+	b := NewBatcher(context.Background(), bp, 50*time.Millisecond)
+
+	prices, errs := fetchAll(b, "btc", "eth", "sol", "btc")
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"100", "20", "3", "100"}, prices)
+	require.Len(t, bp.calls, 1, "all symbols in one window must share a request")
+	require.Equal(t, []string{"btc", "eth", "sol"}, bp.calls[0], "duplicates are requested once")
+}
+
+func TestBatcher_MissingSymbol(t *testing.T) {
+	bp := &fakeBatchProvider{prices: map[string]int64{"btc": 100}}
+	b := NewBatcher(context.Background(), bp, 20*time.Millisecond)
+
+	prices, errs := fetchAll(b, "btc", "nope")
+	require.NoError(t, errs[0])
+	require.Equal(t, "100", prices[0])
+	require.ErrorIs(t, errs[1], model.ErrUnknownCoin)
+}
+
+func TestBatcher_QuotesShareRequest(t *testing.T) {
+	bp := &fakeBatchProvider{
+		prices: map[string]int64{"btc": 100},
+		quotes: map[string]map[string]model.Decimal{"btc": {"eur": model.MustDecimal("90.5")}, "eth": {"eur": model.MustDecimal("0.000009")}},
+	}
+	b := NewBatcher(context.Background(), bp, 30*time.Millisecond)
+
+	prices, errs := fetchPairs(b, [2]string{"btc", "usd"}, [2]string{"btc", "eur"}, [2]string{"eth", "eur"}, [2]string{"eth", "usd"})
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	require.NoError(t, errs[2])
+	require.Equal(t, []string{"100", "90.5", "0.000009"}, prices[:3])
+	require.ErrorIs(t, errs[3], model.ErrUnsupportedQuote)
+	require.Len(t, bp.calls, 1)
+	require.Equal(t, []string{"eur", "usd"}, bp.qcalls[0], "quotes of one window are merged")
+}
+
+func TestBatcher_ProviderErrorFansOut(t *testing.T) {
+	bp := &fakeBatchProvider{err: errors.New("429")}
+	b := NewBatcher(context.Background(), bp, 20*time.Millisecond)
+
+	_, errs := fetchAll(b, "btc", "eth")
+	require.Error(t, errs[0])
+	require.Error(t, errs[1])
+}
+
+func TestBatcher_SeparateWindows(t *testing.T) {
+	bp := &fakeBatchProvider{prices: map[string]int64{"btc": 1}}
+	b := NewBatcher(context.Background(), bp, 10*time.Millisecond)
+
+	_, err := b.GetPrice(context.Background(), "btc", "usd")
+	require.NoError(t, err)
+	_, err = b.GetPrice(context.Background(), "btc", "usd")
+	require.NoError(t, err)
+	require.Len(t, bp.calls, 2)
+}
+
+func TestBatcher_ContextCancel(t *testing.T) {
+	b := NewBatcher(context.Background(), &fakeBatchProvider{}, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := b.GetPrice(ctx, "btc", "usd")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// blockingBatchProvider отвечает по release или по отмене ctx и запоминает ошибку ctx.
+type blockingBatchProvider struct {
+	entered chan struct{}
+	release chan struct{}
+	gotErr  chan error
+}
+
+func newBlockingBatchProvider() *blockingBatchProvider {
+	return &blockingBatchProvider{entered: make(chan struct{}), release: make(chan struct{}), gotErr: make(chan error, 1)}
+}
+
+func (p *blockingBatchProvider) GetPrices(ctx context.Context, symbols, quotes []string) (map[string]map[string]model.Decimal, error) {
+	close(p.entered)
+	select {
+	case <-ctx.Done():
+	case <-p.release:
+	}
+	p.gotErr <- ctx.Err()
+	return nil, errors.New("no prices")
+}
+
+func TestBatcher_CancelsRequestWhenAllWaitersGiveUp(t *testing.T) {
+	bp := newBlockingBatchProvider()
+	b := NewBatcher(context.Background(), bp, 10*time.Millisecond)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() { _, err := b.GetPrice(ctx1, "btc", "usd"); errs <- err }()
+	go func() { _, err := b.GetPrice(ctx2, "eth", "usd"); errs <- err }()
+	<-bp.entered
+
+	cancel1()
+	require.ErrorIs(t, <-errs, context.Canceled)
+	select {
+	case <-bp.gotErr:
+		t.Fatal("request canceled while another waiter still waits")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel2()
+	require.ErrorIs(t, <-errs, context.Canceled)
+	select {
+	case err := <-bp.gotErr:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("request outlived all its waiters")
+	}
+}
+
+func TestBatcher_RootContextCancelsRequest(t *testing.T) {
+	root, stop := context.WithCancel(context.Background())
+	bp := newBlockingBatchProvider()
+	b := NewBatcher(root, bp, 10*time.Millisecond)
+
+	errs := make(chan error, 1)
+	go func() { _, err := b.GetPrice(context.Background(), "btc", "usd"); errs <- err }()
+	<-bp.entered
+
+	stop()
+	require.ErrorIs(t, <-bp.gotErr, context.Canceled)
+	require.Error(t, <-errs, "waiter gets the failed batch")
+}
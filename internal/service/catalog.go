@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-observer/internal/coingecko"
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+// Сколько вариантов предлагать в ответе на неизвестный/неоднозначный тикер
+const maxSuggestions = 5
+
+type coinLister interface {
+	ListCoins(ctx context.Context) ([]coingecko.Coin, error)
+}
+
+// CoinCatalog — локальная копия справочника /coins/list с периодическим обновлением.
+// По нему AddCurrency проверяет тикер и определяет id монеты у провайдера.
+type CoinCatalog struct {
+	lister  coinLister
+	refresh time.Duration
+
+	mu       sync.RWMutex
+	byID     map[string]model.CoinRef
+	bySymbol map[string][]model.CoinRef
+}
+
+func NewCoinCatalog(lister coinLister, refresh time.Duration) *CoinCatalog {
+	return &CoinCatalog{lister: lister, refresh: refresh}
+}
+
+// Start загружает справочник и обновляет его каждые refresh до отмены ctx.
+// Ошибка первой загрузки не фатальна: пока справочник пуст, проверка пропускается.
+func (c *CoinCatalog) Start(ctx context.Context) {
+	if err := c.Refresh(ctx); err != nil {
+		logger.L().WithError(err).Warn("CoinCatalog: initial load failed")
+	}
+	go func() {
+		t := time.NewTicker(c.refresh)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if err := c.Refresh(ctx); err != nil {
+					logger.L().WithError(err).Warn("CoinCatalog: refresh failed")
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (c *CoinCatalog) Refresh(ctx context.Context) error {
+	coins, err := c.lister.ListCoins(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]model.CoinRef, len(coins))
+	bySymbol := make(map[string][]model.CoinRef, len(coins))
+	for _, cn := range coins {
+		ref := model.CoinRef{ID: cn.ID, Symbol: strings.ToLower(cn.Symbol), Name: cn.Name}
+		byID[ref.ID] = ref
+		bySymbol[ref.Symbol] = append(bySymbol[ref.Symbol], ref)
+	}
+
+	c.mu.Lock()
+	c.byID, c.bySymbol = byID, bySymbol
+	c.mu.Unlock()
+	logger.L().WithField("coins", len(byID)).Info("CoinCatalog: loaded")
+	return nil
+}
+
+// Known — есть ли такой id в справочнике; без справочника считаем, что есть.
+func (c *CoinCatalog) Known(id string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.byID) == 0 {
+		return true
+	}
+	_, ok := c.byID[id]
+	return ok
+}
+
+// Similar — монеты с похожим тикером или id, для подсказок
+func (c *CoinCatalog) Similar(q string) []model.CoinRef {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.similar(q)
+}
+
+// Loaded — есть ли в памяти справочник
+func (c *CoinCatalog) Loaded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.byID) > 0
+}
+
+// Resolve сопоставляет тикер монете. Порядок: явный providerID, совпадение с id,
+// единственная монета с таким тикером. Иначе — *model.SymbolError с вариантами.
+func (c *CoinCatalog) Resolve(symbol, providerID string) (string, error) {
+	sym := normSymbol(symbol)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if providerID != "" {
+		if _, ok := c.byID[providerID]; ok {
+			return providerID, nil
+		}
+		return "", &model.SymbolError{Symbol: providerID, Err: model.ErrUnknownCoin, Suggestions: c.similar(providerID)}
+	}
+	if _, ok := c.byID[sym]; ok {
+		return sym, nil
+	}
+	switch refs := c.bySymbol[sym]; len(refs) {
+	case 0:
+		return "", &model.SymbolError{Symbol: symbol, Err: model.ErrUnknownCoin, Suggestions: c.similar(sym)}
+	case 1:
+		return refs[0].ID, nil
+	default:
+		cands := append([]model.CoinRef(nil), refs...)
+		sort.Slice(cands, func(i, j int) bool { return cands[i].ID < cands[j].ID })
+		if len(cands) > maxSuggestions {
+			cands = cands[:maxSuggestions]
+		}
+		return "", &model.SymbolError{Symbol: symbol, Err: model.ErrAmbiguousSymbol, Suggestions: cands}
+	}
+}
+
+// similar подбирает монеты с похожим тикером или id (расстояние Левенштейна <= 1 / 2).
+func (c *CoinCatalog) similar(q string) []model.CoinRef {
+	type scored struct {
+		ref  model.CoinRef
+		dist int
+	}
+	var found []scored
+	for _, ref := range c.byID {
+		d := levenshtein(q, ref.Symbol)
+		if d > 1 {
+			if d = levenshtein(q, ref.ID); d > 2 {
+				continue
+			}
+		}
+		found = append(found, scored{ref, d})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].dist != found[j].dist {
+			return found[i].dist < found[j].dist
+		}
+		return found[i].ref.ID < found[j].ref.ID
+	})
+	out := make([]model.CoinRef, 0, maxSuggestions)
+	for i := 0; i < len(found) && i < maxSuggestions; i++ {
+		out = append(out, found[i].ref)
+	}
+	return out
+}
+
+func levenshtein(a, b string) int {
+	if a == b {
+		return 0
+	}
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"crypto-observer/internal/coingecko"
+	"crypto-observer/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLister struct {
+	coins []coingecko.Coin
+	err   error
+}
+
+func (f *fakeLister) ListCoins(ctx context.Context) ([]coingecko.Coin, error) {
+	return f.coins, f.err
+}
+
+func loadedCatalog(t *testing.T) *CoinCatalog {
+	t.Helper()
+	c := NewCoinCatalog(&fakeLister{coins: []coingecko.Coin{
+		{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin"},
+		{ID: "wrapped-bitcoin", Symbol: "wbtc", Name: "Wrapped Bitcoin"},
+		{ID: "uniswap", Symbol: "uni", Name: "Uniswap"},
+		{ID: "universe-token", Symbol: "UNI", Name: "Universe"},
+		{ID: "pepe", Symbol: "pepe", Name: "Pepe"},
+	}}, 0)
+	require.NoError(t, c.Refresh(context.Background()))
+	return c
+}
+
+func TestCoinCatalog_Resolve(t *testing.T) {
+	c := loadedCatalog(t)
+
+	cases := []struct {
+		symbol, providerID, want string
+	}{
+		{"btc", "", "bitcoin"},                      // встроенная карта
+		{"PEPE", "", "pepe"},                        // единственная монета с тикером
+		{"wrapped-bitcoin", "", "wrapped-bitcoin"},  // тикер совпал с id
+		{"uni", "universe-token", "universe-token"}, // явный id снимает неоднозначность
+	}
+	for _, tc := range cases {
+		got, err := c.Resolve(tc.symbol, tc.providerID)
+		require.NoError(t, err, tc.symbol)
+		require.Equal(t, tc.want, got, tc.symbol)
+	}
+}
+
+func TestCoinCatalog_Resolve_Ambiguous(t *testing.T) {
+	c := loadedCatalog(t)
+
+	_, err := c.Resolve("uni", "")
+	require.ErrorIs(t, err, model.ErrAmbiguousSymbol)
+
+	var se *model.SymbolError
+	require.True(t, errors.As(err, &se))
+	require.Equal(t, []string{"uniswap", "universe-token"}, []string{se.Suggestions[0].ID, se.Suggestions[1].ID})
+}
+
+func TestCoinCatalog_Resolve_UnknownWithSuggestions(t *testing.T) {
+	c := loadedCatalog(t)
+
+	_, err := c.Resolve("pepr", "")
+	require.ErrorIs(t, err, model.ErrUnknownCoin)
+
+	var se *model.SymbolError
+	require.True(t, errors.As(err, &se))
+	require.NotEmpty(t, se.Suggestions)
+	require.Equal(t, "pepe", se.Suggestions[0].ID)
+
+	_, err = c.Resolve("uni", "no-such-id")
+	require.ErrorIs(t, err, model.ErrUnknownCoin)
+}
+
+func TestCoinCatalog_RefreshError_KeepsOld(t *testing.T) {
+	l := &fakeLister{coins: []coingecko.Coin{{ID: "pepe", Symbol: "pepe"}}}
+	c := NewCoinCatalog(l, 0)
+	require.False(t, c.Loaded())
+	require.NoError(t, c.Refresh(context.Background()))
+
+	l.err = errors.New("429")
+	require.Error(t, c.Refresh(context.Background()))
+	require.True(t, c.Loaded())
+	got, err := c.Resolve("pepe", "")
+	require.NoError(t, err)
+	require.Equal(t, "pepe", got)
+}
+
+func TestLevenshtein(t *testing.T) {
+	require.Equal(t, 0, levenshtein("btc", "btc"))
+	require.Equal(t, 1, levenshtein("btcc", "btc"))
+	require.Equal(t, 2, levenshtein("eht", "eth"))
+	require.Equal(t, 3, levenshtein("", "abc"))
+}
+
+func TestService_AddCurrency_ValidatesAgainstCatalog(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+	s.UseCatalog(loadedCatalog(t))
+
+	err := s.AddCurrency(model.AddReq{Symbol: "uni", Period: 3600})
+	require.ErrorIs(t, err, model.ErrAmbiguousSymbol)
+	_, ok := s.collectors["uni"]
+	require.False(t, ok, "ambiguous symbol must not start a collector")
+	require.Empty(t, fs.watch)
+
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "uni", Period: 3600, ProviderID: "uniswap"}))
+	require.Equal(t, "uniswap", fs.watch["uni"].ProviderID)
+	require.Equal(t, "uniswap", s.symbols.CoingeckoID("uni"), "resolved id must be used for fetching")
+
+	_ = s.RemoveCurrency("uni")
+	sleepMS(20)
+}
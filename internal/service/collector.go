@@ -2,38 +2,84 @@ package service
 
 import (
 	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"crypto-observer/internal/metrics"
 	"crypto-observer/internal/model"
 	"crypto-observer/pkg/logger"
 )
 
-type priceClient interface {
-	GetPriceCents(ctx context.Context, symbol string) (int64, error)
-}
+// Состояние последней попытки коллектора
+const (
+	StatePending     = "pending"      // ещё не было ни одного тика
+	StateOK          = "ok"           // цена получена и сохранена
+	StateError       = "error"        // сбой провайдера или БД, повторим на следующем тике
+	StateUnknownCoin = "unknown_coin" // провайдер не знает монету — скорее всего опечатка в тикере
+)
 
 type storageIface interface {
-	SavePrice(ctx context.Context, p model.Price) error
+	SavePrice(ctx context.Context, p model.Price) (int64, error)
+}
+
+// publisher получает каждую сохранённую цену (Hub, правила оповещения); nil — никому не рассылаем.
+type publisher interface {
+	Publish(p model.Price)
 }
 
+// publisherFunc позволяет передать функцию как publisher.
+type publisherFunc func(p model.Price)
+
+func (f publisherFunc) Publish(p model.Price) { f(p) }
+
 type collector struct {
-	symbol string
-	every  time.Duration
-	st     storageIface
-	pc     priceClient
+	symbol   string
+	st       storageIface
+	pc       PriceProvider
+	provider string // имя провайдера в реестре, для /readyz
+	pub      publisher
+	onFail   func(state string, err error) // переход в error/unknown_coin; nil — не сообщаем
+	ctx      context.Context               // отмена — остановка и обрыв запроса к провайдеру
+	wg       *sync.WaitGroup               // nil — никто не ждёт остановки
+	stopCh   chan struct{}
+	resetCh  chan struct{} // поменялся период — перезавести таймер
+	run      atomic.Bool   // потокобезопасный флаг
 
-	stopCh chan struct{}
-	run    atomic.Bool // потокобезопасный флаг
+	// всё ниже читают HTTP-обработчики параллельно с тиками — только под mu
+	mu        sync.Mutex
+	quotes    []string
+	sched     schedule
+	plan      model.WatchItem // из чего собран sched: Period, Align, Cron, Windows
+	state     string
+	lastErr   error
+	failures  int       // неудачных тиков подряд
+	lastTick  time.Time // конец последнего тика
+	started   time.Time // последний запуск
+	fetched   time.Time // последний успешный запрос к провайдеру
+	saved     time.Time // последнее сохранение цены
+	lastPrice map[string]model.Decimal
 }
 
-func newCollector(symbol string, every time.Duration, st storageIface, pc priceClient) *collector {
+func newCollector(symbol string, quotes []string, every time.Duration, st storageIface, pc PriceProvider) *collector {
+	if len(quotes) == 0 {
+		quotes = []string{model.DefaultQuote}
+	}
 	return &collector{
-		symbol: symbol,
-		every:  every,
-		st:     st,
-		pc:     pc,
-		stopCh: make(chan struct{}, 1),
+		symbol:    symbol,
+		quotes:    quotes,
+		sched:     fixedSchedule{every: every},
+		plan:      model.WatchItem{Period: int(every / time.Second)},
+		st:        st,
+		pc:        pc,
+		ctx:       context.Background(),
+		stopCh:    make(chan struct{}, 1),
+		resetCh:   make(chan struct{}, 1),
+		state:     StatePending,
+		lastPrice: make(map[string]model.Decimal, len(quotes)),
 	}
 }
 
@@ -42,33 +88,264 @@ func (c *collector) Start() {
 		return
 	}
 	log := logger.L().WithField("symbol", c.symbol)
+	c.mu.Lock()
+	c.started = time.Now()
+	c.mu.Unlock()
+	metrics.CollectorsActive.Inc()
+	if c.wg != nil {
+		c.wg.Add(1)
+	}
 	go func() {
-		t := time.NewTicker(c.every)
+		if c.wg != nil {
+			defer c.wg.Done()
+		}
+		// prev — плановое время предыдущего тика (или запуска), next — следующего;
+		// в БД пишется именно плановое время, а не момент, когда провайдер ответил.
+		prev := time.Now()
+		next := c.schedule().next(prev, prev)
+		t := time.NewTimer(time.Hour)
+		rearm(t, next)
 		defer t.Stop()
+		defer metrics.CollectorsActive.Dec()
 		defer log.Info("Collector: stop")
 		for {
 			select {
 			case <-t.C:
-				price, err := c.pc.GetPriceCents(context.Background(), toCoingeckoID(c.symbol))
-				if err != nil {
-					log.WithError(err).Error("Collector: fetch failed")
-					continue
+				if !c.run.Load() {
+					return // Stop пришёл во время тика, а таймер уже сработал
 				}
-				p := model.Price{Symbol: c.symbol, TS: time.Now().Unix(), Price: price}
-				if err := c.st.SavePrice(context.Background(), p); err != nil {
-					log.WithError(err).Error("Collector: save failed")
+				err := c.tickAt(next)
+				if c.ctx.Err() != nil {
+					// остановка сервиса посреди тика — не сбой коллектора, onFail не зовём
+					c.run.Store(false)
+					return
 				}
+				c.setResult(err)
+				prev, next = next, c.schedule().next(next, time.Now())
+				rearm(t, next)
+			case <-c.resetCh:
+				// расписание поменялось — продолжаем его от предыдущего тика,
+				// так что следующий будет не позже чем через новый период
+				next = c.schedule().next(prev, time.Now())
+				rearm(t, next)
 			case <-c.stopCh:
 				c.run.Store(false)
 				return
+			case <-c.ctx.Done():
+				c.run.Store(false)
+				return
 			}
 		}
 	}()
 	log.Info("Collector: start")
 }
 
+// rearm заводит таймер на next. Нулевое next — расписание больше не срабатывает:
+// таймер останавливается, коллектор ждёт смены расписания или остановки.
+// Reset и Stop (Go 1.23+) отбрасывают и несработавший, и уже сработавший таймер.
+func rearm(t *time.Timer, next time.Time) {
+	if next.IsZero() {
+		t.Stop()
+		return
+	}
+	t.Reset(time.Until(next))
+}
+
+// tick — один цикл: получить цены во всех валютах котировки и сохранить.
+// Валюты запрашиваются параллельно, чтобы попасть в одно окно Batcher.
+// Пустую/нулевую цену не пишем; ошибки по валютам объединяются.
+func (c *collector) tick() error { return c.tickAt(time.Now()) }
+
+// tickAt — тик с плановым временем at: оно становится ts всех сохранённых цен.
+func (c *collector) tickAt(at time.Time) error {
+	ts := at.Unix()
+	quotes := c.quoteList()
+	errs := make([]error, len(quotes))
+
+	var wg sync.WaitGroup
+	for i, quote := range quotes {
+		wg.Add(1)
+		go func(i int, quote string) {
+			defer wg.Done()
+			errs[i] = c.tickQuote(ts, quote)
+		}(i, quote)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (c *collector) tickQuote(ts int64, quote string) error {
+	log := logger.L().WithFields(logger.Fields{"symbol": c.symbol, "quote": quote})
+
+	start := time.Now()
+	q, err := fetchQuote(c.ctx, c.pc, c.symbol, quote)
+	metrics.CollectorFetchSeconds.WithLabelValues(c.symbol).Observe(time.Since(start).Seconds())
+	if err == nil && q.Price.Sign() <= 0 {
+		err = model.ErrZeroPrice
+	}
+	if err != nil && c.ctx.Err() != nil {
+		return err
+	}
+	if err != nil {
+		metrics.CollectorErrors.WithLabelValues(c.symbol, metrics.StageFetch).Inc()
+		log.WithError(err).Error("Collector: fetch failed")
+		return err
+	}
+	c.touch(&c.fetched)
+	p := model.Price{
+		Symbol:  c.symbol,
+		Quote:   quote,
+		TS:      ts,
+		Price:   q.Price,
+		Sources: q.Sources,
+		Spread:  q.Spread,
+	}
+	// цена уже получена — сохраняем её и при остановке сервиса
+	id, err := c.st.SavePrice(context.WithoutCancel(c.ctx), p)
+	if err != nil {
+		metrics.CollectorErrors.WithLabelValues(c.symbol, metrics.StageSave).Inc()
+		log.WithError(err).Error("Collector: save failed")
+		return err
+	}
+	p.ID = id
+	c.mu.Lock()
+	c.saved = time.Now()
+	c.lastPrice[quote] = q.Price
+	c.mu.Unlock()
+	metrics.CollectorLastSuccess.WithLabelValues(c.symbol).SetToCurrentTime()
+	if c.pub != nil {
+		c.pub.Publish(p)
+	}
+	return nil
+}
+
+// setResult запоминает итог тика. О переходе в состояние ошибки сообщает onFail —
+// один раз на переход, а не на каждый неудачный тик.
+func (c *collector) setResult(err error) {
+	c.mu.Lock()
+	prev := c.state
+	c.lastErr = err
+	c.lastTick = time.Now()
+	switch {
+	case err == nil:
+		c.state = StateOK
+		c.failures = 0
+	case errors.Is(err, model.ErrUnknownCoin):
+		c.state = StateUnknownCoin
+	default:
+		c.state = StateError
+	}
+	if err != nil {
+		c.failures++
+	}
+	state := c.state
+	c.mu.Unlock()
+
+	if err != nil && state != prev && c.onFail != nil {
+		c.onFail(state, err)
+	}
+}
+
+// Status — состояние последнего тика и его ошибка (nil, если всё хорошо)
+func (c *collector) Status() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state, c.lastErr
+}
+
+// period — номинальный период сейчас (см. schedule.period).
+func (c *collector) period() time.Duration { return c.schedule().period(time.Now()) }
+
+func (c *collector) schedule() schedule {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sched
+}
+
+// schedulePlan — настройки расписания, из которых собран текущий sched.
+func (c *collector) schedulePlan() model.WatchItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.plan
+}
+
+func (c *collector) quoteList() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.quotes
+}
+
+// SetSchedule меняет расписание на лету; plan — настройки, из которых собран sched.
+// Состояние и последние цены не сбрасываются.
+func (c *collector) SetSchedule(plan model.WatchItem, sched schedule) {
+	c.mu.Lock()
+	c.plan, c.sched = plan, sched
+	c.mu.Unlock()
+	select {
+	case c.resetCh <- struct{}{}:
+	default:
+	}
+}
+
+// SetQuotes меняет валюты котировки со следующего тика.
+// Цены по убранным валютам из снимка состояния исчезают.
+func (c *collector) SetQuotes(quotes []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.quotes = quotes
+	for q := range c.lastPrice {
+		if !slices.Contains(quotes, q) {
+			delete(c.lastPrice, q)
+		}
+	}
+}
+
+// touch отмечает текущее время в одном из полей активности.
+func (c *collector) touch(at *time.Time) {
+	now := time.Now()
+	c.mu.Lock()
+	*at = now
+	c.mu.Unlock()
+}
+
+// info — согласованный снимок состояния коллектора для API.
+func (c *collector) info() model.CollectorStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := model.CollectorStatus{
+		Symbol:    c.symbol,
+		Running:   c.Running(),
+		State:     c.state,
+		PeriodSec: int64(c.sched.period(time.Now()) / time.Second),
+		Aligned:   c.plan.Align,
+		Cron:      c.plan.Cron,
+		Windows:   c.plan.Windows,
+		Quotes:    slices.Clone(c.quotes),
+		Failures:  c.failures,
+	}
+	if c.lastErr != nil {
+		st.Error = c.lastErr.Error()
+	}
+	if !c.lastTick.IsZero() {
+		st.LastFetch = c.lastTick.Unix()
+	}
+	if len(c.lastPrice) > 0 {
+		st.LastPrice = maps.Clone(c.lastPrice)
+	}
+	return st
+}
+
+// activity — время запуска, последнего успешного запроса к провайдеру и последнего сохранения.
+func (c *collector) activity() (started, fetched, saved time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.started, c.fetched, c.saved
+}
+
+// Stop помечает коллектор остановленным сразу, так что Running() == false ещё до конца
+// идущего тика; горутина выходит, закончив его.
 func (c *collector) Stop() {
-	if !c.run.Load() {
+	if !c.run.CompareAndSwap(true, false) {
 		return
 	}
 	select {
@@ -3,14 +3,17 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"crypto-observer/internal/metrics"
 	"crypto-observer/internal/model"
 
 	"crypto-observer/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,12 +22,12 @@ func init() {
 }
 
 type fakePriceClient struct {
-	val   int64
+	val   model.Decimal
 	err   error
 	calls int32
 }
 
-func (f *fakePriceClient) GetPriceCents(ctx context.Context, symbol string) (int64, error) {
+func (f *fakePriceClient) GetPrice(ctx context.Context, symbol, quote string) (model.Decimal, error) {
 	atomic.AddInt32(&f.calls, 1)
 	return f.val, f.err
 }
@@ -36,12 +39,12 @@ type memStorage struct {
 	count int32
 }
 
-func (m *memStorage) SavePrice(ctx context.Context, p model.Price) error {
-	atomic.AddInt32(&m.count, 1)
+func (m *memStorage) SavePrice(ctx context.Context, p model.Price) (int64, error) {
+	id := atomic.AddInt32(&m.count, 1)
 	m.mu.Lock()
 	m.last = p
 	m.mu.Unlock()
-	return m.err
+	return int64(id), m.err
 }
 
 // ----- helpers -----
@@ -52,9 +55,9 @@ func wait(ms int) { time.Sleep(time.Duration(ms) * time.Millisecond) }
 
 func TestCollector_StartAndStop_OK(t *testing.T) {
 	st := &memStorage{}
-	pc := &fakePriceClient{val: 12345}
+	pc := &fakePriceClient{val: model.NewDecimal(12345, 0)}
 
-	c := newCollector("btc", 60*time.Millisecond, st, pc)
+	c := newCollector("btc", nil, 60*time.Millisecond, st, pc)
 
 	require.False(t, c.Running(), "should be not running before start")
 	c.Start()
@@ -78,9 +81,9 @@ func TestCollector_StartAndStop_OK(t *testing.T) {
 
 func TestCollector_DoubleStart_NoPanic(t *testing.T) {
 	st := &memStorage{}
-	pc := &fakePriceClient{val: 7}
+	pc := &fakePriceClient{val: model.NewDecimal(7, 0)}
 
-	c := newCollector("eth", 50*time.Millisecond, st, pc)
+	c := newCollector("eth", nil, 50*time.Millisecond, st, pc)
 
 	// второй старт должен быть проигнорирован и не паниковать
 	c.Start()
@@ -97,21 +100,21 @@ func TestCollector_FetchError(t *testing.T) {
 	st := &memStorage{}
 	pc := &fakePriceClient{err: errors.New("boom")}
 
-	c := newCollector("btc", 50*time.Millisecond, st, pc)
+	c := newCollector("btc", nil, 50*time.Millisecond, st, pc)
 	c.Start()
 	wait(140)
 	c.Stop()
 	wait(30)
 
 	require.Equal(t, int32(0), atomic.LoadInt32(&st.count), "SavePrice shouldn't be called when fetch fails")
-	require.GreaterOrEqual(t, atomic.LoadInt32(&pc.calls), int32(1), "GetPriceCents must be attempted")
+	require.GreaterOrEqual(t, atomic.LoadInt32(&pc.calls), int32(1), "GetPrice must be attempted")
 }
 
 func TestCollector_SaveError_StillTicks(t *testing.T) {
 	st := &memStorage{err: errors.New("db-fail")}
-	pc := &fakePriceClient{val: 999}
+	pc := &fakePriceClient{val: model.NewDecimal(999, 0)}
 
-	c := newCollector("btc", 50*time.Millisecond, st, pc)
+	c := newCollector("btc", nil, 50*time.Millisecond, st, pc)
 	c.Start()
 	wait(140)
 	c.Stop()
@@ -123,8 +126,8 @@ func TestCollector_SaveError_StillTicks(t *testing.T) {
 
 func TestCollector_RunningFlagTransitions(t *testing.T) {
 	st := &memStorage{}
-	pc := &fakePriceClient{val: 1}
-	c := newCollector("btc", 80*time.Millisecond, st, pc)
+	pc := &fakePriceClient{val: model.NewDecimal(1, 0)}
+	c := newCollector("btc", nil, 80*time.Millisecond, st, pc)
 
 	require.False(t, c.Running())
 	c.Start()
@@ -133,3 +136,205 @@ func TestCollector_RunningFlagTransitions(t *testing.T) {
 	wait(30)
 	require.False(t, c.Running())
 }
+
+func TestCollector_ZeroPrice_NotSaved(t *testing.T) {
+	st := &memStorage{}
+	pc := &fakePriceClient{val: model.NewDecimal(0, 0)}
+	c := newCollector("btc", nil, 40*time.Millisecond, st, pc)
+
+	c.Start()
+	wait(100)
+	c.Stop()
+	wait(30)
+
+	require.Equal(t, int32(0), atomic.LoadInt32(&st.count), "zero price must not be saved")
+	state, err := c.Status()
+	require.Equal(t, StateError, state)
+	require.ErrorIs(t, err, model.ErrZeroPrice)
+}
+
+func TestCollector_UnknownCoin_State(t *testing.T) {
+	st := &memStorage{}
+	pc := &fakePriceClient{err: fmt.Errorf("coingecko: %w: btcc", model.ErrUnknownCoin)}
+	c := newCollector("btcc", nil, 40*time.Millisecond, st, pc)
+
+	state, _ := c.Status()
+	require.Equal(t, StatePending, state)
+
+	c.Start()
+	wait(100)
+	c.Stop()
+	wait(30)
+
+	require.Equal(t, int32(0), atomic.LoadInt32(&st.count))
+	state, err := c.Status()
+	require.Equal(t, StateUnknownCoin, state)
+	require.ErrorIs(t, err, model.ErrUnknownCoin)
+}
+
+func TestCollector_RecoversToOK(t *testing.T) {
+	c := newCollector("btc", nil, time.Hour, &memStorage{}, &fakePriceClient{val: model.NewDecimal(5, 0)})
+	c.setResult(errors.New("boom"))
+	c.setResult(c.tick())
+
+	state, err := c.Status()
+	require.Equal(t, StateOK, state)
+	require.NoError(t, err)
+}
+
+func TestCollector_SavesEveryQuote(t *testing.T) {
+	st := &fakeStorage{}
+	c := newCollector("btc", []string{"usd", "eur"}, time.Hour, st, &fakePriceClient{val: model.NewDecimal(5, 0)})
+
+	require.NoError(t, c.tick())
+	require.Equal(t, 2, st.saveCalls, "one row per quote")
+}
+
+func TestCollector_Metrics(t *testing.T) {
+	fetchErrs := metrics.CollectorErrors.WithLabelValues("mtr", metrics.StageFetch)
+	saveErrs := metrics.CollectorErrors.WithLabelValues("mtr", metrics.StageSave)
+	pc := &fakePriceClient{err: errors.New("boom")}
+	st := &memStorage{}
+	c := newCollector("mtr", nil, time.Hour, st, pc)
+
+	_ = c.tick()
+	require.Equal(t, 1.0, testutil.ToFloat64(fetchErrs))
+	require.Zero(t, testutil.ToFloat64(metrics.CollectorLastSuccess.WithLabelValues("mtr")))
+
+	pc.err, pc.val = nil, model.NewDecimal(1, 0)
+	st.err = errors.New("db down")
+	_ = c.tick()
+	require.Equal(t, 1.0, testutil.ToFloat64(saveErrs))
+
+	st.err = nil
+	_ = c.tick()
+	require.InDelta(t, float64(time.Now().Unix()), testutil.ToFloat64(metrics.CollectorLastSuccess.WithLabelValues("mtr")), 2)
+	require.Equal(t, 1.0, testutil.ToFloat64(fetchErrs), "success does not touch error counters")
+}
+
+func TestCollector_Info_TracksFailuresAndLastPrice(t *testing.T) {
+	st := &memStorage{}
+	pc := &fakePriceClient{val: model.MustDecimal("70000.5")}
+	c := newCollector("btc", []string{"usd", "eur"}, time.Minute, st, pc)
+
+	info := c.info()
+	require.Equal(t, StatePending, info.State)
+	require.Equal(t, int64(60), info.PeriodSec)
+	require.Equal(t, []string{"usd", "eur"}, info.Quotes)
+	require.Zero(t, info.LastFetch)
+	require.Nil(t, info.LastPrice)
+
+	c.setResult(c.tick())
+	pc.err = errors.New("boom")
+	c.setResult(c.tick())
+	c.setResult(c.tick())
+
+	info = c.info()
+	require.Equal(t, StateError, info.State)
+	require.Equal(t, 2, info.Failures)
+	require.Contains(t, info.Error, "boom")
+	require.NotZero(t, info.LastFetch)
+	require.Equal(t, "70000.5", info.LastPrice["eur"].String(), "last saved price survives failures")
+
+	pc.err = nil
+	c.setResult(c.tick())
+	require.Zero(t, c.info().Failures, "reset on success")
+}
+
+// info читается из HTTP-обработчиков параллельно с тиками (ловится go test -race)
+func TestCollector_Info_ConcurrentWithTicks(t *testing.T) {
+	c := newCollector("btc", nil, 5*time.Millisecond, &memStorage{}, &fakePriceClient{val: model.NewDecimal(1, 0)})
+	c.Start()
+	defer c.Stop()
+	for i := 0; i < 50; i++ {
+		_ = c.info()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCollector_SetPeriod_Live(t *testing.T) {
+	st := &memStorage{}
+	c := newCollector("btc", nil, time.Hour, st, &fakePriceClient{val: model.NewDecimal(1, 0)})
+	c.Start()
+	defer c.Stop()
+
+	wait(30)
+	require.Zero(t, atomic.LoadInt32(&st.count), "hourly collector has not ticked yet")
+
+	// расписание продолжается от запуска: первый тик не позже чем через 20мс, дальше каждые 20мс
+	c.SetSchedule(model.WatchItem{}, fixedSchedule{every: 20 * time.Millisecond})
+	wait(110)
+	require.GreaterOrEqual(t, atomic.LoadInt32(&st.count), int32(3))
+	require.True(t, c.Running())
+
+	c.SetSchedule(model.WatchItem{Period: 3600}, fixedSchedule{every: time.Hour})
+	wait(20)
+	n := atomic.LoadInt32(&st.count)
+	wait(60)
+	require.Equal(t, n, atomic.LoadInt32(&st.count), "back to hourly")
+}
+
+func TestCollector_SetQuotes_DropsStalePrices(t *testing.T) {
+	c := newCollector("btc", []string{"usd", "eur"}, time.Hour, &memStorage{}, &fakePriceClient{val: model.NewDecimal(1, 0)})
+	require.NoError(t, c.tick())
+	c.SetQuotes([]string{"usd", "gbp"})
+
+	info := c.info()
+	require.Equal(t, []string{"usd", "gbp"}, info.Quotes)
+	require.Contains(t, info.LastPrice, "usd")
+	require.NotContains(t, info.LastPrice, "eur")
+}
+
+// timedStorage запоминает ts всех сохранённых цен.
+type timedStorage struct {
+	mu  sync.Mutex
+	tss []int64
+}
+
+func (s *timedStorage) SavePrice(ctx context.Context, p model.Price) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tss = append(s.tss, p.TS)
+	return int64(len(s.tss)), nil
+}
+
+func TestCollector_Aligned_StoresScheduledTimestamp(t *testing.T) {
+	st := &timedStorage{}
+	c := newCollector("btc", nil, time.Second, st, &fakePriceClient{val: model.NewDecimal(1, 0)})
+	c.sched = fixedSchedule{every: time.Second, align: true}
+	c.Start()
+	defer c.Stop()
+
+	require.Eventually(t, func() bool {
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		return len(st.tss) >= 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	// секундные границы: ts идут подряд, без дрейфа и пропусков
+	require.Equal(t, st.tss[0]+1, st.tss[1])
+}
+
+// doneSchedule — расписание, у которого больше нет срабатываний.
+type doneSchedule struct{}
+
+func (doneSchedule) next(prev, now time.Time) time.Time { return time.Time{} }
+func (doneSchedule) period(time.Time) time.Duration     { return 0 }
+
+func TestCollector_ScheduleWithoutTicks_Waits(t *testing.T) {
+	st := &memStorage{}
+	c := newCollector("btc", nil, time.Hour, st, &fakePriceClient{val: model.NewDecimal(1, 0)})
+	c.sched = doneSchedule{}
+	c.Start()
+	defer c.Stop()
+
+	wait(30)
+	require.Zero(t, atomic.LoadInt32(&st.count), "no ticks at zero time")
+	require.True(t, c.Running())
+
+	// смена расписания будит коллектор
+	c.SetSchedule(model.WatchItem{}, fixedSchedule{every: 10 * time.Millisecond})
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&st.count) > 0 }, time.Second, 5*time.Millisecond)
+}
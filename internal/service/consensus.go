@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+// Способы свести котировки нескольких источников в одну цену
+const (
+	ConsensusMedian      = "median"
+	ConsensusTrimmedMean = "trimmed_mean"
+)
+
+// Quote — итоговая цена вместе с числом источников, которые в неё вошли,
+// и разбросом (max - min) между ними.
+type Quote struct {
+	Price   model.Decimal
+	Sources int
+	Spread  model.Decimal
+}
+
+// quoteProvider — опциональное расширение PriceProvider для провайдеров,
+// которые умеют отдавать не только цену, но и метаданные консенсуса.
+type quoteProvider interface {
+	GetQuote(ctx context.Context, symbol, quote string) (Quote, error)
+}
+
+// fetchQuote спрашивает провайдера; обычный PriceProvider даёт Quote из одного источника.
+func fetchQuote(ctx context.Context, p PriceProvider, symbol, quote string) (Quote, error) {
+	if qp, ok := p.(quoteProvider); ok {
+		return qp.GetQuote(ctx, symbol, quote)
+	}
+	price, err := p.GetPrice(ctx, symbol, quote)
+	if err != nil {
+		return Quote{}, err
+	}
+	return Quote{Price: price, Sources: 1}, nil
+}
+
+type ConsensusOptions struct {
+	Method          string  // median|trimmed_mean (среднее без n/4 крайних котировок с каждой стороны); пусто — median
+	MinSources      int     // минимум согласных источников; <= 0 — 1
+	MaxDeviationPct float64 // котировки дальше этого % от медианы отбрасываются; <= 0 — без отсева
+}
+
+// Consensus опрашивает несколько провайдеров параллельно, отсекает выбросы
+// относительно медианы и сводит оставшиеся котировки в одну цену.
+type Consensus struct {
+	names   []string
+	members []PriceProvider
+	opts    ConsensusOptions
+}
+
+func NewConsensus(members map[string]PriceProvider, opts ConsensusOptions) (*Consensus, error) {
+	if len(members) == 0 {
+		return nil, fmt.Errorf("consensus: no providers")
+	}
+	switch opts.Method {
+	case "":
+		opts.Method = ConsensusMedian
+	case ConsensusMedian, ConsensusTrimmedMean:
+	default:
+		return nil, fmt.Errorf("consensus: unknown method %q", opts.Method)
+	}
+	if opts.MinSources <= 0 {
+		opts.MinSources = 1
+	}
+	if opts.MinSources > len(members) {
+		return nil, fmt.Errorf("consensus: min_sources %d exceeds %d providers", opts.MinSources, len(members))
+	}
+
+	c := &Consensus{opts: opts}
+	for name := range members {
+		c.names = append(c.names, name)
+	}
+	sort.Strings(c.names)
+	for _, name := range c.names {
+		c.members = append(c.members, members[name])
+	}
+	return c, nil
+}
+
+func (c *Consensus) GetPrice(ctx context.Context, symbol, quote string) (model.Decimal, error) {
+	q, err := c.GetQuote(ctx, symbol, quote)
+	return q.Price, err
+}
+
+func (c *Consensus) GetQuote(ctx context.Context, symbol, quote string) (Quote, error) {
+	prices := make([]model.Decimal, len(c.members))
+	errs := make([]error, len(c.members))
+
+	var wg sync.WaitGroup
+	for i, p := range c.members {
+		wg.Add(1)
+		go func(i int, p PriceProvider) {
+			defer wg.Done()
+			prices[i], errs[i] = p.GetPrice(ctx, symbol, quote)
+		}(i, p)
+	}
+	wg.Wait()
+
+	var (
+		ok      []model.Decimal
+		unknown int
+	)
+	for i, err := range errs {
+		if errors.Is(err, model.ErrUnknownCoin) {
+			unknown++
+		}
+		if err != nil {
+			logger.L().WithError(err).WithFields(logger.Fields{
+				"symbol":   symbol,
+				"quote":    quote,
+				"provider": c.names[i],
+			}).Warn("Consensus: source failed")
+			continue
+		}
+		if prices[i].Sign() <= 0 {
+			continue
+		}
+		ok = append(ok, prices[i])
+	}
+
+	if unknown == len(c.members) {
+		return Quote{}, fmt.Errorf("consensus: %w: %s", model.ErrUnknownCoin, symbol)
+	}
+	accepted := rejectOutliers(ok, c.opts.MaxDeviationPct)
+	if len(accepted) < c.opts.MinSources {
+		return Quote{}, fmt.Errorf("consensus: %d of %d sources usable for %s/%s, need %d",
+			len(accepted), len(c.members), symbol, quote, c.opts.MinSources)
+	}
+
+	q := Quote{Sources: len(accepted), Spread: accepted[len(accepted)-1].Sub(accepted[0])}
+	if c.opts.Method == ConsensusTrimmedMean {
+		q.Price = trimmedMean(accepted)
+	} else {
+		q.Price = median(accepted)
+	}
+	return q, nil
+}
+
+// rejectOutliers оставляет котировки в пределах maxDevPct от медианы; результат отсортирован.
+// Сами цены не округляются: float64 нужен только для процента отклонения.
+func rejectOutliers(prices []model.Decimal, maxDevPct float64) []model.Decimal {
+	sorted := append([]model.Decimal(nil), prices...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	if len(sorted) == 0 || maxDevPct <= 0 {
+		return sorted
+	}
+	m := median(sorted).Float64()
+	out := sorted[:0]
+	for _, p := range sorted {
+		dev := (p.Float64() - m) / m * 100
+		if dev < 0 {
+			dev = -dev
+		}
+		if dev <= maxDevPct {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// trimmedMean — среднее без k = n/4 самых низких и самых высоких котировок:
+// при 4–7 источниках отбрасывается по одной с каждой стороны, при 8–11 — по две.
+// Ожидает отсортированный непустой срез.
+func trimmedMean(sorted []model.Decimal) model.Decimal {
+	k := len(sorted) / 4
+	return model.Mean(sorted[k : len(sorted)-k]...)
+}
+
+// median ожидает отсортированный непустой срез
+func median(sorted []model.Decimal) model.Decimal {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return model.Mean(sorted[n/2-1], sorted[n/2])
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func consensusOf(t *testing.T, opts ConsensusOptions, vals ...any) *Consensus {
+	t.Helper()
+	members := make(map[string]PriceProvider, len(vals))
+	for i, v := range vals {
+		f := &fakePriceClient{}
+		switch x := v.(type) {
+		case int:
+			f.val = model.NewDecimal(int64(x), 0)
+		case string:
+			f.val = model.MustDecimal(x)
+		case error:
+			f.err = x
+		}
+		members[string(rune('a'+i))] = f
+	}
+	c, err := NewConsensus(members, opts)
+	require.NoError(t, err)
+	return c
+}
+
+func TestConsensus_Median_RejectsOutlier(t *testing.T) {
+	c := consensusOf(t, ConsensusOptions{MaxDeviationPct: 5}, 10000, 10100, 9900, 50000)
+
+	q, err := c.GetQuote(context.Background(), "btc", "usd")
+	require.NoError(t, err)
+	require.Equal(t, model.NewDecimal(10000, 0), q.Price)
+	require.Equal(t, 3, q.Sources, "outlier must not be counted")
+	require.Equal(t, model.NewDecimal(200, 0), q.Spread)
+}
+
+func TestConsensus_TrimmedMean(t *testing.T) {
+	c := consensusOf(t, ConsensusOptions{Method: ConsensusTrimmedMean, MaxDeviationPct: 5}, 100, 102, 104, 1)
+
+	q, err := c.GetQuote(context.Background(), "btc", "usd")
+	require.NoError(t, err)
+	require.Equal(t, model.NewDecimal(102, 0), q.Price, "three sources: nothing to trim")
+	require.Equal(t, 3, q.Sources)
+
+	// восемь источников без отсева: по две крайние котировки с каждой стороны не входят в среднее
+	c = consensusOf(t, ConsensusOptions{Method: ConsensusTrimmedMean}, 90, 101, 102, 103, 104, 105, 106, 200)
+	q, err = c.GetQuote(context.Background(), "btc", "usd")
+	require.NoError(t, err)
+	require.Equal(t, "103.5", q.Price.String())
+	require.Equal(t, 8, q.Sources)
+}
+
+func TestConsensus_SkipsFailedSources(t *testing.T) {
+	c := consensusOf(t, ConsensusOptions{MinSources: 2}, 100, errors.New("down"), 200)
+
+	q, err := c.GetQuote(context.Background(), "btc", "usd")
+	require.NoError(t, err)
+	require.Equal(t, model.NewDecimal(150, 0), q.Price)
+	require.Equal(t, 2, q.Sources)
+	require.Equal(t, model.NewDecimal(100, 0), q.Spread)
+}
+
+func TestConsensus_SubCentExact(t *testing.T) {
+	c := consensusOf(t, ConsensusOptions{MaxDeviationPct: 50}, "0.00001", "0.000012", "0.00005")
+
+	q, err := c.GetQuote(context.Background(), "pepe", "usd")
+	require.NoError(t, err)
+	require.Equal(t, "0.000011", q.Price.String(), "median of two after dropping the outlier")
+	require.Equal(t, "0.000002", q.Spread.String())
+}
+
+func TestConsensus_NotEnoughSources(t *testing.T) {
+	c := consensusOf(t, ConsensusOptions{MinSources: 2}, 100, errors.New("down"), 0)
+
+	_, err := c.GetQuote(context.Background(), "btc", "usd")
+	require.Error(t, err)
+}
+
+func TestConsensus_AllUnknown(t *testing.T) {
+	unknown := fmt.Errorf("x: %w", model.ErrUnknownCoin)
+	c := consensusOf(t, ConsensusOptions{}, unknown, unknown)
+
+	_, err := c.GetQuote(context.Background(), "btcc", "usd")
+	require.ErrorIs(t, err, model.ErrUnknownCoin)
+}
+
+func TestNewConsensus_Validation(t *testing.T) {
+	m := map[string]PriceProvider{"a": &fakePriceClient{}}
+
+	_, err := NewConsensus(nil, ConsensusOptions{})
+	require.Error(t, err)
+	_, err = NewConsensus(m, ConsensusOptions{Method: "mode"})
+	require.Error(t, err)
+	_, err = NewConsensus(m, ConsensusOptions{MinSources: 2})
+	require.Error(t, err)
+}
+
+// slowPriceClient отвечает через delay — проверяем, что источники опрашиваются параллельно
+type slowPriceClient struct {
+	delay time.Duration
+	calls int32
+}
+
+func (s *slowPriceClient) GetPrice(ctx context.Context, symbol, quote string) (model.Decimal, error) {
+	atomic.AddInt32(&s.calls, 1)
+	time.Sleep(s.delay)
+	return model.NewDecimal(100, 0), nil
+}
+
+func TestConsensus_QueriesConcurrently(t *testing.T) {
+	members := map[string]PriceProvider{
+		"a": &slowPriceClient{delay: 80 * time.Millisecond},
+		"b": &slowPriceClient{delay: 80 * time.Millisecond},
+		"c": &slowPriceClient{delay: 80 * time.Millisecond},
+	}
+	c, err := NewConsensus(members, ConsensusOptions{})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = c.GetQuote(context.Background(), "btc", "usd")
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestCollector_SavesConsensusMetadata(t *testing.T) {
+	st := &memStorage{}
+	c := newCollector("btc", nil, 40*time.Millisecond, st, consensusOf(t, ConsensusOptions{}, 100, 110, 120))
+	c.Start()
+	wait(100)
+	c.Stop()
+	wait(30)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	require.Equal(t, model.NewDecimal(110, 0), st.last.Price)
+	require.Equal(t, 3, st.last.Sources)
+	require.Equal(t, model.NewDecimal(20, 0), st.last.Spread)
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"crypto-observer/internal/metrics"
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+// Границы поиска разрывов
+const (
+	maxGaps     = 100  // разрывов на тикер и валюту за один запрос к БД
+	maxGapTicks = 1000 // дальше тики расписания внутри разрыва не считаем
+)
+
+// GapAuditOptions — пороги аудитора разрывов в истории цен.
+type GapAuditOptions struct {
+	Factor   int           // разрыв — пропущено не меньше Factor тиков расписания подряд; по умолчанию staleFactor
+	Lookback time.Duration // какую часть истории проверять; 24h
+	Interval time.Duration // как часто проходить по коллекторам; 0 — фоновый аудит выключен
+	Backfill bool          // ставить догрузку истории на найденные разрывы
+}
+
+func (o GapAuditOptions) withDefaults() GapAuditOptions {
+	if o.Factor <= 0 {
+		o.Factor = staleFactor
+	}
+	if o.Lookback <= 0 {
+		o.Lookback = 24 * time.Hour
+	}
+	return o
+}
+
+// StartGapAudit задаёт пороги поиска разрывов для FindGaps и, если opts.Interval > 0,
+// запускает фоновый аудит до отмены ctx. Вызывается один раз при старте, как Use*.
+func (s *Service) StartGapAudit(ctx context.Context, opts GapAuditOptions) {
+	s.gapOpts = opts.withDefaults()
+	if opts.Interval <= 0 {
+		return
+	}
+	go func() {
+		t := time.NewTicker(opts.Interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				s.auditGaps(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	logger.L().WithFields(logger.Fields{
+		"factor":   s.gapOpts.Factor,
+		"lookback": s.gapOpts.Lookback,
+		"interval": opts.Interval,
+		"backfill": opts.Backfill,
+	}).Info("GapAudit: start")
+}
+
+// FindGaps — разрывы в истории тикера за последние opts.Lookback по всем его валютам котировки.
+// Разрыв меряется расписанием коллектора, поэтому тикер должен отслеживаться — иначе model.ErrNotTracked.
+// Если догрузка включена, у разрывов, которые уже покрыты задачей, заполнен BackfillJob.
+func (s *Service) FindGaps(symbol string) ([]model.Gap, error) {
+	s.mu.RLock()
+	c, ok := s.collectors[symbol]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, model.ErrNotTracked
+	}
+	gaps, err := s.collectorGaps(context.Background(), c, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.markBackfilled(c.symbol, gaps); err != nil {
+		return nil, err
+	}
+	return gaps, nil
+}
+
+// auditGaps — один проход аудитора по работающим коллекторам.
+// Ошибка по одному тикеру не мешает проверить остальные.
+func (s *Service) auditGaps(ctx context.Context) {
+	now := time.Now()
+	for _, c := range s.collectorList() {
+		if ctx.Err() != nil {
+			return
+		}
+		if !c.Running() {
+			continue
+		}
+		log := logger.L().WithField("symbol", c.symbol)
+		gaps, err := s.collectorGaps(ctx, c, now)
+		if err != nil {
+			log.WithError(err).Error("GapAudit: find failed")
+			continue
+		}
+		perQuote := make(map[string]int)
+		for _, g := range gaps {
+			perQuote[g.Quote]++
+		}
+		for _, q := range c.quoteList() {
+			metrics.PriceGaps.WithLabelValues(c.symbol, q).Set(float64(perQuote[q]))
+		}
+		if len(gaps) == 0 {
+			continue
+		}
+		log.WithField("gaps", len(gaps)).Warn("GapAudit: gaps found")
+		if s.gapOpts.Backfill {
+			s.repairGaps(c.symbol, gaps)
+		}
+	}
+}
+
+// collectorGaps ищет разрывы по текущему расписанию коллектора: в БД — кандидаты длиннее
+// Factor самых коротких периодов, затем по расписанию считается, сколько тиков на них пришлось
+// (у cron и окон период в разное время разный). Прежние расписания тикера не учитываются,
+// паузы через /currency/remove тоже выглядят разрывами.
+func (s *Service) collectorGaps(ctx context.Context, c *collector, now time.Time) ([]model.Gap, error) {
+	opts := s.gapOpts
+	sched := c.schedule()
+	from := now.Add(-opts.Lookback)
+	minGap := int64(time.Duration(opts.Factor) * minPeriod(sched, from, now) / time.Second)
+
+	out := []model.Gap{}
+	for _, q := range c.quoteList() {
+		cands, err := s.st.FindGaps(ctx, c.symbol, q, from.Unix(), now.Unix(), minGap, maxGaps)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range cands {
+			if g.Missed = missedTicks(sched, g.From, g.To); g.Missed >= opts.Factor {
+				out = append(out, g)
+			}
+		}
+	}
+	return out, nil
+}
+
+// markBackfilled проставляет разрывам задачи догрузки (в любом состоянии), которые их покрывают.
+func (s *Service) markBackfilled(symbol string, gaps []model.Gap) error {
+	if s.backfill == nil || len(gaps) == 0 {
+		return nil
+	}
+	jobs, err := s.backfill.Jobs(symbol, maxBackfillJobs)
+	if err != nil {
+		return err
+	}
+	for i, g := range gaps {
+		for _, j := range jobs {
+			if j.Quote == g.Quote && j.From <= g.From && j.To >= g.To {
+				gaps[i].BackfillJob = j.ID
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// repairGaps ставит догрузку на разрывы без задачи. Разрыв, который провайдер не закрыл
+// целиком (его точки реже наших тиков) или не смог догрузить, повторно не ставится.
+func (s *Service) repairGaps(symbol string, gaps []model.Gap) {
+	if s.backfill == nil {
+		return
+	}
+	log := logger.L().WithField("symbol", symbol)
+	if err := s.markBackfilled(symbol, gaps); err != nil {
+		log.WithError(err).Error("GapAudit: list backfill jobs failed")
+		return
+	}
+	providerID, err := s.resolveID(symbol, "")
+	if err != nil {
+		log.WithError(err).Warn("GapAudit: provider id unknown, gaps left as is")
+		return
+	}
+	for i, g := range gaps {
+		if g.BackfillJob != 0 {
+			continue
+		}
+		j, err := s.backfill.Submit(model.BackfillJob{Symbol: symbol, ProviderID: providerID, Quote: g.Quote, From: g.From, To: g.To})
+		if err != nil {
+			log.WithError(err).Warn("GapAudit: backfill not queued")
+			return
+		}
+		gaps[i].BackfillJob = j.ID
+	}
+}
+
+// missedTicks — сколько тиков расписания пришлось строго между from и to, не больше maxGapTicks.
+func missedTicks(sched schedule, from, to int64) int {
+	t, end := time.Unix(from, 0), time.Unix(to, 0)
+	n := 0
+	for n < maxGapTicks {
+		// нулевое время — у расписания больше нет срабатываний
+		if t = sched.next(t, t); t.IsZero() || !t.Before(end) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// minPeriod — самый короткий шаг расписания в [from, to]; с него начинается порог для БД.
+func minPeriod(sched schedule, from, to time.Time) time.Duration {
+	switch sc := sched.(type) {
+	case fixedSchedule:
+		return sc.every
+	case windowSchedule:
+		p := sc.base.every
+		for _, w := range sc.windows {
+			p = min(p, w.every)
+		}
+		return p
+	}
+	// cron: проходим срабатывания в диапазоне
+	p := to.Sub(from)
+	t := sched.next(from, from)
+	for i := 0; i < maxGapTicks && !t.IsZero() && t.Before(to); i++ {
+		next := sched.next(t, t)
+		if next.IsZero() {
+			break
+		}
+		p = min(p, next.Sub(t))
+		t = next
+	}
+	return p
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/metrics"
+	"crypto-observer/internal/model"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_FindGaps(t *testing.T) {
+	fs := &fakeStorage{retGaps: []model.Gap{
+		{Symbol: "btc", Quote: "usd", From: 1000, To: 1031}, // 1010, 1020, 1030 — три пропуска
+		{Symbol: "btc", Quote: "usd", From: 1100, To: 1130}, // 1110, 1120 — допустимо
+		{Symbol: "btc", Quote: "eur", From: 2000, To: 2100},
+	}}
+	s := newSvcWith(fs)
+	_, err := s.FindGaps("btc")
+	require.ErrorIs(t, err, model.ErrNotTracked)
+
+	s.collectors["btc"] = newCollector("btc", []string{"usd", "eur"}, 10*time.Second, &memStorage{}, &fakePriceClient{})
+	before := time.Now().Unix()
+	gaps, err := s.FindGaps("btc")
+	require.NoError(t, err)
+	require.Equal(t, []model.Gap{
+		{Symbol: "btc", Quote: "usd", From: 1000, To: 1031, Missed: 3},
+		{Symbol: "btc", Quote: "eur", From: 2000, To: 2100, Missed: 9},
+	}, gaps)
+
+	// в БД — только интервалы длиннее staleFactor периодов за последние сутки
+	require.Equal(t, int64(30), fs.gotGaps.minGap)
+	require.Equal(t, maxGaps, fs.gotGaps.limit)
+	require.GreaterOrEqual(t, fs.gotGaps.to, before)
+	require.Equal(t, int64(24*60*60), fs.gotGaps.to-fs.gotGaps.from)
+}
+
+func TestGaps_ScheduleAware(t *testing.T) {
+	// cron каждые 5 минут: порог — 5 минут, на час приходится 11 тиков между краями
+	cronSched, err := buildSchedule(model.WatchItem{Cron: "*/5 * * * *"})
+	require.NoError(t, err)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, 5*time.Minute, minPeriod(cronSched, from, from.Add(24*time.Hour)))
+	require.Equal(t, 11, missedTicks(cronSched, from.Unix(), from.Add(time.Hour).Unix()))
+
+	// окна: порог — самый частый период, а ночью без окна час — это один пропуск базового периода
+	winSched, err := buildSchedule(model.WatchItem{Period: 3600, Windows: []model.ScheduleWindow{{From: 9 * 60, To: 17 * 60, Period: 60}}})
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, minPeriod(winSched, from, from.Add(24*time.Hour)))
+	require.Equal(t, 0, missedTicks(winSched, from.Unix(), from.Add(time.Hour).Unix()))
+	require.Equal(t, 4, missedTicks(winSched, from.Add(10*time.Hour).Unix(), from.Add(10*time.Hour+5*time.Minute).Unix()))
+}
+
+func TestService_AuditGaps_QueuesBackfillOnce(t *testing.T) {
+	fs := &fakeStorage{retGaps: []model.Gap{
+		{Symbol: "btc", Quote: "usd", From: 1000, To: 1100},
+		{Symbol: "btc", Quote: "usd", From: 3000, To: 3100},
+	}}
+	s := newSvcWith(fs)
+	c := newCollector("btc", nil, 10*time.Second, &memStorage{}, &fakePriceClient{})
+	c.run.Store(true)
+	s.collectors["btc"] = c
+
+	st := &memBackfill{}
+	s.UseBackfill(newTestBackfiller(st, &fakeChart{step: 50}))
+	s.StartGapAudit(context.Background(), GapAuditOptions{Backfill: true})
+	// первый разрыв уже догружали вручную
+	_, err := s.StartBackfill(model.BackfillReq{Symbol: "btc", From: 500, To: 1500})
+	require.NoError(t, err)
+
+	s.auditGaps(context.Background())
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.PriceGaps.WithLabelValues("btc", "usd")))
+	require.Len(t, st.jobs, 2)
+	j := st.job(2)
+	require.Equal(t, "bitcoin", j.ProviderID)
+	require.Equal(t, [2]int64{3000, 3100}, [2]int64{j.From, j.To})
+
+	// разрыв не закрылся (воркер не запущен), но повторно не ставится
+	s.auditGaps(context.Background())
+	require.Len(t, st.jobs, 2)
+
+	gaps, err := s.FindGaps("btc")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), gaps[0].BackfillJob)
+	require.Equal(t, int64(2), gaps[1].BackfillJob)
+}
+
+func TestGaps_ScheduleWithoutTicks(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Zero(t, missedTicks(doneSchedule{}, from.Unix(), from.Add(time.Hour).Unix()))
+	require.Equal(t, time.Hour, minPeriod(doneSchedule{}, from, from.Add(time.Hour)))
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"crypto-observer/internal/model"
+)
+
+// staleFactor — коллектор застыл, если после последнего сохранения прошло staleFactor
+// тиков его расписания. Тот же порог решает, «давно ли» провайдер отвечал.
+const staleFactor = 3
+
+// pingTimeout — сколько ждём Postgres в /readyz; пробы kubelet сами по себе короткие.
+const pingTimeout = 2 * time.Second
+
+// Readiness проверяет БД и собирает сводку по провайдерам и коллекторам.
+// Отсчёт для только что запущенного коллектора идёт от старта,
+// так что первые staleFactor тиков он не считается застывшим.
+func (s *Service) Readiness() model.Readiness {
+	var r model.Readiness
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err := s.st.Ping(ctx); err != nil {
+		r.DBError = err.Error()
+	}
+
+	now := time.Now()
+	providers := make(map[string]*model.ProviderHealth)
+	for _, c := range s.collectorList() {
+		if !c.Running() {
+			continue
+		}
+		started, fetched, saved := c.activity()
+		sched := c.schedule()
+
+		p, ok := providers[c.provider]
+		if !ok {
+			p = &model.ProviderHealth{Name: c.provider}
+			providers[c.provider] = p
+		}
+		if !fetched.IsZero() && fetched.Unix() > p.LastSuccess {
+			p.LastSuccess = fetched.Unix()
+		}
+		if !overdue(sched, latest(started, fetched), now) {
+			p.OK = true
+		}
+
+		if overdue(sched, latest(started, saved), now) {
+			st := model.StaleCollector{Symbol: c.symbol, PeriodSec: int64(c.period() / time.Second)}
+			if !saved.IsZero() {
+				st.LastSave = saved.Unix()
+			}
+			r.Stale = append(r.Stale, st)
+		}
+	}
+	for _, p := range providers {
+		r.Providers = append(r.Providers, *p)
+	}
+	sort.Slice(r.Providers, func(i, j int) bool { return r.Providers[i].Name < r.Providers[j].Name })
+	sort.Slice(r.Stale, func(i, j int) bool { return r.Stale[i].Symbol < r.Stale[j].Symbol })
+	return r
+}
+
+// overdue — прошло ли к now staleFactor тиков расписания после since. Тики проходим
+// по самому расписанию: у окон и cron текущий период ничего не говорит о прошлых тиках.
+func overdue(sched schedule, since, now time.Time) bool {
+	t := since
+	for i := 0; i < staleFactor; i++ {
+		// нулевое время — у расписания больше нет срабатываний
+		if t = sched.next(t, t); t.IsZero() || !t.Before(now) {
+			return false
+		}
+	}
+	return true
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+// runningCollector — коллектор, помеченный запущенным, без горутины тиков.
+func runningCollector(symbol, provider string, every time.Duration, started, fetched, saved time.Time) *collector {
+	c := newCollector(symbol, nil, every, &memStorage{}, &fakePriceClient{})
+	c.provider = provider
+	c.started, c.fetched, c.saved = started, fetched, saved
+	c.run.Store(true)
+	return c
+}
+
+func TestReadiness_StaleAndProviders(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	now := time.Now()
+	per := time.Minute
+	var never time.Time
+
+	// свежий: сохранял минуту назад
+	s.collectors["btc"] = runningCollector("btc", "coingecko", per, now.Add(-time.Hour), now.Add(-per), now.Add(-per))
+	// провайдер отвечает, но БД не пишет уже 10 периодов
+	s.collectors["eth"] = runningCollector("eth", "coingecko", per, now.Add(-time.Hour), now.Add(-per), now.Add(-10*per))
+	// только что запущен — ещё не застыл
+	s.collectors["sol"] = runningCollector("sol", "binance", per, now.Add(-2*per), never, never)
+	// binance молчит давно
+	s.collectors["ada"] = runningCollector("ada", "kraken", per, now.Add(-time.Hour), never, never)
+	// остановленные не учитываются
+	s.collectors["xrp"] = newCollector("xrp", nil, per, &memStorage{}, &fakePriceClient{})
+
+	r := s.Readiness()
+	require.Empty(t, r.DBError)
+
+	require.Len(t, r.Stale, 2)
+	require.Equal(t, "ada", r.Stale[0].Symbol)
+	require.Zero(t, r.Stale[0].LastSave)
+	require.Equal(t, "eth", r.Stale[1].Symbol)
+	require.Equal(t, int64(60), r.Stale[1].PeriodSec)
+	require.Equal(t, now.Add(-10*per).Unix(), r.Stale[1].LastSave)
+
+	require.Len(t, r.Providers, 3)
+	byName := map[string]bool{}
+	for _, p := range r.Providers {
+		byName[p.Name] = p.OK
+	}
+	require.Equal(t, map[string]bool{"binance": true, "coingecko": true, "kraken": false}, byName)
+	require.Equal(t, now.Add(-per).Unix(), r.Providers[1].LastSuccess, "coingecko: latest fetch of its collectors")
+}
+
+// sparseSchedule — тики из списка, а period отдаёт другой, текущий шаг (как у окон и cron).
+type sparseSchedule struct {
+	ticks []time.Time
+	every time.Duration
+}
+
+func (s sparseSchedule) next(_, now time.Time) time.Time {
+	for _, t := range s.ticks {
+		if t.After(now) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s sparseSchedule) period(time.Time) time.Duration { return s.every }
+
+func TestReadiness_StaleByScheduledTicks(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	now := time.Now()
+	hourly := sparseSchedule{every: 10 * time.Second}
+	for i := -5; i <= 1; i++ {
+		hourly.ticks = append(hourly.ticks, now.Add(time.Duration(i)*time.Hour-5*time.Minute))
+	}
+
+	// сейчас шаг 10s, но тики раз в час: 50 минут без сохранения — это ни одного пропущенного тика
+	fresh := runningCollector("btc", "coingecko", time.Minute, now.Add(-5*time.Hour), now.Add(-50*time.Minute), now.Add(-50*time.Minute))
+	fresh.sched = hourly
+	s.collectors["btc"] = fresh
+	// а три часовых тика подряд без сохранения — застыл, хоть сейчас шаг и 10s
+	stuck := runningCollector("eth", "coingecko", time.Minute, now.Add(-5*time.Hour), now.Add(-50*time.Minute), now.Add(-3*time.Hour-10*time.Minute))
+	stuck.sched = hourly
+	s.collectors["eth"] = stuck
+	// у расписания больше нет тиков — ждать нечего
+	done := runningCollector("sol", "binance", time.Minute, now.Add(-5*time.Hour), time.Time{}, time.Time{})
+	done.sched = sparseSchedule{}
+	s.collectors["sol"] = done
+
+	r := s.Readiness()
+	require.Len(t, r.Stale, 1)
+	require.Equal(t, "eth", r.Stale[0].Symbol)
+	require.Equal(t, []model.ProviderHealth{{Name: "binance", OK: true}, {Name: "coingecko", OK: true, LastSuccess: now.Add(-50 * time.Minute).Unix()}}, r.Providers)
+}
+
+func TestReadiness_DBDown(t *testing.T) {
+	s := newSvcWith(&fakeStorage{pingErr: errors.New("connection refused")})
+	r := s.Readiness()
+	require.Equal(t, "connection refused", r.DBError)
+	require.Empty(t, r.Providers)
+	require.Empty(t, r.Stale)
+}
+
+func TestCollector_RecordsActivity(t *testing.T) {
+	st := &memStorage{}
+	pc := &fakePriceClient{err: errors.New("boom")}
+	c := newCollector("btc", nil, time.Hour, st, pc)
+
+	_ = c.tick()
+	_, fetched, saved := c.activity()
+	require.True(t, fetched.IsZero())
+	require.True(t, saved.IsZero())
+
+	pc.err, pc.val = nil, model.MustDecimal("1")
+	st.err = errors.New("db down")
+	_ = c.tick()
+	_, fetched, saved = c.activity()
+	require.False(t, fetched.IsZero(), "fetch succeeded")
+	require.True(t, saved.IsZero(), "save failed")
+
+	st.err = nil
+	_ = c.tick()
+	_, _, saved = c.activity()
+	require.WithinDuration(t, time.Now(), saved, time.Second)
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"sync"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+// Сколько непрочитанных цен держим на подписчика; дальше новые отбрасываются
+const hubBuffer = 64
+
+// Hub — in-process pub/sub сохранённых цен: коллекторы публикуют,
+// потоковые эндпоинты (WebSocket) подписываются на нужные тикеры.
+// Медленный подписчик не тормозит коллектор: если его буфер полон, цена для него теряется.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[*hubSub]struct{}
+}
+
+type hubSub struct {
+	symbols map[string]bool // пусто — все тикеры
+	ch      chan model.Price
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*hubSub]struct{})}
+}
+
+// Subscribe подписывает на цены перечисленных тикеров (пустой список — на все).
+// cancel отписывает и закрывает канал; вызывать можно несколько раз.
+func (h *Hub) Subscribe(symbols []string) (<-chan model.Price, func()) {
+	sub := &hubSub{symbols: make(map[string]bool, len(symbols)), ch: make(chan model.Price, hubBuffer)}
+	for _, s := range symbols {
+		sub.symbols[normSymbol(s)] = true
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, sub)
+			h.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// Publish рассылает цену подписчикам тикера; никогда не блокируется.
+func (h *Hub) Publish(p model.Price) {
+	sym := normSymbol(p.Symbol)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs {
+		if len(sub.symbols) > 0 && !sub.symbols[sym] {
+			continue
+		}
+		select {
+		case sub.ch <- p:
+		default:
+			logger.L().WithField("symbol", p.Symbol).Debug("Hub: subscriber is slow, price dropped")
+		}
+	}
+}
+
+// Subscribers — число активных подписок.
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
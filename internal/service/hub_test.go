@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func recvPrice(t *testing.T, ch <-chan model.Price) model.Price {
+	t.Helper()
+	select {
+	case p := <-ch:
+		return p
+	case <-time.After(time.Second):
+		t.Fatal("no price received")
+	}
+	return model.Price{}
+}
+
+func TestHub_FiltersBySymbol(t *testing.T) {
+	h := NewHub()
+	btc, cancelBTC := h.Subscribe([]string{"BTC"})
+	defer cancelBTC()
+	all, cancelAll := h.Subscribe(nil)
+	defer cancelAll()
+
+	h.Publish(model.Price{Symbol: "eth", Price: model.NewDecimal(3, 0)})
+	h.Publish(model.Price{Symbol: "btc", Price: model.NewDecimal(5, 0)})
+
+	require.Equal(t, "btc", recvPrice(t, btc).Symbol, "eth filtered out")
+	require.Equal(t, "eth", recvPrice(t, all).Symbol)
+	require.Equal(t, "btc", recvPrice(t, all).Symbol)
+}
+
+func TestHub_CancelClosesAndUnsubscribes(t *testing.T) {
+	h := NewHub()
+	ch, cancel := h.Subscribe([]string{"btc"})
+	require.Equal(t, 1, h.Subscribers())
+
+	cancel()
+	cancel() // повторный вызов безопасен
+	_, ok := <-ch
+	require.False(t, ok, "channel closed")
+	require.Equal(t, 0, h.Subscribers())
+
+	h.Publish(model.Price{Symbol: "btc"}) // без паники на закрытом канале
+}
+
+func TestHub_SlowSubscriberDoesNotBlock(t *testing.T) {
+	h := NewHub()
+	ch, cancel := h.Subscribe(nil)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < hubBuffer*2; i++ {
+			h.Publish(model.Price{Symbol: "btc", TS: int64(i)})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscriber")
+	}
+	require.Len(t, ch, hubBuffer, "overflow dropped")
+	require.Equal(t, int64(0), recvPrice(t, ch).TS, "oldest kept")
+}
+
+func TestCollector_PublishesAfterSave(t *testing.T) {
+	h := NewHub()
+	ch, cancel := h.Subscribe([]string{"btc"})
+	defer cancel()
+
+	st := &memStorage{}
+	c := newCollector("btc", nil, time.Hour, st, &fakePriceClient{val: model.MustDecimal("0.5")})
+	c.pub = h
+	require.NoError(t, c.tick())
+
+	p := recvPrice(t, ch)
+	require.Equal(t, "0.5", p.Price.String())
+	require.Equal(t, model.DefaultQuote, p.Quote)
+	require.Equal(t, int64(1), p.ID, "published with its row id")
+
+	st.err = errors.New("db down")
+	require.Error(t, c.tick())
+	require.Len(t, ch, 0, "unsaved price is not published")
+}
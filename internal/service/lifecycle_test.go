@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+// blockingSaver — SavePrice ждёт release и запоминает, был ли отменён его ctx.
+type blockingSaver struct {
+	entered  chan struct{}
+	release  chan struct{}
+	once     sync.Once
+	canceled atomic.Bool
+	saved    atomic.Int32
+}
+
+func newBlockingSaver() *blockingSaver {
+	return &blockingSaver{entered: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (b *blockingSaver) SavePrice(ctx context.Context, p model.Price) (int64, error) {
+	b.once.Do(func() { close(b.entered) })
+	<-b.release
+	b.canceled.Store(ctx.Err() != nil)
+	return int64(b.saved.Add(1)), nil
+}
+
+// blockingProvider отвечает только по отмене ctx.
+type blockingProvider struct{ entered chan struct{} }
+
+func (p *blockingProvider) GetPrice(ctx context.Context, symbol, quote string) (model.Decimal, error) {
+	close(p.entered)
+	<-ctx.Done()
+	return model.Decimal{}, ctx.Err()
+}
+
+// attach запускает коллектор так же, как startCollector, но с коротким периодом.
+func attach(s *Service, c *collector) {
+	c.ctx, c.wg = s.ctx, &s.wg
+	s.mu.Lock()
+	s.collectors[c.symbol] = c
+	s.mu.Unlock()
+	c.Start()
+}
+
+func TestService_Shutdown_WaitsForInFlightSave(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	saver := newBlockingSaver()
+	c := newCollector("btc", nil, 10*time.Millisecond, saver, &fakePriceClient{val: model.NewDecimal(1, 0)})
+	attach(s, c)
+	<-saver.entered
+
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown(context.Background()) }()
+	select {
+	case <-done:
+		t.Fatal("Shutdown returned while a save was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(saver.release)
+	require.NoError(t, <-done)
+	require.Equal(t, int32(1), saver.saved.Load())
+	require.False(t, saver.canceled.Load(), "in-flight save is not canceled by shutdown")
+	require.False(t, c.Running())
+}
+
+func TestService_Shutdown_AbortsFetch(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	pc := &blockingProvider{entered: make(chan struct{})}
+	c := newCollector("btc", nil, 10*time.Millisecond, &memStorage{}, pc)
+	var failed atomic.Bool
+	c.onFail = func(string, error) { failed.Store(true) }
+	attach(s, c)
+	<-pc.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Shutdown(ctx))
+	require.False(t, failed.Load(), "shutdown is not reported as a collector failure")
+	state, _ := c.Status()
+	require.Equal(t, StatePending, state)
+}
+
+func TestService_Shutdown_Deadline(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	saver := newBlockingSaver()
+	defer close(saver.release)
+	attach(s, newCollector("btc", nil, 10*time.Millisecond, saver, &fakePriceClient{val: model.NewDecimal(1, 0)}))
+	<-saver.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, s.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestService_AddAfterShutdown(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	require.NoError(t, s.Context().Err())
+	require.NoError(t, s.Shutdown(context.Background()))
+	require.ErrorIs(t, s.Context().Err(), context.Canceled, "Batcher bound to Context stops with the collectors")
+	require.ErrorIs(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600}), model.ErrShuttingDown)
+	require.Empty(t, s.ListCollectors())
+}
+
+// параллельные запросы API к одному сервису (ловится go test -race)
+func TestService_ConcurrentAccess(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sym := fmt.Sprintf("c%d", i%3)
+			for j := 0; j < 20; j++ {
+				_ = s.AddCurrency(model.AddReq{Symbol: sym, Period: 3600})
+				_, _ = s.UpdateCurrency(sym, model.UpdateReq{Period: 1800})
+				_ = s.Status(sym)
+				_ = s.ListCollectors()
+				_ = s.Readiness()
+				_ = s.RemoveCurrency(sym)
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, s.Shutdown(context.Background()))
+}
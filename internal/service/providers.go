@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"crypto-observer/internal/coingecko"
+	"crypto-observer/internal/model"
+)
+
+// PriceProvider — источник цены по тикеру (btc, eth, ...) в котируемой валюте
+// (usd, eur, btc, ...); цена — точное десятичное число.
+// Перевод тикера и валюты в формат конкретной биржи — забота адаптера.
+type PriceProvider interface {
+	GetPrice(ctx context.Context, symbol, quote string) (model.Decimal, error)
+}
+
+// ProviderRegistry хранит провайдеров по имени и решает, кого спрашивать
+// для конкретного тикера: сначала персональная привязка, потом провайдер по умолчанию.
+type ProviderRegistry struct {
+	mu        sync.RWMutex
+	byName    map[string]PriceProvider
+	perSymbol map[string]string
+	def       string
+}
+
+func NewProviderRegistry() *ProviderRegistry {
+	return &ProviderRegistry{
+		byName:    make(map[string]PriceProvider),
+		perSymbol: make(map[string]string),
+	}
+}
+
+// Register добавляет провайдера; первый зарегистрированный становится провайдером по умолчанию.
+func (r *ProviderRegistry) Register(name string, p PriceProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byName[name] = p
+	if r.def == "" {
+		r.def = name
+	}
+}
+
+func (r *ProviderRegistry) SetDefault(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byName[name]; !ok {
+		return fmt.Errorf("unknown price provider %q", name)
+	}
+	r.def = name
+	return nil
+}
+
+// Assign закрепляет тикер за конкретным провайдером.
+func (r *ProviderRegistry) Assign(symbol, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byName[name]; !ok {
+		return fmt.Errorf("unknown price provider %q for %s", name, symbol)
+	}
+	r.perSymbol[normSymbol(symbol)] = name
+	return nil
+}
+
+// Lookup возвращает провайдера по имени.
+func (r *ProviderRegistry) Lookup(name string) (PriceProvider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.byName[name]
+	return p, ok
+}
+
+// For возвращает провайдера для тикера и его имя; nil, если реестр пуст.
+func (r *ProviderRegistry) For(symbol string) (PriceProvider, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	name, ok := r.perSymbol[normSymbol(symbol)]
+	if !ok {
+		name = r.def
+	}
+	return r.byName[name], name
+}
+
+func normSymbol(sym string) string { return strings.ToLower(strings.TrimSpace(sym)) }
+
+// CoingeckoProvider переводит тикер в CoinGecko id перед запросом.
+// Умеет и одиночный, и пакетный запрос (BatchPriceProvider).
+type CoingeckoProvider struct {
+	cli *coingecko.Client
+	ids *SymbolRegistry
+}
+
+func NewCoingeckoProvider(cli *coingecko.Client, ids *SymbolRegistry) CoingeckoProvider {
+	return CoingeckoProvider{cli: cli, ids: ids}
+}
+
+func (p CoingeckoProvider) GetPrice(ctx context.Context, symbol, quote string) (model.Decimal, error) {
+	return p.cli.GetPrice(ctx, p.ids.CoingeckoID(symbol), quote)
+}
+
+func (p CoingeckoProvider) GetPrices(ctx context.Context, symbols, quotes []string) (map[string]map[string]model.Decimal, error) {
+	ids := make([]string, len(symbols))
+	for i, sym := range symbols {
+		ids[i] = p.ids.CoingeckoID(sym)
+	}
+	byID, err := p.cli.GetPrices(ctx, ids, quotes)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]map[string]model.Decimal, len(byID))
+	for i, sym := range symbols {
+		if prices, ok := byID[ids[i]]; ok {
+			out[sym] = prices
+		}
+	}
+	return out, nil
+}
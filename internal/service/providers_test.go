@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/coingecko"
+	"crypto-observer/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderRegistry_FirstIsDefault_AssignOverrides(t *testing.T) {
+	cg, bn := &fakePriceClient{}, &fakePriceClient{}
+	reg := NewProviderRegistry()
+	reg.Register("coingecko", cg)
+	reg.Register("binance", bn)
+
+	p, name := reg.For("eth")
+	require.Same(t, cg, p)
+	require.Equal(t, "coingecko", name)
+
+	require.NoError(t, reg.Assign(" BTC ", "binance"))
+	p, name = reg.For("btc")
+	require.Same(t, bn, p)
+	require.Equal(t, "binance", name)
+
+	require.NoError(t, reg.SetDefault("binance"))
+	p, _ = reg.For("eth")
+	require.Same(t, bn, p)
+}
+
+func TestProviderRegistry_UnknownProvider(t *testing.T) {
+	reg := NewProviderRegistry()
+	reg.Register("coingecko", &fakePriceClient{})
+
+	require.Error(t, reg.Assign("btc", "kraken"))
+	require.Error(t, reg.SetDefault("kraken"))
+}
+
+func TestProviderRegistry_Empty(t *testing.T) {
+	p, name := NewProviderRegistry().For("btc")
+	require.Nil(t, p)
+	require.Empty(t, name)
+}
+
+func TestCoingeckoProvider_MapsTickerToID(t *testing.T) {
+	var gotIDs string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIDs = r.URL.Query().Get("ids")
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":1.5}}`))
+	}))
+	defer srv.Close()
+
+	p := NewCoingeckoProvider(coingecko.New(srv.URL, time.Second), NewSymbolRegistry())
+	price, err := p.GetPrice(context.Background(), "btc", "usd")
+	require.NoError(t, err)
+	require.Equal(t, "bitcoin", gotIDs)
+	require.Equal(t, model.MustDecimal("1.5"), price)
+}
+
+func TestService_AddCurrency_UsesAssignedProvider(t *testing.T) {
+	bn := &fakePriceClient{}
+	s := newSvcWith(&fakeStorage{})
+	s.providers.Register("binance", bn)
+	require.NoError(t, s.providers.Assign("btc", "binance"))
+
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600}))
+	require.Same(t, bn, s.collectors["btc"].pc)
+
+	_ = s.RemoveCurrency("btc")
+	sleepMS(20)
+}
+
+func TestCoingeckoProvider_BatchMapsIDsBack(t *testing.T) {
+	var gotIDs string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIDs = r.URL.Query().Get("ids")
+		_, _ = w.Write([]byte(`{"bitcoin":{"usd":2,"eur":1.8},"ethereum":{"usd":1}}`))
+	}))
+	defer srv.Close()
+
+	p := NewCoingeckoProvider(coingecko.New(srv.URL, time.Second), NewSymbolRegistry())
+	got, err := p.GetPrices(context.Background(), []string{"btc", "eth", "zzz"}, []string{"usd", "eur"})
+	require.NoError(t, err)
+	require.Equal(t, "bitcoin,ethereum,zzz", gotIDs)
+	require.Equal(t, map[string]map[string]model.Decimal{
+		"btc": {"usd": model.NewDecimal(2, 0), "eur": model.MustDecimal("1.8")},
+		"eth": {"usd": model.NewDecimal(1, 0)},
+	}, got)
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-observer/internal/model"
+
+	"github.com/robfig/cron/v3"
+)
+
+// schedule — когда коллектору следующий тик.
+type schedule interface {
+	// next — плановое время тика после prev (предыдущего тика или запуска), строго позже now.
+	next(prev, now time.Time) time.Time
+	// period — номинальный период в момент now: для /currency/list и /readyz.
+	period(now time.Time) time.Duration
+}
+
+// cronParser — пять полей или шесть с секундами впереди, плюс @every/@hourly;
+// зона — префиксом CRON_TZ=, иначе UTC.
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// maxPeriodSec — верхняя граница периода опроса (неделя): дальше time.Duration
+// в секундах переполняется, да и смысла в таком опросе нет.
+const maxPeriodSec = 7 * 24 * 3600
+
+// checkPeriod — период в секундах в пределах (0, maxPeriodSec].
+func checkPeriod(sec int) error {
+	if sec <= 0 || sec > maxPeriodSec {
+		return fmt.Errorf("period must be within 1..%d seconds, got %d", maxPeriodSec, sec)
+	}
+	return nil
+}
+
+// buildSchedule проверяет расписание тикера (поля Period, Align, Cron, Windows) и собирает его.
+// Ошибки — model.ErrInvalidSchedule.
+func buildSchedule(w model.WatchItem) (schedule, error) {
+	// у cron период — только запасной, его можно не задавать
+	if w.Period != 0 || w.Cron == "" {
+		if err := checkPeriod(w.Period); err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidSchedule, err)
+		}
+	}
+	base := fixedSchedule{every: time.Duration(w.Period) * time.Second, align: w.Align}
+	switch {
+	case w.Cron != "" && len(w.Windows) > 0:
+		return nil, fmt.Errorf("%w: cron and windows are mutually exclusive", model.ErrInvalidSchedule)
+	case w.Cron != "":
+		if w.Align {
+			return nil, fmt.Errorf("%w: align does not apply to cron", model.ErrInvalidSchedule)
+		}
+		expr := w.Cron
+		if !strings.HasPrefix(expr, "CRON_TZ=") && !strings.HasPrefix(expr, "TZ=") {
+			expr = "CRON_TZ=UTC " + expr
+		}
+		spec, err := cronParser.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cron: %v", model.ErrInvalidSchedule, err)
+		}
+		// например, "0 0 30 2 *": Next не находит срабатывания и отдаёт нулевое время
+		if spec.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("%w: cron never fires", model.ErrInvalidSchedule)
+		}
+		return cronSchedule{spec: spec}, nil
+	case len(w.Windows) > 0:
+		ws := windowSchedule{base: base, windows: make([]window, 0, len(w.Windows))}
+		for i, sw := range w.Windows {
+			win, err := newWindow(sw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: windows[%d]: %v", model.ErrInvalidSchedule, i, err)
+			}
+			ws.windows = append(ws.windows, win)
+		}
+		return ws, nil
+	}
+	return base, nil
+}
+
+// fixedSchedule — тик каждые every, по желанию выровненный по часам.
+type fixedSchedule struct {
+	every time.Duration
+	align bool
+}
+
+func (s fixedSchedule) next(prev, now time.Time) time.Time {
+	return nextTick(prev, now, s.every, s.align)
+}
+
+func (s fixedSchedule) period(time.Time) time.Duration { return s.every }
+
+// nextTick — плановое время тика, следующего за prev.
+//
+// Без выравнивания — prev + every; тики, к которым уже опоздали (тик шёл дольше
+// периода), пропускаются, как у time.Ticker.
+// С выравниванием — ближайшая после now граница, кратная every от начала эпохи Unix:
+// при every = 10s это :00, :10, :20…, одна и та же для всех тикеров, поэтому цены
+// разных монет ложатся на общие отметки времени и в общие бакеты свечей.
+func nextTick(prev, now time.Time, every time.Duration, align bool) time.Time {
+	if align {
+		d := every.Nanoseconds()
+		return time.Unix(0, (now.UnixNano()/d+1)*d)
+	}
+	next := prev.Add(every)
+	for !next.After(now) {
+		next = next.Add(every)
+	}
+	return next
+}
+
+// cronSchedule — тики по cron-выражению; всегда по часам, prev не важен.
+// Нулевое next — срабатываний больше нет.
+type cronSchedule struct{ spec cron.Schedule }
+
+func (s cronSchedule) next(_, now time.Time) time.Time { return s.spec.Next(now) }
+
+// period — расстояние между двумя ближайшими срабатываниями; 0, если их уже нет.
+func (s cronSchedule) period(now time.Time) time.Duration {
+	n := s.spec.Next(now)
+	if n.IsZero() {
+		return 0
+	}
+	nn := s.spec.Next(n)
+	if nn.IsZero() {
+		return 0
+	}
+	return nn.Sub(n)
+}
+
+// windowSchedule — базовый период и окна со своим. Внутри окна действует период окна
+// (первого подходящего, если окна пересекаются), вне — базовый; начало окна
+// всегда становится тиком, чтобы частый опрос не опаздывал на базовый период.
+type windowSchedule struct {
+	base    fixedSchedule
+	windows []window
+}
+
+func (s windowSchedule) next(prev, now time.Time) time.Time {
+	next := nextTick(prev, now, s.period(now), s.base.align)
+	for _, w := range s.windows {
+		if start := w.nextStart(now); !start.IsZero() && start.Before(next) {
+			next = start
+		}
+	}
+	return next
+}
+
+func (s windowSchedule) period(now time.Time) time.Duration {
+	for _, w := range s.windows {
+		if w.active(now) {
+			return w.every
+		}
+	}
+	return s.base.every
+}
+
+type window struct {
+	days     [7]bool // по time.Weekday
+	from, to int     // минуты от полуночи
+	loc      *time.Location
+	every    time.Duration
+}
+
+func newWindow(sw model.ScheduleWindow) (window, error) {
+	w := window{from: sw.From, to: sw.To, every: time.Duration(sw.Period) * time.Second}
+	if err := checkPeriod(sw.Period); err != nil {
+		return w, err
+	}
+	if sw.From < 0 || sw.To > 24*60 || sw.From >= sw.To {
+		return w, fmt.Errorf("from must be before to within one day (split overnight windows in two)")
+	}
+	tz := sw.TZ
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return w, fmt.Errorf("tz: %v", err)
+	}
+	w.loc = loc
+	for _, d := range sw.Days {
+		w.days[d] = true
+	}
+	if len(sw.Days) == 0 {
+		w.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	return w, nil
+}
+
+func (w window) active(t time.Time) bool {
+	lt := t.In(w.loc)
+	if !w.days[lt.Weekday()] {
+		return false
+	}
+	start := time.Date(lt.Year(), lt.Month(), lt.Day(), 0, w.from, 0, 0, w.loc)
+	end := time.Date(lt.Year(), lt.Month(), lt.Day(), 0, w.to, 0, 0, w.loc)
+	return !lt.Before(start) && lt.Before(end)
+}
+
+// nextStart — ближайшее начало окна строго после t; нулевое время, если окна нет ни в один день.
+func (w window) nextStart(t time.Time) time.Time {
+	lt := t.In(w.loc)
+	for d := 0; d <= 7; d++ {
+		// time.Date нормализует и переполнение дня, и минуты сверх часа, с учётом перехода на летнее время
+		start := time.Date(lt.Year(), lt.Month(), lt.Day()+d, 0, w.from, 0, 0, w.loc)
+		if w.days[start.Weekday()] && start.After(t) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// parseWindows переводит окна из запроса в модель: дни mon..sun, время HH:MM.
+// Границы и зону проверяет buildSchedule.
+func parseWindows(in []model.ScheduleWindowDTO) ([]model.ScheduleWindow, error) {
+	out := make([]model.ScheduleWindow, 0, len(in))
+	for i, dto := range in {
+		w := model.ScheduleWindow{TZ: strings.TrimSpace(dto.TZ), Period: dto.Period}
+		for _, name := range dto.Days {
+			d, ok := parseWeekday(name)
+			if !ok {
+				return nil, fmt.Errorf("%w: windows[%d]: unknown day %q", model.ErrInvalidSchedule, i, name)
+			}
+			w.Days = append(w.Days, d)
+		}
+		var err error
+		if w.From, err = parseClock(dto.From); err != nil {
+			return nil, fmt.Errorf("%w: windows[%d]: from: %v", model.ErrInvalidSchedule, i, err)
+		}
+		if w.To, err = parseClock(dto.To); err != nil {
+			return nil, fmt.Errorf("%w: windows[%d]: to: %v", model.ErrInvalidSchedule, i, err)
+		}
+		out = append(out, w)
+	}
+	return out, nil
+}
+
+// parseWeekday — "mon", "Monday" и т.п.
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if s == full || s == full[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// parseClock — "HH:MM" в минуты от полуночи; "24:00" — конец суток.
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	h, errH := strconv.Atoi(hh)
+	m, errM := strconv.Atoi(mm)
+	if !ok || errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("want HH:MM, got %q", s)
+	}
+	return h*60 + m, nil
+}
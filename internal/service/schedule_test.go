@@ -0,0 +1,143 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextTick_Unaligned(t *testing.T) {
+	prev := time.Unix(1000, 500)
+	require.Equal(t, time.Unix(1010, 500), nextTick(prev, prev, 10*time.Second, false))
+	require.Equal(t, time.Unix(1010, 500), nextTick(prev, time.Unix(1003, 0), 10*time.Second, false))
+	// тик шёл 25с — пропущенные отметки не догоняем
+	require.Equal(t, time.Unix(1030, 500), nextTick(prev, time.Unix(1025, 0), 10*time.Second, false))
+}
+
+func TestNextTick_Aligned(t *testing.T) {
+	every := 10 * time.Second
+	require.Equal(t, time.Unix(1010, 0), nextTick(time.Time{}, time.Unix(1003, 700), every, true))
+	// ровно на границе — следующая граница, а не та же
+	require.Equal(t, time.Unix(1020, 0), nextTick(time.Time{}, time.Unix(1010, 0), every, true))
+	// минута — на :00 секунд
+	require.Equal(t, time.Unix(1020, 0), nextTick(time.Time{}, time.Unix(1001, 0), time.Minute, true))
+
+	// разные тикеры, добавленные в разные моменты, попадают на одни отметки
+	a := nextTick(time.Time{}, time.Unix(1001, 0), every, true)
+	b := nextTick(time.Time{}, time.Unix(1007, 0), every, true)
+	require.Equal(t, a, b)
+}
+
+func TestBuildSchedule_Validation(t *testing.T) {
+	nyse := []model.ScheduleWindow{{Days: []time.Weekday{time.Monday}, From: 570, To: 960, TZ: "America/New_York", Period: 5}}
+	for name, w := range map[string]model.WatchItem{
+		"cron and windows":   {Period: 60, Cron: "* * * * *", Windows: nyse},
+		"cron with align":    {Period: 60, Cron: "* * * * *", Align: true},
+		"bad cron":           {Period: 60, Cron: "every minute"},
+		"bad cron tz":        {Period: 60, Cron: "CRON_TZ=Mars/Olympus * * * * *"},
+		"cron never fires":   {Period: 60, Cron: "0 0 30 2 *"},
+		"windows w/o period": {Windows: nyse},
+		"no period":          {},
+		"negative period":    {Period: -5, Align: true},
+		"period too long":    {Period: maxPeriodSec + 1},
+		"huge period":        {Period: 1 << 40, Align: true},
+		"cron bad fallback":  {Period: -1, Cron: "* * * * *"},
+		"window too long":    {Period: 60, Windows: []model.ScheduleWindow{{From: 0, To: 60, Period: maxPeriodSec + 1}}},
+		"empty window":       {Period: 60, Windows: []model.ScheduleWindow{{From: 600, To: 600, Period: 5}}},
+		"window w/o period":  {Period: 60, Windows: []model.ScheduleWindow{{From: 0, To: 60}}},
+		"bad window tz":      {Period: 60, Windows: []model.ScheduleWindow{{From: 0, To: 60, TZ: "Mars/Olympus", Period: 5}}},
+	} {
+		_, err := buildSchedule(w)
+		require.ErrorIs(t, err, model.ErrInvalidSchedule, name)
+	}
+
+	s, err := buildSchedule(model.WatchItem{Period: 60, Align: true})
+	require.NoError(t, err)
+	require.Equal(t, fixedSchedule{every: time.Minute, align: true}, s)
+	_, err = buildSchedule(model.WatchItem{Period: 60, Windows: nyse})
+	require.NoError(t, err)
+}
+
+func TestCronSchedule(t *testing.T) {
+	s, err := buildSchedule(model.WatchItem{Period: 60, Cron: "*/15 * * * * *"})
+	require.NoError(t, err)
+	now := time.Date(2026, 10, 19, 12, 0, 7, 0, time.UTC)
+	require.Equal(t, time.Date(2026, 10, 19, 12, 0, 15, 0, time.UTC), s.next(time.Time{}, now))
+	require.Equal(t, 15*time.Second, s.period(now))
+
+	// без префикса — UTC, с префиксом — своя зона
+	s, err = buildSchedule(model.WatchItem{Cron: "0 9 * * *"})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC), s.next(time.Time{}, now))
+	require.Equal(t, 24*time.Hour, s.period(now))
+
+	s, err = buildSchedule(model.WatchItem{Cron: "CRON_TZ=America/New_York 0 9 * * *"})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC), s.next(time.Time{}, now).UTC())
+}
+
+func TestWindow_ActiveAndNextStart(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// пн–пт 09:30–16:00 по Нью-Йорку
+	w, err := newWindow(model.ScheduleWindow{
+		Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		From: 9*60 + 30, To: 16 * 60, TZ: "America/New_York", Period: 5,
+	})
+	require.NoError(t, err)
+
+	mon := func(h, m int) time.Time { return time.Date(2026, 10, 19, h, m, 0, 0, ny) }
+	require.Equal(t, time.Monday, mon(0, 0).Weekday())
+	require.False(t, w.active(mon(9, 29)))
+	require.True(t, w.active(mon(9, 30)))
+	require.True(t, w.active(mon(15, 59)))
+	require.False(t, w.active(mon(16, 0)), "to is exclusive")
+	require.False(t, w.active(mon(12, 0).AddDate(0, 0, -1)), "sunday")
+
+	require.True(t, w.nextStart(mon(8, 0)).Equal(mon(9, 30)))
+	require.True(t, w.nextStart(mon(9, 30)).Equal(mon(9, 30).AddDate(0, 0, 1)), "strictly after t")
+	// пятница вечером — следующий понедельник
+	require.True(t, w.nextStart(mon(17, 0).AddDate(0, 0, 4)).Equal(mon(9, 30).AddDate(0, 0, 7)))
+}
+
+func TestWindowSchedule_Next(t *testing.T) {
+	s, err := buildSchedule(model.WatchItem{Period: 300, Windows: []model.ScheduleWindow{{From: 10 * 60, To: 11 * 60, Period: 5}}})
+	require.NoError(t, err)
+	at := func(h, m, sec int) time.Time { return time.Date(2026, 10, 19, h, m, sec, 0, time.UTC) }
+
+	// вне окна — базовый период
+	require.Equal(t, 300*time.Second, s.period(at(9, 0, 0)))
+	require.Equal(t, at(9, 5, 0), s.next(at(9, 0, 0), at(9, 0, 1)))
+	// базовый тик пришёлся бы на 10:03 — начало окна раньше
+	require.Equal(t, at(10, 0, 0), s.next(at(9, 58, 0), at(9, 58, 1)))
+	// в окне — период окна
+	require.Equal(t, 5*time.Second, s.period(at(10, 30, 0)))
+	require.Equal(t, at(10, 30, 5), s.next(at(10, 30, 0), at(10, 30, 1)))
+	// после окна — снова базовый
+	require.Equal(t, at(11, 5, 0), s.next(at(11, 0, 0), at(11, 0, 1)))
+}
+
+func TestParseWindows(t *testing.T) {
+	got, err := parseWindows([]model.ScheduleWindowDTO{
+		{Days: []string{"mon", "Friday"}, From: "09:30", To: "24:00", TZ: " Europe/London ", Period: 5},
+		{From: "0:00", To: "1:15", Period: 10},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []model.ScheduleWindow{
+		{Days: []time.Weekday{time.Monday, time.Friday}, From: 570, To: 1440, TZ: "Europe/London", Period: 5},
+		{From: 0, To: 75, Period: 10},
+	}, got)
+
+	for _, dto := range []model.ScheduleWindowDTO{
+		{Days: []string{"funday"}, From: "09:00", To: "10:00"},
+		{From: "9", To: "10:00"},
+		{From: "09:60", To: "10:00"},
+		{From: "09:00", To: "24:01"},
+	} {
+		_, err := parseWindows([]model.ScheduleWindowDTO{dto})
+		require.ErrorIs(t, err, model.ErrInvalidSchedule, dto)
+	}
+}
@@ -3,49 +3,364 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
-	"crypto-observer/internal/coingecko"
 	"crypto-observer/internal/model"
 	"crypto-observer/pkg/logger"
 )
 
 type Storage interface {
-	SavePrice(ctx context.Context, p model.Price) error
-	GetClosestPrice(ctx context.Context, symbol string, ts int64) (*model.Price, error)
+	SavePrice(ctx context.Context, p model.Price) (int64, error)
+	GetClosestPrice(ctx context.Context, symbol, quote string, ts int64) (*model.Price, error)
+	GetCandles(ctx context.Context, symbol, quote string, bucketSec, from, to int64) ([]model.Candle, error)
+	GetPriceRange(ctx context.Context, symbol, quote string, from, to int64, after *model.HistoryCursor, limit int) (*model.HistoryPage, error)
+	GetPricesSince(ctx context.Context, symbols []string, after model.HistoryCursor, limit int) ([]model.Price, error)
+	FindGaps(ctx context.Context, symbol, quote string, from, to, minGap int64, limit int) ([]model.Gap, error)
+
+	UpsertWatch(ctx context.Context, w model.WatchItem) error
+	UpdateWatch(ctx context.Context, w model.WatchItem) error
+	SetWatchPaused(ctx context.Context, symbol string, paused bool) error
+	ListWatchlist(ctx context.Context) ([]model.WatchItem, error)
+
+	UpsertSymbolMapping(ctx context.Context, m model.SymbolMapping) error
+	DeleteSymbolMapping(ctx context.Context, symbol string) error
+	ListSymbolMappings(ctx context.Context) ([]model.SymbolMapping, error)
+
+	InsertAlert(ctx context.Context, a model.Alert) (int64, error)
+	UpdateAlert(ctx context.Context, a model.Alert) error
+	DeleteAlert(ctx context.Context, id int64) error
+	ListAlerts(ctx context.Context) ([]model.Alert, error)
+
+	Ping(ctx context.Context) error
+}
+
+// Границы размера страницы истории
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// Сколько сохранённых цен максимум досылаем при возобновлении потока
+const maxReplay = 1000
+
+// Поддерживаемые интервалы свечей (секунды в бакете)
+var candleIntervals = map[string]int64{
+	"1m": 60,
+	"5m": 5 * 60,
+	"1h": 60 * 60,
+	"1d": 24 * 60 * 60,
 }
 
+// Параметры диапазона свечей
+const (
+	defaultCandles = 100
+	maxCandles     = 5000
+)
+
 type Service struct {
 	st         Storage
-	collectors map[string]*collector
 	defaultPer int
-	priceCli   *coingecko.Client
+	providers  *ProviderRegistry
+	catalog    *CoinCatalog
+	symbols    *SymbolRegistry
+	hub        *Hub
+	alerts     *alertBook
+	webhooks   *Webhooks
+	backfill   *Backfiller
+	gapOpts    GapAuditOptions
+
+	// ctx живёт до Shutdown: его отмена останавливает коллекторы и обрывает их запросы к провайдерам.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // горутины коллекторов, в том числе уже убранных из collectors
+
+	mu         sync.RWMutex // защищает collectors; запись держится на всю операцию add/update/remove
+	collectors map[string]*collector
 }
 
-func NewService(st Storage, defaultPeriod int, cgBaseURL string, timeout time.Duration) *Service {
+func NewService(st Storage, defaultPeriod int, providers *ProviderRegistry, symbols *SymbolRegistry) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Service{
+		ctx:        ctx,
+		cancel:     cancel,
 		st:         st,
 		collectors: make(map[string]*collector),
 		defaultPer: defaultPeriod,
-		priceCli:   coingecko.New(cgBaseURL, timeout),
+		providers:  providers,
+		symbols:    symbols,
+		hub:        NewHub(),
+		alerts:     newAlertBook(),
+		gapOpts:    GapAuditOptions{}.withDefaults(),
 	}
 }
 
-func (s *Service) AddCurrency(symbol string, periodSec int) error {
+// AddCurrency начинает отслеживать тикер. Если подключён справочник монет,
+// тикер сначала проверяется по нему: неизвестный или неоднозначный —
+// *model.SymbolError с вариантами, коллектор не создаётся.
+// Quotes пустой — собираем только model.DefaultQuote; кривая валюта — model.ErrInvalidQuote.
+// Расписание (period/align, cron или окна) проверяется до записи — model.ErrInvalidSchedule.
+// Работающий коллектор не перезапускается, но его запись watchlist всегда снимается с паузы.
+// Тикер приводится к нижнему регистру: под ним живут коллектор, цены, правила и поток.
+func (s *Service) AddCurrency(req model.AddReq) error {
+	symbol, periodSec := normSymbol(req.Symbol), req.Period
 	if periodSec <= 0 {
 		periodSec = s.defaultPer
 	}
-	if c, ok := s.collectors[symbol]; ok && c.Running() {
+	quotes, err := normQuotes(req.Quotes)
+	if err != nil {
+		return err
+	}
+	windows, err := parseWindows(req.Windows)
+	if err != nil {
+		return err
+	}
+	plan := model.WatchItem{Period: periodSec, Align: req.Align, Cron: strings.TrimSpace(req.Cron), Windows: windows}
+	if _, err := buildSchedule(plan); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx.Err() != nil {
+		return model.ErrShuttingDown
+	}
+	providerID, err := s.resolveID(symbol, req.ProviderID)
+	if err != nil {
+		return err
+	}
+	w := plan
+	w.Symbol, w.ProviderID, w.Quotes, w.CreatedAt = symbol, providerID, quotes, time.Now().Unix()
+	c, ok := s.collectors[symbol]
+	running := ok && c.Running()
+	if running {
+		// работающий коллектор не трогаем, но запись watchlist приводим к нему и снимаем с паузы
+		cur := c.schedulePlan()
+		w.Period, w.Align, w.Cron, w.Windows, w.Quotes = cur.Period, cur.Align, cur.Cron, cur.Windows, c.quoteList()
+	}
+	if err := s.st.UpsertWatch(context.Background(), w); err != nil {
+		return err
+	}
+	if running {
 		return nil
 	}
-	c := newCollector(symbol, time.Duration(periodSec)*time.Second, s.st, s.priceCli)
+	if providerID != "" {
+		s.symbols.set(model.SymbolMapping{Symbol: symbol, ProviderID: providerID, Source: SymbolSourceWatchlist})
+	}
+	s.startCollector(w)
+	logger.L().WithFields(logger.Fields{"symbol": symbol, "provider_id": providerID, "quotes": quotes}).Info("Service: AddCurrency")
+	return nil
+}
+
+// normQuote приводит валюту котировки к нижнему регистру; пустая — model.DefaultQuote.
+// Допустимы только латинские буквы, 3–10 символов (usd, eur, btc, usdt).
+func normQuote(q string) (string, error) {
+	q = strings.ToLower(strings.TrimSpace(q))
+	if q == "" {
+		return model.DefaultQuote, nil
+	}
+	if len(q) < 3 || len(q) > 10 {
+		return "", fmt.Errorf("%w: %q", model.ErrInvalidQuote, q)
+	}
+	for _, r := range q {
+		if r < 'a' || r > 'z' {
+			return "", fmt.Errorf("%w: %q", model.ErrInvalidQuote, q)
+		}
+	}
+	return q, nil
+}
+
+// normQuotes нормализует список валют и убирает повторы, сохраняя порядок.
+func normQuotes(qs []string) ([]string, error) {
+	if len(qs) == 0 {
+		return []string{model.DefaultQuote}, nil
+	}
+	out := make([]string, 0, len(qs))
+	seen := make(map[string]bool, len(qs))
+	for _, q := range qs {
+		n, err := normQuote(q)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+// UseCatalog включает проверку тикеров по справочнику монет провайдера.
+func (s *Service) UseCatalog(c *CoinCatalog) { s.catalog = c }
+
+// UseWebhooks включает исходящие уведомления о срабатывании правил и сбоях коллекторов.
+func (s *Service) UseWebhooks(w *Webhooks) { s.webhooks = w }
+
+// UseBackfill включает догрузку истории цен через /currency/backfill.
+func (s *Service) UseBackfill(b *Backfiller) { s.backfill = b }
+
+func (s *Service) notify(event string, data any) {
+	if s.webhooks != nil {
+		s.webhooks.Notify(event, data)
+	}
+}
+
+func (s *Service) resolveID(symbol, providerID string) (string, error) {
+	if providerID == "" {
+		// явное соответствие из реестра снимает неоднозначность тикера
+		if m, ok := s.symbols.Lookup(symbol); ok {
+			return m.ProviderID, nil
+		}
+	}
+	if s.catalog == nil || !s.catalog.Loaded() {
+		// без справочника верим на слово; явный id всё равно запоминаем
+		return providerID, nil
+	}
+	return s.catalog.Resolve(symbol, providerID)
+}
+
+// Restore загружает админские соответствия тикеров и правила оповещения, поднимает коллекторы
+// для всех не приостановленных записей watchlist.
+// Вызывается один раз при старте приложения.
+func (s *Service) Restore(ctx context.Context) error {
+	mappings, err := s.st.ListSymbolMappings(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range mappings {
+		m.Source = SymbolSourceAdmin
+		s.symbols.set(m)
+	}
+
+	alerts, err := s.st.ListAlerts(ctx)
+	if err != nil {
+		return err
+	}
+	s.alerts.mu.Lock()
+	for _, a := range alerts {
+		s.alerts.byID[a.ID] = &a
+	}
+	s.alerts.mu.Unlock()
+
+	items, err := s.st.ListWatchlist(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range items {
+		if w.Paused {
+			continue
+		}
+		if w.ProviderID != "" {
+			s.symbols.set(model.SymbolMapping{Symbol: w.Symbol, ProviderID: w.ProviderID, Source: SymbolSourceWatchlist})
+		}
+		// запасной период startCollector должен быть рабочим, даже если в БД мусор
+		if checkPeriod(w.Period) != nil {
+			w.Period = s.defaultPer
+		}
+		s.startCollector(w)
+	}
+	logger.L().WithField("count", len(items)).Info("Service: Restore")
+	return nil
+}
+
+// startCollector вызывается под s.mu. Расписание, которое не собирается (например, из БД
+// с зоной, которой больше нет в tzdata), заменяется периодом w.Period.
+func (s *Service) startCollector(w model.WatchItem) {
+	symbol := w.Symbol
+	pc, name := s.providers.For(symbol)
+	logger.L().WithFields(logger.Fields{"symbol": symbol, "provider": name}).Debug("Service: provider selected")
+	c := newCollector(symbol, w.Quotes, time.Duration(w.Period)*time.Second, s.st, pc)
+	if sched, err := buildSchedule(w); err != nil {
+		logger.L().WithError(err).WithField("symbol", symbol).Error("Service: bad schedule, falling back to period")
+	} else {
+		c.plan, c.sched = model.WatchItem{Period: w.Period, Align: w.Align, Cron: w.Cron, Windows: w.Windows}, sched
+	}
+	c.provider = name
+	c.ctx, c.wg = s.ctx, &s.wg
+	c.pub = publisherFunc(s.onPriceSaved)
+	c.onFail = func(state string, err error) {
+		s.notify(model.EventCollectorError, model.CollectorErrorData{Symbol: symbol, State: state, Error: err.Error()})
+	}
 	s.collectors[symbol] = c
 	c.Start()
-	logger.L().WithField("symbol", symbol).Info("Service: AddCurrency")
-	return nil
+}
+
+// onPriceSaved — всё, что происходит с ценой после сохранения: рассылка
+// подписчикам потока и проверка правил оповещения.
+func (s *Service) onPriceSaved(p model.Price) {
+	s.hub.Publish(p)
+	s.evaluateAlerts(p)
+}
+
+// UpdateCurrency меняет расписание (период, выравнивание, cron, окна) и/или валюты котировки
+// отслеживаемого тикера на работающем коллекторе — без перезапуска, так что в ряду цен
+// не появляется дыр. Нулевые поля запроса не меняются; тикера нет или он снят через
+// /currency/remove — model.ErrNotTracked (запись watchlist на паузе не трогаем).
+// Период вне 1..maxPeriodSec — model.ErrInvalidPeriod.
+func (s *Service) UpdateCurrency(symbol string, req model.UpdateReq) (*model.CollectorStatus, error) {
+	symbol = normSymbol(symbol)
+	if req.Period != 0 {
+		if err := checkPeriod(req.Period); err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidPeriod, err)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.collectors[symbol]
+	if !ok || !c.Running() {
+		return nil, fmt.Errorf("%w: %s", model.ErrNotTracked, symbol)
+	}
+	plan := c.schedulePlan()
+	if req.Period > 0 {
+		plan.Period = req.Period
+	}
+	if req.Align != nil {
+		plan.Align = *req.Align
+	}
+	if req.Cron != nil {
+		plan.Cron = strings.TrimSpace(*req.Cron)
+	}
+	if req.Windows != nil {
+		windows, err := parseWindows(req.Windows)
+		if err != nil {
+			return nil, err
+		}
+		plan.Windows = windows
+	}
+	sched, err := buildSchedule(plan)
+	if err != nil {
+		return nil, err
+	}
+	quotes := c.quoteList()
+	if req.Quotes != nil {
+		var err error
+		if quotes, err = normQuotes(req.Quotes); err != nil {
+			return nil, err
+		}
+	}
+	w := plan
+	w.Symbol, w.Quotes = symbol, quotes
+	if err := s.st.UpdateWatch(context.Background(), w); err != nil {
+		return nil, err
+	}
+	c.SetQuotes(quotes)
+	c.SetSchedule(plan, sched)
+	logger.L().WithFields(logger.Fields{"symbol": symbol, "period": plan.Period, "align": plan.Align, "cron": plan.Cron, "windows": len(plan.Windows), "quotes": quotes}).Info("Service: UpdateCurrency")
+	st := c.info()
+	return &st, nil
 }
 
 func (s *Service) RemoveCurrency(symbol string) error {
+	symbol = normSymbol(symbol)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.st.SetWatchPaused(context.Background(), symbol, true); err != nil {
+		return err
+	}
 	if c, ok := s.collectors[symbol]; ok {
 		c.Stop()
 	}
@@ -53,15 +368,280 @@ func (s *Service) RemoveCurrency(symbol string) error {
 	return nil
 }
 
-func (s *Service) GetPrice(symbol string, ts int64) (*model.Price, error) {
+func (s *Service) ListSymbols() []model.SymbolMapping {
+	return s.symbols.List()
+}
+
+// SetSymbol сохраняет админское соответствие тикер → id.
+// Без override существующее соответствие на другой id не меняется (model.ErrSymbolExists).
+// Работающие коллекторы подхватывают новый id со следующего тика.
+func (s *Service) SetSymbol(symbol, providerID string, override bool) error {
+	symbol, providerID = normSymbol(symbol), strings.TrimSpace(providerID)
+	if !override {
+		if cur, ok := s.symbols.Lookup(symbol); ok && cur.ProviderID != providerID {
+			return fmt.Errorf("%w: %s -> %s", model.ErrSymbolExists, symbol, cur.ProviderID)
+		}
+	}
+	if s.catalog != nil && !s.catalog.Known(providerID) {
+		return &model.SymbolError{Symbol: providerID, Err: model.ErrUnknownCoin, Suggestions: s.catalog.Similar(providerID)}
+	}
+	m := model.SymbolMapping{Symbol: symbol, ProviderID: providerID, Source: SymbolSourceAdmin, UpdatedAt: time.Now().Unix()}
+	if err := s.st.UpsertSymbolMapping(context.Background(), m); err != nil {
+		return err
+	}
+	s.symbols.set(m)
+	logger.L().WithFields(logger.Fields{"symbol": symbol, "provider_id": providerID}).Info("Service: SetSymbol")
+	return nil
+}
+
+// DeleteSymbol убирает админское соответствие; тикер возвращается к файлу/встроенной карте.
+func (s *Service) DeleteSymbol(symbol string) error {
+	if err := s.st.DeleteSymbolMapping(context.Background(), normSymbol(symbol)); err != nil {
+		return err
+	}
+	s.symbols.remove(symbol)
+	logger.L().WithField("symbol", symbol).Info("Service: DeleteSymbol")
+	return nil
+}
+
+// Status возвращает состояние коллектора; nil, если тикер не отслеживается.
+func (s *Service) Status(symbol string) *model.CollectorStatus {
+	s.mu.RLock()
+	c, ok := s.collectors[normSymbol(symbol)]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	st := c.info()
+	return &st
+}
+
+// ListCollectors — все тикеры, по которым создавались коллекторы (в том числе
+// остановленные через /currency/remove), по алфавиту.
+func (s *Service) ListCollectors() []model.CollectorStatus {
+	var out []model.CollectorStatus
+	for _, c := range s.collectorList() {
+		out = append(out, c.info())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out
+}
+
+// collectorList — копия collectors, чтобы не держать s.mu, пока опрашиваем сами коллекторы.
+func (s *Service) collectorList() []*collector {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*collector, 0, len(s.collectors))
+	for _, c := range s.collectors {
+		out = append(out, c)
+	}
+	return out
+}
+
+// Context отменяется в Shutdown. К нему привязывают то, что должно жить ровно столько же,
+// сколько коллекторы, — например, пакетные запросы Batcher.
+func (s *Service) Context() context.Context { return s.ctx }
+
+// Shutdown останавливает все коллекторы и ждёт, пока они закончат текущий тик.
+// Запросы к провайдерам обрываются сразу, а начатые сохранения в БД доводятся до конца —
+// чтобы не терять уже полученные цены. Ждём не дольше ctx; новые AddCurrency после
+// вызова отклоняются с model.ErrShuttingDown.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		logger.L().Info("Service: collectors stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SubscribePrices подписывает на цены тикеров по мере их сохранения коллекторами.
+// Пустой список — все тикеры. cancel обязателен, когда подписка больше не нужна.
+func (s *Service) SubscribePrices(symbols []string) (<-chan model.Price, func()) {
+	return s.hub.Subscribe(symbols)
+}
+
+// PricesSince — сохранённые цены тикеров строго после позиции after по (ts, id), для досылки
+// пропущенного при переподключении потока. Не больше maxReplay строк.
+func (s *Service) PricesSince(symbols []string, after model.HistoryCursor) ([]model.Price, error) {
+	logger.L().WithFields(logger.Fields{
+		"symbols": symbols,
+		"ts":      after.TS,
+		"id":      after.ID,
+	}).Info("Service: PricesSince")
+
+	norm := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		norm = append(norm, normSymbol(sym))
+	}
+	return s.st.GetPricesSince(context.Background(), norm, after, maxReplay)
+}
+
+// Границы журнала доставок вебхуков
+const (
+	defaultDeliveries = 50
+	maxDeliveries     = 500
+)
+
+// WebhookDeliveries — журнал доставок вебхуков, новые сначала; status пустой — все.
+// Без настроенных вебхуков журнал пуст.
+func (s *Service) WebhookDeliveries(status string, limit int) ([]model.WebhookDelivery, error) {
+	switch status {
+	case "", model.DeliveryPending, model.DeliveryDelivered, model.DeliveryFailed:
+	default:
+		return nil, fmt.Errorf("%w: %q", model.ErrInvalidDeliveryStatus, status)
+	}
+	if s.webhooks == nil {
+		return []model.WebhookDelivery{}, nil
+	}
+	if limit <= 0 {
+		limit = defaultDeliveries
+	}
+	return s.webhooks.Deliveries(status, min(limit, maxDeliveries))
+}
+
+// Границы списка задач догрузки истории
+const (
+	defaultBackfillJobs = 50
+	maxBackfillJobs     = 500
+)
+
+// StartBackfill ставит в очередь догрузку истории тикера за [From, To].
+// id монеты определяется так же, как в AddCurrency; неизвестен — *model.SymbolError
+// или model.ErrInvalidBackfill. Без настроенной догрузки — model.ErrBackfillUnavailable.
+func (s *Service) StartBackfill(req model.BackfillReq) (*model.BackfillJob, error) {
+	if s.backfill == nil {
+		return nil, model.ErrBackfillUnavailable
+	}
+	symbol := normSymbol(req.Symbol)
+	if symbol == "" {
+		return nil, fmt.Errorf("%w: symbol is required", model.ErrInvalidBackfill)
+	}
+	quote, err := normQuote(req.Quote)
+	if err != nil {
+		return nil, err
+	}
+	providerID, err := s.resolveID(symbol, strings.TrimSpace(req.ProviderID))
+	if err != nil {
+		return nil, err
+	}
+	return s.backfill.Submit(model.BackfillJob{
+		Symbol: symbol, ProviderID: providerID, Quote: quote, From: req.From, To: req.To,
+	})
+}
+
+// BackfillJob — состояние задачи догрузки; нет такой — model.ErrBackfillNotFound.
+func (s *Service) BackfillJob(id int64) (*model.BackfillJob, error) {
+	if s.backfill == nil {
+		return nil, model.ErrBackfillNotFound
+	}
+	j, err := s.backfill.Job(id)
+	if err != nil {
+		return nil, err
+	}
+	if j == nil {
+		return nil, model.ErrBackfillNotFound
+	}
+	return j, nil
+}
+
+// BackfillJobs — задачи догрузки, новые сначала; symbol пустой — все.
+func (s *Service) BackfillJobs(symbol string, limit int) ([]model.BackfillJob, error) {
+	if s.backfill == nil {
+		return []model.BackfillJob{}, nil
+	}
+	if limit <= 0 {
+		limit = defaultBackfillJobs
+	}
+	return s.backfill.Jobs(normSymbol(symbol), min(limit, maxBackfillJobs))
+}
+
+// GetPrice отдаёт ближайшую к ts цену в валюте quote (пусто — model.DefaultQuote).
+func (s *Service) GetPrice(symbol, quote string, ts int64) (*model.Price, error) {
+	symbol = normSymbol(symbol)
 	logger.L().WithFields(logger.Fields{
 		"symbol": symbol,
+		"quote":  quote,
 		"ts":     ts,
 	}).Info("Service: GetPrice")
 
+	quote, err := normQuote(quote)
+	if err != nil {
+		return nil, err
+	}
 	// если ts == 0 — используем текущий момент
 	if ts == 0 {
 		ts = time.Now().Unix()
 	}
-	return s.st.GetClosestPrice(context.Background(), symbol, ts)
+	return s.st.GetClosestPrice(context.Background(), symbol, quote, ts)
+}
+
+// GetHistory отдаёт страницу ряда цен за [from, to].
+// to == 0 — до текущего момента; limit ограничивается maxHistoryLimit.
+func (s *Service) GetHistory(symbol, quote string, from, to int64, limit int, after *model.HistoryCursor) (*model.HistoryPage, error) {
+	symbol = normSymbol(symbol)
+	logger.L().WithFields(logger.Fields{
+		"symbol": symbol,
+		"quote":  quote,
+		"from":   from,
+		"to":     to,
+		"limit":  limit,
+	}).Info("Service: GetHistory")
+
+	quote, err := normQuote(quote)
+	if err != nil {
+		return nil, err
+	}
+	if to == 0 {
+		to = time.Now().Unix()
+	}
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+	return s.st.GetPriceRange(context.Background(), symbol, quote, from, to, after, limit)
+}
+
+// GetCandles строит OHLC-свечи по сырым ценам.
+// to == 0 — до текущего момента; from == 0 — последние defaultCandles бакетов.
+func (s *Service) GetCandles(symbol, quote, interval string, from, to int64) ([]model.Candle, error) {
+	symbol = normSymbol(symbol)
+	logger.L().WithFields(logger.Fields{
+		"symbol":   symbol,
+		"quote":    quote,
+		"interval": interval,
+		"from":     from,
+		"to":       to,
+	}).Info("Service: GetCandles")
+
+	quote, err := normQuote(quote)
+	if err != nil {
+		return nil, err
+	}
+	bucket, ok := candleIntervals[interval]
+	if !ok {
+		return nil, model.ErrInvalidInterval
+	}
+	if to == 0 {
+		to = time.Now().Unix()
+	}
+	if from == 0 {
+		from = (to/bucket - defaultCandles + 1) * bucket
+	}
+	if (to-from)/bucket >= maxCandles {
+		return nil, model.ErrRangeTooLarge
+	}
+	return s.st.GetCandles(context.Background(), symbol, quote, bucket, from, to)
 }
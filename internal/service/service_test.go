@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -17,32 +18,230 @@ import (
 type fakeStorage struct {
 	mu        sync.Mutex
 	gotSym    string
+	gotQuote  string
 	gotTS     int64
 	retPrice  *model.Price
 	retErr    error
 	saveCalls int
+
+	gotRange struct {
+		from, to int64
+		after    *model.HistoryCursor
+		limit    int
+	}
+	retPage *model.HistoryPage
+
+	gotSince struct {
+		symbols []string
+		after   model.HistoryCursor
+		limit   int
+	}
+	retSince []model.Price
+
+	gotCandles struct {
+		bucket, from, to int64
+	}
+	retCandles []model.Candle
+
+	gotGaps struct {
+		from, to, minGap int64
+		limit            int
+	}
+	retGaps []model.Gap // отдаются только по своей валюте
+
+	mappings map[string]model.SymbolMapping
+	mapErr   error
+
+	watch     map[string]model.WatchItem
+	watchErr  error
+	listItems []model.WatchItem
+
+	alerts   map[int64]model.Alert
+	alertSeq int64
+	alertErr error
+
+	pingErr error
 }
 
-func (f *fakeStorage) SavePrice(ctx context.Context, p model.Price) error {
+func (f *fakeStorage) SavePrice(ctx context.Context, p model.Price) (int64, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	f.saveCalls++
-	return nil
+	return int64(f.saveCalls), nil
 }
 
-func (f *fakeStorage) GetClosestPrice(ctx context.Context, symbol string, ts int64) (*model.Price, error) {
+func (f *fakeStorage) GetClosestPrice(ctx context.Context, symbol, quote string, ts int64) (*model.Price, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	f.gotSym = symbol
+	f.gotSym, f.gotQuote = symbol, quote
 	f.gotTS = ts
 	return f.retPrice, f.retErr
 }
 
+func (f *fakeStorage) GetPriceRange(ctx context.Context, symbol, quote string, from, to int64, after *model.HistoryCursor, limit int) (*model.HistoryPage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gotSym, f.gotQuote = symbol, quote
+	f.gotRange.from, f.gotRange.to = from, to
+	f.gotRange.after, f.gotRange.limit = after, limit
+	return f.retPage, f.retErr
+}
+
+func (f *fakeStorage) GetPricesSince(ctx context.Context, symbols []string, after model.HistoryCursor, limit int) ([]model.Price, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gotSince.symbols, f.gotSince.after, f.gotSince.limit = symbols, after, limit
+	return f.retSince, f.retErr
+}
+
+func (f *fakeStorage) GetCandles(ctx context.Context, symbol, quote string, bucketSec, from, to int64) ([]model.Candle, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gotSym, f.gotQuote = symbol, quote
+	f.gotCandles.bucket, f.gotCandles.from, f.gotCandles.to = bucketSec, from, to
+	return f.retCandles, f.retErr
+}
+
+func (f *fakeStorage) FindGaps(ctx context.Context, symbol, quote string, from, to, minGap int64, limit int) ([]model.Gap, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gotSym = symbol
+	f.gotGaps.from, f.gotGaps.to, f.gotGaps.minGap, f.gotGaps.limit = from, to, minGap, limit
+	var out []model.Gap
+	for _, g := range f.retGaps {
+		if g.Quote == quote {
+			out = append(out, g)
+		}
+	}
+	return out, f.retErr
+}
+
+func (f *fakeStorage) UpsertWatch(ctx context.Context, w model.WatchItem) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.watchErr != nil {
+		return f.watchErr
+	}
+	if f.watch == nil {
+		f.watch = make(map[string]model.WatchItem)
+	}
+	f.watch[w.Symbol] = w
+	return nil
+}
+
+func (f *fakeStorage) UpdateWatch(ctx context.Context, u model.WatchItem) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.watchErr != nil {
+		return f.watchErr
+	}
+	if w, ok := f.watch[u.Symbol]; ok {
+		w.Period, w.Quotes, w.Align, w.Cron, w.Windows = u.Period, u.Quotes, u.Align, u.Cron, u.Windows
+		f.watch[u.Symbol] = w
+	}
+	return nil
+}
+
+func (f *fakeStorage) SetWatchPaused(ctx context.Context, symbol string, paused bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.watchErr != nil {
+		return f.watchErr
+	}
+	if w, ok := f.watch[symbol]; ok {
+		w.Paused = paused
+		f.watch[symbol] = w
+	}
+	return nil
+}
+
+func (f *fakeStorage) ListWatchlist(ctx context.Context) ([]model.WatchItem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.listItems, f.watchErr
+}
+
+func (f *fakeStorage) UpsertSymbolMapping(ctx context.Context, m model.SymbolMapping) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.mapErr != nil {
+		return f.mapErr
+	}
+	if f.mappings == nil {
+		f.mappings = make(map[string]model.SymbolMapping)
+	}
+	f.mappings[m.Symbol] = m
+	return nil
+}
+
+func (f *fakeStorage) DeleteSymbolMapping(ctx context.Context, symbol string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.mappings, symbol)
+	return f.mapErr
+}
+
+func (f *fakeStorage) ListSymbolMappings(ctx context.Context) ([]model.SymbolMapping, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []model.SymbolMapping
+	for _, m := range f.mappings {
+		out = append(out, m)
+	}
+	return out, f.mapErr
+}
+
+func (f *fakeStorage) InsertAlert(ctx context.Context, a model.Alert) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.alertErr != nil {
+		return 0, f.alertErr
+	}
+	if f.alerts == nil {
+		f.alerts = make(map[int64]model.Alert)
+	}
+	f.alertSeq++
+	a.ID = f.alertSeq
+	f.alerts[a.ID] = a
+	return a.ID, nil
+}
+
+func (f *fakeStorage) UpdateAlert(ctx context.Context, a model.Alert) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.alertErr != nil {
+		return f.alertErr
+	}
+	f.alerts[a.ID] = a
+	return nil
+}
+
+func (f *fakeStorage) DeleteAlert(ctx context.Context, id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.alerts, id)
+	return f.alertErr
+}
+
+func (f *fakeStorage) ListAlerts(ctx context.Context) ([]model.Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []model.Alert
+	for _, a := range f.alerts {
+		out = append(out, a)
+	}
+	return out, f.alertErr
+}
+
+func (f *fakeStorage) Ping(ctx context.Context) error { return f.pingErr }
+
 // ---- helpers ----
 
 func newSvcWith(storage Storage) *Service {
-	// baseURL/timeout не важны — в тестах не даём коллектору тикаать
-	return NewService(storage /*defaultPeriod*/, 1, "http://localhost", 5*time.Second)
+	// провайдер не важен — в тестах не даём коллектору тикаать
+	reg := NewProviderRegistry()
+	reg.Register("fake", &fakePriceClient{})
+	return NewService(storage /*defaultPeriod*/, 1, reg, NewSymbolRegistry())
 }
 
 func sleepMS(ms int) { time.Sleep(time.Duration(ms) * time.Millisecond) }
@@ -50,35 +249,44 @@ func sleepMS(ms int) { time.Sleep(time.Duration(ms) * time.Millisecond) }
 // ---- tests ----
 
 func TestService_GetPrice_ZeroTS_UsesNow(t *testing.T) {
-	fs := &fakeStorage{retPrice: &model.Price{Symbol: "btc", TS: 1, Price: 2}}
+	fs := &fakeStorage{retPrice: &model.Price{Symbol: "btc", TS: 1, Price: model.NewDecimal(2, 0)}}
 	s := newSvcWith(fs)
 
 	start := time.Now().Unix()
-	got, err := s.GetPrice("btc", 0)
+	got, err := s.GetPrice("btc", "", 0)
 	require.NoError(t, err)
 	require.Equal(t, fs.retPrice, got)
 
-	// проверяем, что в сторадж ушёл ts "примерно сейчас"
+	// проверяем, что в сторадж ушёл ts "примерно сейчас" и валюта по умолчанию
 	require.Equal(t, "btc", fs.gotSym)
+	require.Equal(t, "usd", fs.gotQuote)
 	require.InDelta(t, start, fs.gotTS, 2, "ts should be near now (seconds)")
 }
 
 func TestService_GetPrice_PassesTS(t *testing.T) {
-	fs := &fakeStorage{retPrice: &model.Price{Symbol: "eth", TS: 111, Price: 222}}
+	fs := &fakeStorage{retPrice: &model.Price{Symbol: "eth", TS: 111, Price: model.NewDecimal(222, 0)}}
 	s := newSvcWith(fs)
 
-	got, err := s.GetPrice("eth", 12345)
+	got, err := s.GetPrice("eth", "EUR", 12345)
 	require.NoError(t, err)
 	require.Equal(t, fs.retPrice, got)
 	require.Equal(t, int64(12345), fs.gotTS)
 	require.Equal(t, "eth", fs.gotSym)
+	require.Equal(t, "eur", fs.gotQuote)
+}
+
+func TestService_GetPrice_InvalidQuote(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+
+	_, err := s.GetPrice("btc", "u$d", 0)
+	require.ErrorIs(t, err, model.ErrInvalidQuote)
 }
 
 func TestService_GetPrice_PropagatesError(t *testing.T) {
 	fs := &fakeStorage{retErr: errors.New("db boom")}
 	s := newSvcWith(fs)
 
-	got, err := s.GetPrice("btc", 100)
+	got, err := s.GetPrice("btc", "", 100)
 	require.Error(t, err)
 	require.Nil(t, got)
 }
@@ -88,7 +296,7 @@ func TestService_AddCurrency_StartsCollector_AndRemoveStops(t *testing.T) {
 	s := newSvcWith(fs)
 
 	// длинный период — чтобы тики не успели сработать в тесте
-	err := s.AddCurrency("btc", 3600)
+	err := s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600})
 	require.NoError(t, err)
 
 	c, ok := s.collectors["btc"]
@@ -106,7 +314,7 @@ func TestService_AddCurrency_DefaultPeriodUsed_WhenZero(t *testing.T) {
 	fs := &fakeStorage{}
 	s := newSvcWith(fs)
 	// periodSec <= 0 => берётся defaultPer
-	err := s.AddCurrency("eth", 0)
+	err := s.AddCurrency(model.AddReq{Symbol: "eth", Period: 0})
 	require.NoError(t, err)
 	c, ok := s.collectors["eth"]
 	require.True(t, ok)
@@ -120,15 +328,330 @@ func TestService_AddCurrency_SecondCallDoesNotDuplicate(t *testing.T) {
 	fs := &fakeStorage{}
 	s := newSvcWith(fs)
 
-	require.NoError(t, s.AddCurrency("btc", 3600))
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600}))
 	first := s.collectors["btc"]
 	require.NotNil(t, first)
 
 	// повторный вызов — коллектор уже запущен; должен остаться тем же
-	require.NoError(t, s.AddCurrency("btc", 1))
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 1}))
 	second := s.collectors["btc"]
 
 	require.Same(t, first, second, "should not replace already running collector")
+	require.Equal(t, 3600, fs.watch["btc"].Period, "watchlist keeps the running collector's settings")
+	_ = s.RemoveCurrency("btc")
+	sleepMS(20)
+}
+
+func TestService_AddRemove_PersistsWatchlist(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600}))
+	w, ok := fs.watch["btc"]
+	require.True(t, ok, "AddCurrency must persist symbol")
+	require.Equal(t, 3600, w.Period)
+	require.False(t, w.Paused)
+	require.NotZero(t, w.CreatedAt)
+
+	require.NoError(t, s.RemoveCurrency("btc"))
+	require.True(t, fs.watch["btc"].Paused, "RemoveCurrency must pause symbol")
+	sleepMS(20)
+}
+
+func TestService_RemoveThenAdd_RestartsAtOnce(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600}))
+	old := s.collectors["btc"]
+	// без паузы между вызовами: горутина старого коллектора могла ещё не выйти
+	require.NoError(t, s.RemoveCurrency("btc"))
+	require.False(t, old.Running(), "Stop marks the collector stopped at once")
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600}))
+
+	c := s.collectors["btc"]
+	require.NotSame(t, old, c)
+	require.True(t, c.Running())
+	require.False(t, fs.watch["btc"].Paused)
 	_ = s.RemoveCurrency("btc")
 	sleepMS(20)
 }
+
+func TestService_AddCurrency_Quotes(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+
+	err := s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600, Quotes: []string{"EUR", "usd", "eur"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"eur", "usd"}, fs.watch["btc"].Quotes, "quotes are normalized and deduplicated")
+	require.Equal(t, []string{"eur", "usd"}, s.collectors["btc"].quotes)
+
+	require.ErrorIs(t, s.AddCurrency(model.AddReq{Symbol: "eth", Quotes: []string{"e"}}), model.ErrInvalidQuote)
+	_, ok := s.collectors["eth"]
+	require.False(t, ok)
+
+	_ = s.RemoveCurrency("btc")
+	sleepMS(20)
+}
+
+func TestService_UpdateCurrency(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600}))
+	c := s.collectors["btc"]
+	c.setResult(errors.New("boom"))
+
+	st, err := s.UpdateCurrency("btc", model.UpdateReq{Period: 60})
+	require.NoError(t, err)
+	require.Same(t, c, s.collectors["btc"], "collector is updated in place, not replaced")
+	require.Equal(t, int64(60), st.PeriodSec)
+	require.Equal(t, []string{"usd"}, st.Quotes, "quotes untouched")
+	require.Equal(t, 1, st.Failures, "state survives the update")
+	require.Equal(t, 60, fs.watch["btc"].Period)
+
+	st, err = s.UpdateCurrency("btc", model.UpdateReq{Quotes: []string{"EUR", "usd"}})
+	require.NoError(t, err)
+	require.Equal(t, int64(60), st.PeriodSec, "period untouched")
+	require.Equal(t, []string{"eur", "usd"}, fs.watch["btc"].Quotes)
+
+	_, err = s.UpdateCurrency("eth", model.UpdateReq{Period: 60})
+	require.ErrorIs(t, err, model.ErrNotTracked)
+	_, err = s.UpdateCurrency("btc", model.UpdateReq{Period: -1})
+	require.ErrorIs(t, err, model.ErrInvalidPeriod)
+	_, err = s.UpdateCurrency("btc", model.UpdateReq{Period: maxPeriodSec + 1})
+	require.ErrorIs(t, err, model.ErrInvalidPeriod)
+	require.Equal(t, int64(60), c.info().PeriodSec, "out-of-range period is not applied")
+
+	// регистр и пробелы в пути не мешают найти коллектор
+	st, err = s.UpdateCurrency(" BTC ", model.UpdateReq{Period: 120})
+	require.NoError(t, err)
+	require.Equal(t, int64(120), st.PeriodSec)
+	require.Equal(t, 120, fs.watch["btc"].Period)
+	_, err = s.UpdateCurrency("btc", model.UpdateReq{Period: 60})
+	require.NoError(t, err)
+	_, err = s.UpdateCurrency("btc", model.UpdateReq{Quotes: []string{"x"}})
+	require.ErrorIs(t, err, model.ErrInvalidQuote)
+
+	fs.watchErr = errors.New("db boom")
+	_, err = s.UpdateCurrency("btc", model.UpdateReq{Period: 5})
+	require.Error(t, err)
+	require.Equal(t, int64(60), c.info().PeriodSec, "collector untouched when the write fails")
+
+	fs.watchErr = nil
+	require.NoError(t, s.RemoveCurrency("btc"))
+	_, err = s.UpdateCurrency("btc", model.UpdateReq{Period: 5})
+	require.ErrorIs(t, err, model.ErrNotTracked, "stopped collector is not updated")
+	require.Equal(t, 60, fs.watch["btc"].Period, "paused watch row untouched")
+	sleepMS(20)
+}
+
+func TestService_Align_AddAndUpdate(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600, Align: true}))
+	require.True(t, fs.watch["btc"].Align)
+	require.True(t, s.Status("btc").Aligned)
+
+	off := false
+	st, err := s.UpdateCurrency("btc", model.UpdateReq{Align: &off})
+	require.NoError(t, err)
+	require.False(t, st.Aligned)
+	require.Equal(t, int64(3600), st.PeriodSec)
+	require.False(t, fs.watch["btc"].Align)
+
+	st, err = s.UpdateCurrency("btc", model.UpdateReq{Period: 60})
+	require.NoError(t, err)
+	require.False(t, st.Aligned, "align untouched when omitted")
+
+	_ = s.RemoveCurrency("btc")
+	sleepMS(20)
+}
+
+func TestService_CronAndWindows_AddAndUpdate(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+	err := s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600, Cron: "bogus"})
+	require.ErrorIs(t, err, model.ErrInvalidSchedule)
+	_, ok := fs.watch["btc"]
+	require.False(t, ok, "invalid schedule is not persisted")
+
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600, Cron: " */30 * * * * * "}))
+	require.Equal(t, "*/30 * * * * *", fs.watch["btc"].Cron)
+	require.Equal(t, int64(30), s.Status("btc").PeriodSec)
+
+	// переход на окна: cron сбрасывается тем же запросом
+	none := ""
+	st, err := s.UpdateCurrency("btc", model.UpdateReq{Cron: &none, Windows: []model.ScheduleWindowDTO{
+		{From: "00:00", To: "24:00", Period: 10},
+	}})
+	require.NoError(t, err)
+	require.Empty(t, st.Cron)
+	require.Equal(t, []model.ScheduleWindow{{From: 0, To: 1440, Period: 10}}, st.Windows)
+	require.Equal(t, int64(10), st.PeriodSec, "window period applies all day")
+	require.Equal(t, st.Windows, fs.watch["btc"].Windows)
+
+	every := "* * * * *"
+	_, err = s.UpdateCurrency("btc", model.UpdateReq{Cron: &every})
+	require.ErrorIs(t, err, model.ErrInvalidSchedule, "cron with windows")
+	require.Empty(t, fs.watch["btc"].Cron, "rejected update is not persisted")
+
+	st, err = s.UpdateCurrency("btc", model.UpdateReq{Windows: []model.ScheduleWindowDTO{}})
+	require.NoError(t, err)
+	require.Empty(t, st.Windows)
+	require.Equal(t, int64(3600), st.PeriodSec)
+
+	_ = s.RemoveCurrency("btc")
+	sleepMS(20)
+}
+
+func TestService_AddCurrency_PersistError_NoCollector(t *testing.T) {
+	fs := &fakeStorage{watchErr: errors.New("db boom")}
+	s := newSvcWith(fs)
+
+	require.Error(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600}))
+	_, ok := s.collectors["btc"]
+	require.False(t, ok, "collector must not start when watchlist write fails")
+}
+
+func TestService_Restore_StartsOnlyActive(t *testing.T) {
+	fs := &fakeStorage{listItems: []model.WatchItem{
+		{Symbol: "btc", Period: 3600},
+		{Symbol: "eth", Period: 0},
+		{Symbol: "sol", Period: 3600, Paused: true},
+	}}
+	s := newSvcWith(fs)
+
+	require.NoError(t, s.Restore(context.Background()))
+	require.True(t, s.collectors["btc"].Running())
+	require.Equal(t, time.Hour, s.collectors["btc"].period())
+	require.True(t, s.collectors["eth"].Running())
+	require.Equal(t, time.Second, s.collectors["eth"].period(), "zero period falls back to default")
+	_, ok := s.collectors["sol"]
+	require.False(t, ok, "paused symbols must not be restored")
+
+	_ = s.RemoveCurrency("btc")
+	_ = s.RemoveCurrency("eth")
+	sleepMS(20)
+}
+
+func TestService_Restore_PropagatesError(t *testing.T) {
+	fs := &fakeStorage{watchErr: errors.New("db boom")}
+	s := newSvcWith(fs)
+	require.Error(t, s.Restore(context.Background()))
+}
+
+func TestService_GetHistory_Defaults(t *testing.T) {
+	fs := &fakeStorage{retPage: &model.HistoryPage{}}
+	s := newSvcWith(fs)
+
+	start := time.Now().Unix()
+	got, err := s.GetHistory("btc", "", 10, 0, 0, nil)
+	require.NoError(t, err)
+	require.Same(t, fs.retPage, got)
+	require.Equal(t, "btc", fs.gotSym)
+	require.Equal(t, int64(10), fs.gotRange.from)
+	require.InDelta(t, start, fs.gotRange.to, 2, "to should default to now")
+	require.Equal(t, defaultHistoryLimit, fs.gotRange.limit)
+}
+
+func TestService_GetHistory_ClampsLimit_PassesCursor(t *testing.T) {
+	fs := &fakeStorage{retPage: &model.HistoryPage{}}
+	s := newSvcWith(fs)
+
+	cur := &model.HistoryCursor{TS: 5, ID: 1}
+	_, err := s.GetHistory("eth", "btc", 1, 2, 1_000_000, cur)
+	require.NoError(t, err)
+	require.Equal(t, maxHistoryLimit, fs.gotRange.limit)
+	require.Equal(t, int64(2), fs.gotRange.to)
+	require.Same(t, cur, fs.gotRange.after)
+	require.Equal(t, "btc", fs.gotQuote)
+}
+
+func TestService_PricesSince_NormalizesAndCaps(t *testing.T) {
+	fs := &fakeStorage{retSince: []model.Price{{Symbol: "btc", TS: 11}}}
+	s := newSvcWith(fs)
+
+	got, err := s.PricesSince([]string{" BTC", "eth"}, model.HistoryCursor{TS: 10, ID: 3})
+	require.NoError(t, err)
+	require.Equal(t, fs.retSince, got)
+	require.Equal(t, []string{"btc", "eth"}, fs.gotSince.symbols)
+	require.Equal(t, model.HistoryCursor{TS: 10, ID: 3}, fs.gotSince.after)
+	require.Equal(t, maxReplay, fs.gotSince.limit)
+}
+
+func TestService_GetCandles_PassesBucket(t *testing.T) {
+	fs := &fakeStorage{retCandles: []model.Candle{{Symbol: "btc", TS: 3600}}}
+	s := newSvcWith(fs)
+
+	got, err := s.GetCandles("btc", "", "1h", 3600, 7200)
+	require.NoError(t, err)
+	require.Equal(t, fs.retCandles, got)
+	require.Equal(t, int64(3600), fs.gotCandles.bucket)
+	require.Equal(t, int64(3600), fs.gotCandles.from)
+	require.Equal(t, int64(7200), fs.gotCandles.to)
+}
+
+func TestService_GetCandles_DefaultRange(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+
+	_, err := s.GetCandles("btc", "", "1m", 0, 60*1000)
+	require.NoError(t, err)
+	// последние defaultCandles минутных бакетов, выровненных по границе
+	require.Equal(t, int64(60*(1000-defaultCandles+1)), fs.gotCandles.from)
+}
+
+func TestService_GetCandles_Validation(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+
+	_, err := s.GetCandles("btc", "", "7m", 0, 0)
+	require.ErrorIs(t, err, model.ErrInvalidInterval)
+
+	_, err = s.GetCandles("btc", "", "1m", 1, 60*maxCandles+1)
+	require.ErrorIs(t, err, model.ErrRangeTooLarge)
+}
+
+func TestService_Status(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	require.Nil(t, s.Status("btc"), "untracked symbol has no status")
+
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "btc", Period: 3600}))
+	st := s.Status("btc")
+	require.NotNil(t, st)
+	require.True(t, st.Running)
+	require.Equal(t, StatePending, st.State)
+	require.Empty(t, st.Error)
+
+	s.collectors["btc"].setResult(fmt.Errorf("x: %w", model.ErrUnknownCoin))
+	st = s.Status("btc")
+	require.Equal(t, StateUnknownCoin, st.State)
+	require.Contains(t, st.Error, "unknown coin")
+
+	_ = s.RemoveCurrency("btc")
+	sleepMS(20)
+}
+
+func TestService_Restore_AppliesProviderID(t *testing.T) {
+	fs := &fakeStorage{listItems: []model.WatchItem{{Symbol: "zzz", ProviderID: "zzz-coin", Period: 3600}}}
+	s := newSvcWith(fs)
+
+	require.NoError(t, s.Restore(context.Background()))
+	require.Equal(t, "zzz-coin", s.symbols.CoingeckoID("zzz"))
+
+	_ = s.RemoveCurrency("zzz")
+	sleepMS(20)
+}
+
+func TestService_ListCollectors_Sorted(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	s.collectors["eth"] = newCollector("eth", nil, time.Minute, &memStorage{}, &fakePriceClient{})
+	s.collectors["btc"] = newCollector("btc", []string{"usd", "eur"}, 30*time.Second, &memStorage{}, &fakePriceClient{})
+
+	list := s.ListCollectors()
+	require.Len(t, list, 2)
+	require.Equal(t, "btc", list[0].Symbol)
+	require.Equal(t, int64(30), list[0].PeriodSec)
+	require.Equal(t, "eth", list[1].Symbol)
+	require.Equal(t, StatePending, list[1].State)
+	require.False(t, list[1].Running)
+}
@@ -1,9 +1,27 @@
 package service
 
-import "strings"
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-observer/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Откуда взялось соответствие тикер → id; чем ниже в списке, тем приоритетнее
+const (
+	SymbolSourceBuiltin   = "builtin"   // встроенная карта популярных монет
+	SymbolSourceFile      = "file"      // файл symbols.file из конфига
+	SymbolSourceWatchlist = "watchlist" // id, определённый по справочнику при /currency/add
+	SymbolSourceAdmin     = "admin"     // задано через /admin/symbols, хранится в БД
+)
 
 // Базовая карта популярных монет
-var cgID = map[string]string{
+var builtinIDs = map[string]string{
 	"btc":  "bitcoin",
 	"eth":  "ethereum",
 	"bnb":  "binancecoin",
@@ -16,11 +34,103 @@ var cgID = map[string]string{
 	"trx":  "tron",
 }
 
-func toCoingeckoID(sym string) string {
-	s := strings.ToLower(strings.TrimSpace(sym))
-	if id, ok := cgID[s]; ok {
-		return id
+// SymbolRegistry — соответствие локальных тикеров id монет CoinGecko.
+// База (builtin + файл) перекрывается оверрайдами (watchlist + admin).
+// Коллизии тикеров решаются локальными алиасами: "uni" → uniswap,
+// "uni-universe" → universe-token — отслеживать можно оба.
+type SymbolRegistry struct {
+	mu        sync.RWMutex
+	base      map[string]model.SymbolMapping
+	overrides map[string]model.SymbolMapping
+}
+
+func NewSymbolRegistry() *SymbolRegistry {
+	r := &SymbolRegistry{
+		base:      make(map[string]model.SymbolMapping, len(builtinIDs)),
+		overrides: make(map[string]model.SymbolMapping),
+	}
+	for sym, id := range builtinIDs {
+		r.base[sym] = model.SymbolMapping{Symbol: sym, ProviderID: id, Source: SymbolSourceBuiltin}
+	}
+	return r
+}
+
+// LoadFile читает карту тикер → id из YAML или JSON (JSON — подмножество YAML).
+func (r *SymbolRegistry) LoadFile(path string) (int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var m map[string]string
+	if err := yaml.Unmarshal(raw, &m); err != nil {
+		return 0, fmt.Errorf("symbols file %s: %w", path, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for sym, id := range m {
+		sym = normSymbol(sym)
+		r.base[sym] = model.SymbolMapping{Symbol: sym, ProviderID: strings.TrimSpace(id), Source: SymbolSourceFile}
+	}
+	return len(m), nil
+}
+
+// Lookup — id монеты для тикера, если соответствие задано явно
+func (r *SymbolRegistry) Lookup(sym string) (model.SymbolMapping, bool) {
+	s := normSymbol(sym)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if m, ok := r.overrides[s]; ok {
+		return m, true
+	}
+	m, ok := r.base[s]
+	return m, ok
+}
+
+// CoingeckoID — id для запроса к CoinGecko; без соответствия пробуем тикер как есть.
+func (r *SymbolRegistry) CoingeckoID(sym string) string {
+	if m, ok := r.Lookup(sym); ok {
+		return m.ProviderID
+	}
+	return normSymbol(sym)
+}
+
+// set кладёт оверрайд; запись из watchlist не перетирает админскую.
+func (r *SymbolRegistry) set(m model.SymbolMapping) {
+	m.Symbol = normSymbol(m.Symbol)
+	if m.UpdatedAt == 0 {
+		m.UpdatedAt = time.Now().Unix()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cur, ok := r.overrides[m.Symbol]; ok && cur.Source == SymbolSourceAdmin && m.Source != SymbolSourceAdmin {
+		return
+	}
+	r.overrides[m.Symbol] = m
+}
+
+func (r *SymbolRegistry) remove(sym string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.overrides, normSymbol(sym))
+}
+
+// List — итоговое соответствие по всем слоям, отсортированное по тикеру
+func (r *SymbolRegistry) List() []model.SymbolMapping {
+	r.mu.RLock()
+	merged := make(map[string]model.SymbolMapping, len(r.base)+len(r.overrides))
+	for s, m := range r.base {
+		merged[s] = m
+	}
+	for s, m := range r.overrides {
+		merged[s] = m
+	}
+	r.mu.RUnlock()
+
+	out := make([]model.SymbolMapping, 0, len(merged))
+	for _, m := range merged {
+		out = append(out, m)
 	}
-	// Фоллбэк: пробуем как есть (вдруг уже совпадает с ID CoinGecko)
-	return s
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out
 }
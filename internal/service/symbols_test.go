@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"crypto-observer/internal/coingecko"
+	"crypto-observer/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolRegistry_BuiltinAndFallback(t *testing.T) {
+	r := NewSymbolRegistry()
+	require.Equal(t, "bitcoin", r.CoingeckoID(" BTC "))
+	require.Equal(t, "some-coin", r.CoingeckoID("some-coin"), "unknown ticker is used as id")
+
+	m, ok := r.Lookup("eth")
+	require.True(t, ok)
+	require.Equal(t, SymbolSourceBuiltin, m.Source)
+}
+
+func TestSymbolRegistry_LoadFile_YAMLAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	yml := filepath.Join(dir, "symbols.yaml")
+	require.NoError(t, os.WriteFile(yml, []byte("uni: uniswap\nBTC: wrapped-bitcoin\n"), 0o644))
+	js := filepath.Join(dir, "symbols.json")
+	require.NoError(t, os.WriteFile(js, []byte(`{"pepe": "pepe"}`), 0o644))
+
+	r := NewSymbolRegistry()
+	n, err := r.LoadFile(yml)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	_, err = r.LoadFile(js)
+	require.NoError(t, err)
+
+	require.Equal(t, "uniswap", r.CoingeckoID("uni"))
+	require.Equal(t, "wrapped-bitcoin", r.CoingeckoID("btc"), "file overrides builtin")
+	require.Equal(t, "pepe", r.CoingeckoID("pepe"))
+
+	m, _ := r.Lookup("uni")
+	require.Equal(t, SymbolSourceFile, m.Source)
+
+	_, err = r.LoadFile(filepath.Join(dir, "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestSymbolRegistry_AdminBeatsWatchlist(t *testing.T) {
+	r := NewSymbolRegistry()
+	r.set(model.SymbolMapping{Symbol: "uni", ProviderID: "uniswap", Source: SymbolSourceAdmin})
+	r.set(model.SymbolMapping{Symbol: "uni", ProviderID: "universe-token", Source: SymbolSourceWatchlist})
+	require.Equal(t, "uniswap", r.CoingeckoID("uni"))
+
+	r.remove("uni")
+	require.Equal(t, "uni", r.CoingeckoID("uni"))
+}
+
+func TestService_SetSymbol_ConflictAndOverride(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+
+	err := s.SetSymbol("btc", "wrapped-bitcoin", false)
+	require.ErrorIs(t, err, model.ErrSymbolExists, "builtin btc must not be silently replaced")
+
+	require.NoError(t, s.SetSymbol("btc", "wrapped-bitcoin", true))
+	require.Equal(t, "wrapped-bitcoin", s.symbols.CoingeckoID("btc"))
+	require.Equal(t, "wrapped-bitcoin", fs.mappings["btc"].ProviderID)
+
+	// алиас для второй монеты с тем же биржевым тикером
+	require.NoError(t, s.SetSymbol("uni-universe", "universe-token", false))
+	require.Equal(t, "universe-token", s.symbols.CoingeckoID("uni-universe"))
+
+	require.NoError(t, s.DeleteSymbol("btc"))
+	require.Equal(t, "bitcoin", s.symbols.CoingeckoID("btc"), "delete falls back to builtin")
+	_, ok := fs.mappings["btc"]
+	require.False(t, ok)
+}
+
+func TestService_SetSymbol_UnknownInCatalog(t *testing.T) {
+	s := newSvcWith(&fakeStorage{})
+	c := NewCoinCatalog(&fakeLister{coins: []coingecko.Coin{{ID: "uniswap", Symbol: "uni"}}}, 0)
+	require.NoError(t, c.Refresh(context.Background()))
+	s.UseCatalog(c)
+
+	err := s.SetSymbol("uni", "uniswapp", true)
+	require.ErrorIs(t, err, model.ErrUnknownCoin)
+}
+
+func TestService_Restore_LoadsAdminMappings(t *testing.T) {
+	fs := &fakeStorage{mappings: map[string]model.SymbolMapping{
+		"uni": {Symbol: "uni", ProviderID: "uniswap", UpdatedAt: 1},
+	}}
+	s := newSvcWith(fs)
+
+	require.NoError(t, s.Restore(context.Background()))
+	m, ok := s.symbols.Lookup("uni")
+	require.True(t, ok)
+	require.Equal(t, SymbolSourceAdmin, m.Source)
+	require.Equal(t, "uniswap", m.ProviderID)
+}
+
+func TestService_AddCurrency_UsesRegistryBeforeCatalog(t *testing.T) {
+	fs := &fakeStorage{}
+	s := newSvcWith(fs)
+	s.UseCatalog(loadedCatalog(t)) // в справочнике "uni" неоднозначен
+	require.NoError(t, s.SetSymbol("uni", "uniswap", false))
+
+	require.NoError(t, s.AddCurrency(model.AddReq{Symbol: "uni", Period: 3600}))
+	require.Equal(t, "uniswap", fs.watch["uni"].ProviderID)
+
+	_ = s.RemoveCurrency("uni")
+	sleepMS(20)
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"crypto-observer/internal/model"
+	"crypto-observer/pkg/logger"
+)
+
+// Заголовки исходящего вебхука
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+type webhookStore interface {
+	EnqueueDelivery(ctx context.Context, d model.WebhookDelivery) error
+	ClaimDeliveries(ctx context.Context, now, leaseUntil int64, limit int) ([]model.WebhookDelivery, error)
+	FinishDelivery(ctx context.Context, d model.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, status string, limit int) ([]model.WebhookDelivery, error)
+}
+
+// WebhookEndpoint — получатель вебхуков. Events пустой — все события.
+type WebhookEndpoint struct {
+	Name   string
+	URL    string
+	Secret string
+	Events []string
+}
+
+type WebhookOptions struct {
+	MaxAttempts int           // после стольких неудач доставка — failed
+	Backoff     time.Duration // задержка перед первым повтором, дальше x2
+	MaxBackoff  time.Duration
+	Timeout     time.Duration // таймаут одного POST
+	Poll        time.Duration // как часто смотреть в очередь без новых событий
+	Batch       int           // сколько доставок забирать за раз
+}
+
+// Webhooks — исходящие уведомления с очередью в Postgres.
+// Notify только кладёт событие в очередь (по строке на получателя);
+// отправляет фоновый воркер, повторяя неудачи с экспоненциальной задержкой.
+// Секреты в БД не пишутся: доставка ссылается на получателя по имени.
+type Webhooks struct {
+	st        webhookStore
+	endpoints map[string]WebhookEndpoint
+	opts      WebhookOptions
+	client    *http.Client
+	now       func() time.Time
+	wake      chan struct{}
+}
+
+func NewWebhooks(st webhookStore, endpoints []WebhookEndpoint, opts WebhookOptions) *Webhooks {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 5 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.Poll <= 0 {
+		opts.Poll = time.Second
+	}
+	if opts.Batch <= 0 {
+		opts.Batch = 20
+	}
+	byName := make(map[string]WebhookEndpoint, len(endpoints))
+	for _, e := range endpoints {
+		byName[e.Name] = e
+	}
+	return &Webhooks{
+		st:        st,
+		endpoints: byName,
+		opts:      opts,
+		client:    &http.Client{Timeout: opts.Timeout},
+		now:       time.Now,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Notify ставит событие в очередь всем подписанным получателям.
+// Ошибки только логируются: уведомления не должны ломать сбор цен.
+func (w *Webhooks) Notify(event string, data any) {
+	now := w.now().Unix()
+	body, err := json.Marshal(model.WebhookEvent{Event: event, Timestamp: now, Data: data})
+	if err != nil {
+		logger.L().WithError(err).WithField("event", event).Error("Webhooks: marshal failed")
+		return
+	}
+	queued := 0
+	for _, e := range w.endpoints {
+		if len(e.Events) > 0 && !slices.Contains(e.Events, event) {
+			continue
+		}
+		d := model.WebhookDelivery{
+			Endpoint:      e.Name,
+			URL:           e.URL,
+			Event:         event,
+			Payload:       string(body),
+			Status:        model.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+		if err := w.st.EnqueueDelivery(context.Background(), d); err != nil {
+			logger.L().WithError(err).WithFields(logger.Fields{"event": event, "endpoint": e.Name}).Error("Webhooks: enqueue failed")
+			continue
+		}
+		queued++
+	}
+	if queued > 0 {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start запускает воркер доставки до отмены ctx.
+func (w *Webhooks) Start(ctx context.Context) {
+	go func() {
+		t := time.NewTicker(w.opts.Poll)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+			case <-w.wake:
+			case <-ctx.Done():
+				return
+			}
+			for {
+				n, err := w.deliverDue(ctx)
+				if err != nil {
+					logger.L().WithError(err).Error("Webhooks: claim failed")
+				}
+				// полная пачка — в очереди, вероятно, есть ещё
+				if n < w.opts.Batch || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}()
+	logger.L().WithField("endpoints", len(w.endpoints)).Info("Webhooks: start")
+}
+
+// Deliveries — журнал доставок, новые сначала.
+func (w *Webhooks) Deliveries(status string, limit int) ([]model.WebhookDelivery, error) {
+	return w.st.ListDeliveries(context.Background(), status, limit)
+}
+
+// deliverDue отправляет доставки, чей срок подошёл; возвращает, сколько забрал из очереди.
+func (w *Webhooks) deliverDue(ctx context.Context) (int, error) {
+	now := w.now()
+	// аренда с запасом на все отправки пачки
+	lease := now.Add(w.opts.Timeout*time.Duration(w.opts.Batch) + time.Minute)
+	batch, err := w.st.ClaimDeliveries(ctx, now.Unix(), lease.Unix(), w.opts.Batch)
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range batch {
+		res := w.send(ctx, d)
+		if ctx.Err() != nil {
+			// остановка посреди пачки — попытку не засчитываем, доставка вернётся в очередь по истечении аренды
+			return len(batch), nil
+		}
+		w.finish(ctx, d, res)
+	}
+	return len(batch), nil
+}
+
+// sendResult — итог одной попытки: код ответа (0 — ответа не было) и ошибка.
+// final — повторять бессмысленно.
+type sendResult struct {
+	code  int
+	err   error
+	final bool
+}
+
+func (w *Webhooks) send(ctx context.Context, d model.WebhookDelivery) sendResult {
+	e, ok := w.endpoints[d.Endpoint]
+	if !ok {
+		return sendResult{err: fmt.Errorf("endpoint %q is no longer configured", d.Endpoint), final: true}
+	}
+	ts := w.now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		return sendResult{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, d.Event)
+	req.Header.Set(HeaderWebhookDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderWebhookSignature, SignWebhook(e.Secret, ts, []byte(d.Payload)))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return sendResult{err: err}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return sendResult{code: resp.StatusCode, err: fmt.Errorf("receiver responded %s", resp.Status)}
+	}
+	return sendResult{code: resp.StatusCode}
+}
+
+// finish записывает итог попытки: успех, повтор с задержкой или окончательный отказ.
+func (w *Webhooks) finish(ctx context.Context, d model.WebhookDelivery, res sendResult) {
+	now := w.now()
+	d.Attempts++
+	d.LastCode = res.code
+	d.LastError = ""
+	log := logger.L().WithFields(logger.Fields{"delivery": d.ID, "endpoint": d.Endpoint, "event": d.Event, "attempt": d.Attempts})
+	switch {
+	case res.err == nil:
+		d.Status, d.DeliveredAt = model.DeliveryDelivered, now.Unix()
+		log.Info("Webhooks: delivered")
+	case res.final || d.Attempts >= w.opts.MaxAttempts:
+		d.Status, d.LastError = model.DeliveryFailed, res.err.Error()
+		log.WithError(res.err).Error("Webhooks: giving up")
+	default:
+		d.LastError = res.err.Error()
+		d.NextAttemptAt = now.Add(w.retryDelay(d.Attempts)).Unix()
+		log.WithError(res.err).Warn("Webhooks: attempt failed, will retry")
+	}
+	if err := w.st.FinishDelivery(ctx, d); err != nil {
+		log.WithError(err).Error("Webhooks: save result failed")
+	}
+}
+
+// retryDelay — задержка перед повтором после attempts неудачных попыток: Backoff × 2^(attempts-1).
+func (w *Webhooks) retryDelay(attempts int) time.Duration {
+	d := w.opts.Backoff
+	for i := 1; i < attempts && d < w.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, w.opts.MaxBackoff)
+}
+
+// SignWebhook — подпись тела вебхука: "sha256=" + hex(HMAC-SHA256(secret, "<ts>.<body>")).
+// Получатель пересчитывает её по заголовку X-Webhook-Timestamp и сырому телу.
+func SignWebhook(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook проверяет подпись в постоянном времени.
+func VerifyWebhook(secret string, ts int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, ts, body)), []byte(signature))
+}
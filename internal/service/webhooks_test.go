@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"crypto-observer/internal/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memQueue — очередь доставок в памяти с той же семантикой аренды, что и в БД.
+type memQueue struct {
+	mu  sync.Mutex
+	seq int64
+	ds  map[int64]model.WebhookDelivery
+}
+
+func (q *memQueue) EnqueueDelivery(ctx context.Context, d model.WebhookDelivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ds == nil {
+		q.ds = make(map[int64]model.WebhookDelivery)
+	}
+	q.seq++
+	d.ID = q.seq
+	q.ds[d.ID] = d
+	return nil
+}
+
+func (q *memQueue) ClaimDeliveries(ctx context.Context, now, leaseUntil int64, limit int) ([]model.WebhookDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []model.WebhookDelivery
+	for _, d := range q.sorted() {
+		if len(out) == limit {
+			break
+		}
+		if d.Status == model.DeliveryPending && d.NextAttemptAt <= now {
+			d.NextAttemptAt = leaseUntil
+			q.ds[d.ID] = d
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (q *memQueue) FinishDelivery(ctx context.Context, d model.WebhookDelivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ds[d.ID] = d
+	return nil
+}
+
+func (q *memQueue) ListDeliveries(ctx context.Context, status string, limit int) ([]model.WebhookDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.sorted(), nil
+}
+
+func (q *memQueue) sorted() []model.WebhookDelivery {
+	out := make([]model.WebhookDelivery, 0, len(q.ds))
+	for _, d := range q.ds {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (q *memQueue) get(id int64) model.WebhookDelivery {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.ds[id]
+}
+
+// fakeClock — управляемое время для проверки задержек повторов.
+type fakeClock struct{ t atomic.Int64 }
+
+func (c *fakeClock) now() time.Time          { return time.Unix(c.t.Load(), 0) }
+func (c *fakeClock) advance(d time.Duration) { c.t.Add(int64(d / time.Second)) }
+
+func newTestWebhooks(q *memQueue, endpoints []WebhookEndpoint, opts WebhookOptions) (*Webhooks, *fakeClock) {
+	w := NewWebhooks(q, endpoints, opts)
+	clk := &fakeClock{}
+	clk.t.Store(1_000_000)
+	w.now = clk.now
+	return w, clk
+}
+
+func TestWebhooks_Notify_FiltersByEvent(t *testing.T) {
+	q := &memQueue{}
+	w, _ := newTestWebhooks(q, []WebhookEndpoint{
+		{Name: "all", URL: "http://a"},
+		{Name: "alerts", URL: "http://b", Events: []string{model.EventAlertFired}},
+	}, WebhookOptions{})
+
+	w.Notify(model.EventCollectorError, model.CollectorErrorData{Symbol: "btc"})
+	w.Notify(model.EventAlertFired, model.AlertFiredData{AlertID: 1})
+
+	got := q.sorted()
+	require.Len(t, got, 3)
+	require.Equal(t, "all", got[0].Endpoint)
+	require.Equal(t, model.EventCollectorError, got[0].Event)
+	require.Equal(t, model.DeliveryPending, got[0].Status)
+	require.ElementsMatch(t, []string{"all", "alerts"}, []string{got[1].Endpoint, got[2].Endpoint})
+}
+
+func TestWebhooks_DeliversSignedPayload(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.Header.Clone(), body}
+	}))
+	defer srv.Close()
+
+	q := &memQueue{}
+	w, clk := newTestWebhooks(q, []WebhookEndpoint{{Name: "ops", URL: srv.URL, Secret: "s3cret"}}, WebhookOptions{})
+	w.Notify(model.EventAlertFired, model.AlertFiredData{AlertID: 7, Symbol: "btc", Price: model.MustDecimal("70000.01")})
+
+	n, err := w.deliverDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	r := <-got
+	require.Equal(t, model.EventAlertFired, r.header.Get(HeaderWebhookEvent))
+	require.Equal(t, "1", r.header.Get(HeaderWebhookDelivery))
+	ts, err := strconv.ParseInt(r.header.Get(HeaderWebhookTimestamp), 10, 64)
+	require.NoError(t, err)
+	require.Equal(t, clk.now().Unix(), ts)
+	require.True(t, VerifyWebhook("s3cret", ts, r.body, r.header.Get(HeaderWebhookSignature)))
+	require.False(t, VerifyWebhook("other", ts, r.body, r.header.Get(HeaderWebhookSignature)))
+
+	var ev struct {
+		Event string               `json:"event"`
+		Data  model.AlertFiredData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(r.body, &ev))
+	require.Equal(t, model.EventAlertFired, ev.Event)
+	require.Equal(t, "70000.01", ev.Data.Price.String())
+
+	d := q.get(1)
+	require.Equal(t, model.DeliveryDelivered, d.Status)
+	require.Equal(t, 1, d.Attempts)
+	require.Equal(t, http.StatusOK, d.LastCode)
+}
+
+func TestWebhooks_RetriesWithBackoff(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	q := &memQueue{}
+	w, clk := newTestWebhooks(q, []WebhookEndpoint{{Name: "ops", URL: srv.URL}},
+		WebhookOptions{Backoff: 10 * time.Second, MaxAttempts: 5})
+	w.Notify(model.EventCollectorError, model.CollectorErrorData{Symbol: "btc"})
+	ctx := context.Background()
+
+	_, _ = w.deliverDue(ctx)
+	d := q.get(1)
+	require.Equal(t, model.DeliveryPending, d.Status)
+	require.Equal(t, 1, d.Attempts)
+	require.Equal(t, http.StatusServiceUnavailable, d.LastCode)
+	require.Contains(t, d.LastError, "503")
+	require.Equal(t, clk.now().Add(10*time.Second).Unix(), d.NextAttemptAt)
+
+	// до истечения задержки повтора нет
+	n, _ := w.deliverDue(ctx)
+	require.Zero(t, n)
+
+	clk.advance(10 * time.Second)
+	_, _ = w.deliverDue(ctx)
+	require.Equal(t, clk.now().Add(20*time.Second).Unix(), q.get(1).NextAttemptAt, "delay doubles")
+
+	clk.advance(20 * time.Second)
+	_, _ = w.deliverDue(ctx)
+	d = q.get(1)
+	require.Equal(t, model.DeliveryDelivered, d.Status)
+	require.Equal(t, 3, d.Attempts)
+	require.Empty(t, d.LastError)
+}
+
+func TestWebhooks_GivesUpAfterMaxAttempts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	q := &memQueue{}
+	w, clk := newTestWebhooks(q, []WebhookEndpoint{{Name: "ops", URL: srv.URL}},
+		WebhookOptions{Backoff: time.Second, MaxAttempts: 2})
+	w.Notify(model.EventCollectorError, nil)
+
+	_, _ = w.deliverDue(context.Background())
+	clk.advance(time.Second)
+	_, _ = w.deliverDue(context.Background())
+
+	d := q.get(1)
+	require.Equal(t, model.DeliveryFailed, d.Status)
+	require.Equal(t, 2, d.Attempts)
+	clk.advance(time.Hour)
+	n, _ := w.deliverDue(context.Background())
+	require.Zero(t, n, "failed deliveries are not retried")
+}
+
+func TestWebhooks_RemovedEndpointFailsAtOnce(t *testing.T) {
+	q := &memQueue{}
+	_ = q.EnqueueDelivery(context.Background(), model.WebhookDelivery{Endpoint: "gone", Status: model.DeliveryPending})
+	w, _ := newTestWebhooks(q, nil, WebhookOptions{})
+
+	_, _ = w.deliverDue(context.Background())
+	require.Equal(t, model.DeliveryFailed, q.get(1).Status)
+	require.Contains(t, q.get(1).LastError, "no longer configured")
+}
+
+func TestWebhooks_RetryDelayCapped(t *testing.T) {
+	w := NewWebhooks(&memQueue{}, nil, WebhookOptions{Backoff: time.Second, MaxBackoff: 5 * time.Second})
+	require.Equal(t, time.Second, w.retryDelay(1))
+	require.Equal(t, 4*time.Second, w.retryDelay(3))
+	require.Equal(t, 5*time.Second, w.retryDelay(4))
+	require.Equal(t, 5*time.Second, w.retryDelay(60))
+}
+
+func TestCollector_OnFail_OncePerTransition(t *testing.T) {
+	c := newCollector("btc", nil, time.Hour, &memStorage{}, &fakePriceClient{})
+	var states []string
+	c.onFail = func(state string, err error) { states = append(states, state) }
+
+	c.setResult(errors.New("boom"))
+	c.setResult(errors.New("boom again"))
+	c.setResult(nil)
+	c.setResult(model.ErrUnknownCoin)
+	c.setResult(errors.New("boom"))
+
+	require.Equal(t, []string{StateError, StateUnknownCoin, StateError}, states)
+}
+
+func TestService_AlertFired_NotifiesWebhooks(t *testing.T) {
+	q := &memQueue{}
+	s := newSvcWith(&fakeStorage{})
+	w, _ := newTestWebhooks(q, []WebhookEndpoint{{Name: "ops", URL: "http://x"}}, WebhookOptions{})
+	s.UseWebhooks(w)
+
+	a, err := s.CreateAlert(model.AlertReq{Symbol: "btc", Kind: model.AlertBelow, Threshold: model.MustDecimal("100")})
+	require.NoError(t, err)
+	s.evaluateAlerts(btcAt(5, "99.5"))
+
+	got := q.sorted()
+	require.Len(t, got, 1)
+	require.Equal(t, model.EventAlertFired, got[0].Event)
+	var ev struct{ Data model.AlertFiredData }
+	require.NoError(t, json.Unmarshal([]byte(got[0].Payload), &ev))
+	require.Equal(t, a.ID, ev.Data.AlertID)
+	require.Equal(t, "99.5", ev.Data.Price.String())
+
+	list, err := s.WebhookDeliveries("", 0)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	_, err = s.WebhookDeliveries("bogus", 0)
+	require.ErrorIs(t, err, model.ErrInvalidDeliveryStatus)
+}
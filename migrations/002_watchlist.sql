@@ -0,0 +1,10 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS watchlist (
+    symbol      VARCHAR(32) PRIMARY KEY,
+    period_s    INTEGER     NOT NULL,
+    created_at  BIGINT      NOT NULL,
+    paused      BOOLEAN     NOT NULL DEFAULT FALSE
+    );
+
+COMMIT;
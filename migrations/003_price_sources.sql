@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE prices ADD COLUMN IF NOT EXISTS sources      INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE prices ADD COLUMN IF NOT EXISTS spread_cents BIGINT  NOT NULL DEFAULT 0;
+
+COMMIT;
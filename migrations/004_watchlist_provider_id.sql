@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS provider_id VARCHAR(128) NOT NULL DEFAULT '';
+
+COMMIT;
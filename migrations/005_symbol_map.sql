@@ -0,0 +1,9 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS symbol_map (
+    symbol      VARCHAR(32)  PRIMARY KEY,
+    provider_id VARCHAR(128) NOT NULL,
+    updated_at  BIGINT       NOT NULL
+    );
+
+COMMIT;
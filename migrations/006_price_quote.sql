@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE prices ADD COLUMN IF NOT EXISTS quote VARCHAR(10) NOT NULL DEFAULT 'usd';
+CREATE INDEX IF NOT EXISTS idx_prices_symbol_quote_ts ON prices(symbol, quote, ts DESC);
+
+ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS quotes TEXT[] NOT NULL DEFAULT '{usd}';
+
+COMMIT;
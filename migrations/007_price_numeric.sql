@@ -0,0 +1,10 @@
+BEGIN;
+
+-- центы в BIGINT обнуляли монеты дешевле цента; храним точные десятичные значения
+ALTER TABLE prices ALTER COLUMN price_cents TYPE NUMERIC USING round(price_cents::numeric / 100, 2);
+ALTER TABLE prices RENAME COLUMN price_cents TO price;
+
+ALTER TABLE prices ALTER COLUMN spread_cents TYPE NUMERIC USING round(spread_cents::numeric / 100, 2);
+ALTER TABLE prices RENAME COLUMN spread_cents TO spread;
+
+COMMIT;
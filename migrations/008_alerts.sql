@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS alerts (
+    id          BIGSERIAL   PRIMARY KEY,
+    symbol      VARCHAR(32) NOT NULL,
+    quote       VARCHAR(10) NOT NULL DEFAULT 'usd',
+    kind        VARCHAR(16) NOT NULL,
+    threshold   NUMERIC     NOT NULL,
+    window_s    BIGINT      NOT NULL DEFAULT 0,
+    cooldown_s  BIGINT      NOT NULL DEFAULT 0,
+    state       VARCHAR(16) NOT NULL DEFAULT 'armed',
+    fired_at    BIGINT      NOT NULL DEFAULT 0,
+    fired_price NUMERIC     NOT NULL DEFAULT 0,
+    created_at  BIGINT      NOT NULL
+);
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              BIGSERIAL   PRIMARY KEY,
+    endpoint        VARCHAR(64) NOT NULL,
+    url             TEXT        NOT NULL,
+    event           VARCHAR(64) NOT NULL,
+    payload         TEXT        NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT      NOT NULL,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    last_code       INTEGER     NOT NULL DEFAULT 0,
+    created_at      BIGINT      NOT NULL,
+    delivered_at    BIGINT      NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS aligned BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMIT;
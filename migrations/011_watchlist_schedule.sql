@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS cron    TEXT  NOT NULL DEFAULT '';
+ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS windows JSONB NOT NULL DEFAULT '[]';
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+    id          BIGSERIAL    PRIMARY KEY,
+    symbol      VARCHAR(32)  NOT NULL,
+    provider_id VARCHAR(128) NOT NULL,
+    quote       VARCHAR(10)  NOT NULL,
+    from_ts     BIGINT       NOT NULL,
+    to_ts       BIGINT       NOT NULL,
+    cursor_ts   BIGINT       NOT NULL,
+    state       VARCHAR(16)  NOT NULL DEFAULT 'pending',
+    fetched     INTEGER      NOT NULL DEFAULT 0,
+    inserted    INTEGER      NOT NULL DEFAULT 0,
+    error       TEXT         NOT NULL DEFAULT '',
+    created_at  BIGINT       NOT NULL,
+    started_at  BIGINT       NOT NULL DEFAULT 0,
+    updated_at  BIGINT       NOT NULL DEFAULT 0,
+    finished_at BIGINT       NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_backfill_jobs_active ON backfill_jobs(id) WHERE state IN ('pending', 'running');
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+-- одна цена на (symbol, quote, ts): догрузка истории и коллектор пишут через ON CONFLICT
+DELETE FROM prices a USING prices b
+WHERE a.symbol = b.symbol AND a.quote = b.quote AND a.ts = b.ts AND a.id > b.id;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_prices_symbol_quote_ts ON prices(symbol, quote, ts);
+DROP INDEX IF EXISTS idx_prices_symbol_quote_ts;
+
+COMMIT;
@@ -18,18 +18,86 @@ type Config struct {
 
 	Collector struct {
 		DefaultPeriodSeconds int `yaml:"default_period_seconds"` // N секунд по умолчанию
+		BatchWindowMs        int `yaml:"batch_window_ms"`        // окно склейки запросов в один; 0 — без склейки
 	} `yaml:"collector"`
 
 	Coingecko struct {
-		BaseURL    string `yaml:"base_url"`  // https://api.coingecko.com/api/v3
-		TimeoutSec int    `yaml:"timeout_s"` // 5
+		BaseURL      string  `yaml:"base_url"`       // https://api.coingecko.com/api/v3
+		TimeoutSec   int     `yaml:"timeout_s"`      // 5
+		MaxRetries   int     `yaml:"max_retries"`    // повторы на 429/5xx/сетевых ошибках
+		BackoffMs    int     `yaml:"backoff_ms"`     // первая задержка, дальше x2 с джиттером
+		MaxBackoffMs int     `yaml:"max_backoff_ms"` // потолок задержки
+		RatePerMin   float64 `yaml:"rate_per_min"`   // клиентский лимит запросов; 0 — без лимита
+		Burst        int     `yaml:"burst"`
+		// как часто обновлять справочник /coins/list; 0 — тикеры не проверяются
+		CoinsRefreshMin int `yaml:"coins_refresh_min"`
 	} `yaml:"coingecko"`
 
+	// Источники цен: провайдер по умолчанию и персональные привязки тикеров
+	Providers struct {
+		Default string            `yaml:"default"` // coingecko|binance|kraken|coinbase|consensus
+		Symbols map[string]string `yaml:"symbols"` // тикер → провайдер, например btc: binance
+	} `yaml:"providers"`
+
+	// Консенсус нескольких источников; регистрируется как провайдер "consensus"
+	Consensus struct {
+		Providers       []string `yaml:"providers"`         // например [coingecko, binance, kraken]
+		Method          string   `yaml:"method"`            // median|trimmed_mean — среднее без n/4 крайних котировок с каждой стороны
+		MinSources      int      `yaml:"min_sources"`       // минимум согласных источников
+		MaxDeviationPct float64  `yaml:"max_deviation_pct"` // порог отсева выбросов от медианы, %
+	} `yaml:"consensus"`
+
+	Symbols struct {
+		File string `yaml:"file"` // YAML/JSON с картой тикер → id CoinGecko; пусто — не грузим
+	} `yaml:"symbols"`
+
+	Binance  ProviderConfig `yaml:"binance"`
+	Kraken   ProviderConfig `yaml:"kraken"`
+	Coinbase ProviderConfig `yaml:"coinbase"`
+
+	// Исходящие вебхуки о срабатывании правил и сбоях коллекторов; без endpoints — выключены
+	Webhooks struct {
+		Endpoints     []WebhookEndpoint `yaml:"endpoints"`
+		MaxAttempts   int               `yaml:"max_attempts"`  // после стольких неудач доставка — failed; 8
+		BackoffSec    int               `yaml:"backoff_s"`     // первая задержка повтора, дальше x2; 5
+		MaxBackoffSec int               `yaml:"max_backoff_s"` // потолок задержки; 3600
+		TimeoutSec    int               `yaml:"timeout_s"`     // таймаут одного POST; 5
+		PollMs        int               `yaml:"poll_ms"`       // как часто смотреть в очередь; 1000
+	} `yaml:"webhooks"`
+
+	// Догрузка истории из /coins/{id}/market_chart/range CoinGecko
+	Backfill struct {
+		ChunkDays int `yaml:"chunk_days"` // дней на запрос: 1 — точки каждые ~5 мин, до 90 — часовые; 90
+		BatchSize int `yaml:"batch_size"` // цен в одном INSERT; 500
+	} `yaml:"backfill"`
+
+	// Аудит разрывов в истории цен (GET /currency/gaps работает и без фонового прохода)
+	Gaps struct {
+		Factor      int  `yaml:"factor"`       // разрыв — пропущено от factor тиков расписания; 3
+		LookbackH   int  `yaml:"lookback_h"`   // сколько часов истории проверять; 24
+		IntervalMin int  `yaml:"interval_min"` // как часто проходить по коллекторам; 0 — фоновый аудит выключен
+		Backfill    bool `yaml:"backfill"`     // ставить догрузку истории на найденные разрывы
+	} `yaml:"gaps"`
+
 	Log struct {
 		Level string `yaml:"level"` // info|debug|warn|error
 	} `yaml:"log"`
 }
 
+// ProviderConfig — общие настройки HTTP-адаптера биржи; пустой base_url — адаптер выключен.
+type ProviderConfig struct {
+	BaseURL    string `yaml:"base_url"`
+	TimeoutSec int    `yaml:"timeout_s"`
+}
+
+// WebhookEndpoint — получатель вебхуков; events пустой — все события.
+type WebhookEndpoint struct {
+	Name   string   `yaml:"name"`
+	URL    string   `yaml:"url"`
+	Secret string   `yaml:"secret"` // ключ HMAC-подписи
+	Events []string `yaml:"events"` // alert.fired, collector.error
+}
+
 var cfg Config
 
 func MustLoad() *Config {